@@ -0,0 +1,62 @@
+package ionos
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// adoptInstances registers the group servers left by a previous run of the
+// plugin and continues the instance counter after the highest index found,
+// so a restart neither loses track of running instances nor reuses names.
+func (i *InstanceGroup) adoptInstances(ctx context.Context) error {
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return err
+	}
+
+	standby, err := i.serverLabel(ctx, labelStandby)
+	if err != nil {
+		return err
+	}
+
+	stopped, err := i.serverLabel(ctx, labelStopped)
+	if err != nil {
+		return err
+	}
+
+	maxIndex := int32(0)
+	for _, server := range servers {
+		i.registry.adopt(*server.Id)
+		if _, ok := standby[*server.Id]; ok {
+			i.registry.setStandby(*server.Id, true)
+		}
+		if _, ok := stopped[*server.Id]; ok {
+			i.registry.setStopped(*server.Id, true)
+		}
+		if index, ok := i.instanceIndex(*server.Properties.Name); ok && index > maxIndex {
+			maxIndex = index
+		}
+	}
+	if maxIndex > i.instanceCounter.Load() {
+		i.instanceCounter.Store(maxIndex)
+	}
+
+	i.log.Info("Adopted existing instances", "count", len(servers), "instance_counter", maxIndex)
+	return nil
+}
+
+// instanceIndex parses the index out of a server name of the form
+// "<name>-<index>" or "<name>-<index>-<token>".
+func (i *InstanceGroup) instanceIndex(name string) (int32, bool) {
+	rest, ok := strings.CutPrefix(name, i.ServerSpec.Name+"-")
+	if !ok {
+		return 0, false
+	}
+	indexPart, _, _ := strings.Cut(rest, "-")
+	index, err := strconv.ParseInt(indexPart, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(index), true
+}
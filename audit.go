@@ -0,0 +1,113 @@
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const auditStderr = "stderr"
+
+// auditEvent is a line of the audit log, written for every resource the
+// plugin creates or deletes.
+type auditEvent struct {
+	Time         time.Time `json:"time"`
+	Group        string    `json:"group"`
+	Operation    string    `json:"operation"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   string    `json:"resource_id,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	Datacenter   string    `json:"datacenter,omitempty"`
+	RequestID    string    `json:"request_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// auditLog appends events as JSON lines to audit_log, a file or stderr. The
+// file is opened on the first event, so every entry point of the plugin
+// writes to it without opening it first.
+type auditLog struct {
+	once sync.Once
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+	err  error
+}
+
+func (a *auditLog) open(path string) error {
+	a.once.Do(func() {
+		if path == auditStderr {
+			a.w = os.Stderr
+			return
+		}
+		a.file, a.err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		a.w = a.file
+	})
+	return a.err
+}
+
+func (a *auditLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file, a.w = nil, nil
+	return err
+}
+
+type operationKey struct{}
+
+// withOperation records the fleeting call or background task that
+// initiated the API calls made with ctx. An operation already set is kept,
+// so nested calls are attributed to the outermost one.
+func withOperation(ctx context.Context, op string) context.Context {
+	if operation(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operation(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// audit writes e to the audit log, if configured, completed with the
+// initiating operation, the request ID and the error of the API call.
+func (i *InstanceGroup) audit(ctx context.Context, e auditEvent, apiResponse *shared.APIResponse, err error) {
+	if i.AuditLog == "" {
+		return
+	}
+	e.Time = time.Now().UTC()
+	e.Group = i.groupLabel()
+	e.Operation = operation(ctx)
+	e.RequestID = requestID(apiResponse)
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	if err := i.auditLog.open(i.AuditLog); err != nil {
+		i.log.Error("Failed to open audit log", "path", i.AuditLog, "err", err)
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	i.auditLog.mu.Lock()
+	defer i.auditLog.mu.Unlock()
+	if i.auditLog.w == nil {
+		return
+	}
+	if _, err := fmt.Fprintf(i.auditLog.w, "%s\n", line); err != nil {
+		i.log.Error("Failed to write audit log", "path", i.AuditLog, "err", err)
+	}
+}
@@ -0,0 +1,176 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const defaultAutoscalingURL = "https://api.ionos.com/autoscaling"
+
+// AutoscalingConfig delegates capacity management to an IONOS VM Auto
+// Scaling group. Increase and Decrease change the target replica count of
+// the group instead of creating and deleting servers one by one, the group's
+// replica configuration replaces server_spec.
+type AutoscalingConfig struct {
+	GroupID string `json:"group_id"`
+	// APIURL is the base URL of the VM Auto Scaling API.
+	APIURL string `json:"api_url"`
+}
+
+func (c AutoscalingConfig) enabled() bool {
+	return c.GroupID != ""
+}
+
+// The VM Auto Scaling API is not part of the bundled SDK, so groups are
+// read and written as raw JSON. Properties are kept as a map, as a PUT has to
+// send back all of them.
+type autoscalingGroup struct {
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+}
+
+type autoscalingServers struct {
+	Items []struct {
+		Properties struct {
+			DatacenterServer struct {
+				ID string `json:"id"`
+			} `json:"datacenterServer"`
+		} `json:"properties"`
+	} `json:"items"`
+}
+
+type autoscalingBackend struct {
+	i *InstanceGroup
+}
+
+func (b autoscalingBackend) url(path string) string {
+	base := b.i.Autoscaling.APIURL
+	if base == "" {
+		base = defaultAutoscalingURL
+	}
+	return strings.TrimSuffix(base, "/") + "/groups/" + b.i.Autoscaling.GroupID + path
+}
+
+func (b autoscalingBackend) group(ctx context.Context) (autoscalingGroup, error) {
+	group, _, err := withRetry(ctx, b.i, "AutoscalingGroupsFindById", func(ctx context.Context) (autoscalingGroup, *shared.APIResponse, error) {
+		var group autoscalingGroup
+		apiResponse, err := b.i.rawRequestURL(ctx, http.MethodGet, b.url(""), nil, &group)
+		return group, apiResponse, err
+	})
+	if err != nil {
+		return autoscalingGroup{}, fmt.Errorf("getting autoscaling group %s: %w", b.i.Autoscaling.GroupID, err)
+	}
+	return group, nil
+}
+
+// init takes the datacenter from the autoscaling group if datacenter_id is
+// not configured.
+func (b autoscalingBackend) init(ctx context.Context) error {
+	if b.i.DatacenterId != "" || len(b.i.Datacenters) > 0 {
+		return nil
+	}
+	group, err := b.group(ctx)
+	if err != nil {
+		return err
+	}
+	datacenter, _ := group.Properties["datacenter"].(map[string]any)
+	id, _ := datacenter["id"].(string)
+	if id == "" {
+		return fmt.Errorf("autoscaling group %s has no datacenter", b.i.Autoscaling.GroupID)
+	}
+	b.i.DatacenterId = id
+	return nil
+}
+
+func (b autoscalingBackend) members(ctx context.Context) (map[string]string, error) {
+	servers, _, err := withRetry(ctx, b.i, "AutoscalingGroupsServersGet", func(ctx context.Context) (autoscalingServers, *shared.APIResponse, error) {
+		var servers autoscalingServers
+		apiResponse, err := b.i.rawRequestURL(ctx, http.MethodGet, b.url("/servers?depth=1"), nil, &servers)
+		return servers, apiResponse, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing autoscaling group servers: %w", err)
+	}
+	members := make(map[string]string, len(servers.Items))
+	for _, item := range servers.Items {
+		if id := item.Properties.DatacenterServer.ID; id != "" {
+			members[id] = id
+		}
+	}
+	return members, nil
+}
+
+func (b autoscalingBackend) resize(ctx context.Context, delta int) (int, int, error) {
+	group, err := b.group(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	current := intProperty(group.Properties, "targetReplicaCount")
+	target := current + delta
+	if maxReplicas := intProperty(group.Properties, "maxReplicaCount"); maxReplicas > 0 && target > maxReplicas {
+		target = maxReplicas
+	}
+	if minReplicas := intProperty(group.Properties, "minReplicaCount"); target < minReplicas {
+		target = minReplicas
+	}
+	if target == current {
+		return current, target, nil
+	}
+
+	group.Properties["targetReplicaCount"] = target
+	if b.i.dryRun("would set autoscaling target replica count", "group", b.i.Autoscaling.GroupID, "current", current, "target", target) {
+		return current, target, nil
+	}
+	_, err = withRetryNoResult(ctx, b.i, "AutoscalingGroupsPut", func(ctx context.Context) (*shared.APIResponse, error) {
+		return b.i.rawRequestURL(ctx, http.MethodPut, b.url(""), map[string]any{"properties": group.Properties}, nil)
+	})
+	if err != nil {
+		return current, current, fmt.Errorf("updating autoscaling group %s: %w", b.i.Autoscaling.GroupID, err)
+	}
+	b.i.log.Info("Changed autoscaling target replica count", "group", b.i.Autoscaling.GroupID, "from", current, "to", target)
+	return current, target, nil
+}
+
+// remove lowers the target replica count by the number of servers first and
+// then deletes them, so the group does not replace the deleted servers. The
+// target is raised again for servers that could not be deleted.
+func (b autoscalingBackend) remove(ctx context.Context, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if _, _, err := b.resize(ctx, -len(ids)); err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	var err error
+	for _, id := range ids {
+		dc := b.i.datacenterOf(ctx, id)
+		if b.i.dryRun("would delete server", "id", id, "datacenter", dc) {
+			removed = append(removed, id)
+			continue
+		}
+		apiResponse, err2 := withRetryNoResult(ctx, b.i, "Decrease", func(ctx context.Context) (*shared.APIResponse, error) {
+			return b.i.api.DeleteServer(ctx, dc, id)
+		})
+		b.i.audit(ctx, auditEvent{Action: "delete", ResourceType: "server", ResourceID: id, Datacenter: dc}, apiResponse, err2)
+		if err2 != nil {
+			b.i.log.Error("Failed to delete instance", "err", err2, "id", id)
+			err = errors.Join(err, err2)
+			continue
+		}
+		removed = append(removed, id)
+	}
+
+	if failed := len(ids) - len(removed); failed > 0 {
+		if _, _, err2 := b.resize(ctx, failed); err2 != nil {
+			err = errors.Join(err, err2)
+		}
+	}
+	return removed, err
+}
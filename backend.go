@@ -0,0 +1,124 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// capacityBackend is implemented by the backend modes that leave creating
+// and deleting servers to an IONOS service and only change its desired size.
+type capacityBackend interface {
+	// init validates the configuration and fills in defaults, e.g. the
+	// datacenter, from the service.
+	init(ctx context.Context) error
+	// members maps the IDs of the datacenter servers managed by the service
+	// to the service's own ID for them.
+	members(ctx context.Context) (map[string]string, error)
+	// resize changes the desired size by delta within the service's limits
+	// and returns the previous and the new size.
+	resize(ctx context.Context, delta int) (int, int, error)
+	// remove deletes the given members, by service ID, and shrinks the
+	// desired size so that they are not replaced.
+	remove(ctx context.Context, ids []string) ([]string, error)
+}
+
+// backend returns the configured backend, or nil if the plugin manages the
+// servers itself.
+func (i *InstanceGroup) backend() capacityBackend {
+	switch {
+	case i.Autoscaling.enabled():
+		return autoscalingBackend{i}
+	case i.Kubernetes.enabled():
+		return nodePoolBackend{i}
+	}
+	return nil
+}
+
+func (i *InstanceGroup) initBackend(ctx context.Context) error {
+	if i.Autoscaling.enabled() && i.Kubernetes.enabled() {
+		return fmt.Errorf("autoscaling and kubernetes are mutually exclusive")
+	}
+	return i.backend().init(ctx)
+}
+
+// forEachBackendServer calls fn for the datacenter servers that are members
+// of the backend.
+func (i *InstanceGroup) forEachBackendServer(ctx context.Context, b capacityBackend, depth int32, fn func(server compute.Server)) error {
+	members, err := b.members(ctx)
+	if err != nil {
+		return err
+	}
+	for _, dc := range i.datacenters() {
+		err := i.forEachDatacenterServer(ctx, dc.ID, depth, func(server compute.Server) {
+			if _, ok := members[*server.Id]; ok {
+				i.registry.setDatacenter(*server.Id, dc.ID)
+				fn(server)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *InstanceGroup) backendIncrease(ctx context.Context, b capacityBackend, delta int) (int, error) {
+	current, target, err := b.resize(ctx, delta)
+	if err != nil {
+		return 0, err
+	}
+	if target-current < delta {
+		i.log.Warn("Backend maximum reached, limiting delta", "delta", delta, "max", target)
+	}
+	i.serverCache.invalidate()
+	return target - current, nil
+}
+
+// backendDecrease checks ownership and protection like Decrease does before
+// handing the instances to the backend.
+func (i *InstanceGroup) backendDecrease(ctx context.Context, b capacityBackend, instances []string) ([]string, error) {
+	members, err := b.members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	byID := make(map[string]string)
+	for _, id := range instances {
+		memberID, ok := members[id]
+		if !ok {
+			err = errors.Join(err, fmt.Errorf("refusing to delete %s: %w", id, ErrNotGroupInstance))
+			continue
+		}
+		if i.isProtected(compute.Server{Id: &id}) {
+			err = errors.Join(err, fmt.Errorf("refusing to delete %s: %w", id, ErrProtectedInstance))
+			continue
+		}
+		ids = append(ids, memberID)
+		byID[memberID] = id
+	}
+	if len(ids) == 0 {
+		return nil, err
+	}
+
+	removed, err2 := b.remove(ctx, ids)
+	var succeeded []string
+	for _, memberID := range removed {
+		id := byID[memberID]
+		i.registry.remove(id)
+		i.closeTunnel(id)
+		succeeded = append(succeeded, id)
+	}
+	i.serverCache.invalidate()
+	return succeeded, errors.Join(err, err2)
+}
+
+func intProperty(props map[string]any, key string) int {
+	if n, ok := props[key].(float64); ok {
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package ionos
+
+import (
+	"context"
+	"time"
+)
+
+// startBackground prepares the context for background tasks, which is
+// cancelled again by stopBackground during Shutdown.
+func (i *InstanceGroup) startBackground() {
+	i.bgCtx, i.bgCancel = context.WithCancel(context.Background())
+}
+
+// stopBackground cancels all background tasks and waits for them to return.
+func (i *InstanceGroup) stopBackground() {
+	if i.bgCancel == nil {
+		return
+	}
+	i.bgCancel()
+	i.bgWG.Wait()
+}
+
+// runBackground calls fn once in the background. An error is logged.
+func (i *InstanceGroup) runBackground(name string, fn func(ctx context.Context) error) {
+	if i.bgCtx == nil || i.bgCtx.Err() != nil {
+		return
+	}
+	i.bgWG.Add(1)
+	go func() {
+		defer i.bgWG.Done()
+		if err := fn(withOperation(i.bgCtx, name)); err != nil {
+			i.log.Error("Background task failed", "task", name, "err", err)
+		}
+	}()
+}
+
+// runPeriodic calls fn every interval until the plugin shuts down. Errors are
+// logged and do not stop the task.
+func (i *InstanceGroup) runPeriodic(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	i.bgWG.Add(1)
+	go func() {
+		defer i.bgWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-i.bgCtx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(withOperation(i.bgCtx, name)); err != nil {
+					i.log.Error("Background task failed", "task", name, "err", err)
+				}
+			}
+		}
+	}()
+}
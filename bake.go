@@ -0,0 +1,191 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	defaultBakeTimeout = 30 * time.Minute
+	bakePollInterval   = 10 * time.Second
+)
+
+// BakeOptions describes the golden image BakeImage builds.
+type BakeOptions struct {
+	// Name is the name of the snapshot to create.
+	Name string
+	// BaseImage defaults to server_spec.image.
+	BaseImage string
+	// UserData provisions the builder server and has to power it off when
+	// done, e.g. with cloud-init's power_state.
+	UserData string
+	// Timeout bounds the provisioning, defaults to 30 minutes.
+	Timeout time.Duration
+	// Promote makes the snapshot the active golden image of the group.
+	Promote bool
+}
+
+// BakeResult holds the IDs of a baked image.
+type BakeResult struct {
+	SnapshotID string `json:"snapshot_id"`
+	Name       string `json:"name"`
+	ServerID   string `json:"builder_server_id"`
+	Promoted   bool   `json:"promoted"`
+}
+
+// BakeImage builds a golden image: it boots a builder server from the base
+// image with the provisioning user data, waits for it to power off,
+// snapshots its boot volume and deletes it. The snapshot can be used as
+// server_spec.image, picked up by image_pattern or promoted to the active
+// golden image. BakeImage is called
+// instead of Init.
+func (i *InstanceGroup) BakeImage(ctx context.Context, logger hclog.Logger, opts BakeOptions) (result BakeResult, err error) {
+	ctx = withOperation(ctx, "BakeImage")
+	i.log = newRedactingLogger(logger)
+	if err := i.initAPI(ctx); err != nil {
+		return result, err
+	}
+	if opts.Name == "" {
+		return result, fmt.Errorf("a snapshot name is required")
+	}
+	if opts.UserData == "" {
+		return result, fmt.Errorf("provisioning user data is required")
+	}
+	if opts.BaseImage == "" {
+		opts.BaseImage = i.ServerSpec.Image
+	}
+	if opts.BaseImage == "" || opts.BaseImage == imageActive {
+		return result, fmt.Errorf("a base image is required")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultBakeTimeout
+	}
+	if err := i.validateDatacenters(); err != nil {
+		return result, err
+	}
+	// The golden image is a snapshot in the builder's datacenter, which all
+	// datacenters can only use if they share its location.
+	if _, err := i.datacenterLocation(ctx); err != nil {
+		return result, err
+	}
+
+	// The builder is an ordinary server of the spec, booted from the base
+	// image with the provisioning user data instead of the runner's.
+	i.ServerSpec.Image = opts.BaseImage
+	i.ServerSpec.ImagePattern = ""
+	i.ServerSpec.UserData = opts.UserData
+	i.ServerSpec.UserDataFile = ""
+	i.ServerSpec.UserDataTemplate = false
+	spec, err := i.resolveSpec(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	dc := i.datacenters()[0]
+	if i.lanID(dc) == 0 {
+		lanID, err := i.EnsureLan(ctx, dc.ID)
+		if err != nil {
+			return result, err
+		}
+		i.autoLans = map[string]int32{dc.ID: lanID}
+	}
+
+	serverName := fmt.Sprintf("%s-bake-%s", i.ServerSpec.Name, newIdempotencyToken())
+	serverData, err := i.getPostServerData(ctx, spec, dc, serverName, 0, "")
+	if err != nil {
+		return result, err
+	}
+	server, err := i.postServer(ctx, dc, serverName, serverData)
+	if err != nil {
+		return result, fmt.Errorf("creating builder server: %w", err)
+	}
+	if i.DryRun {
+		return result, nil
+	}
+	result.ServerID = *server.Id
+	i.log.Info("Created builder server", "id", result.ServerID, "name", serverName, "base_image", opts.BaseImage)
+
+	defer func() {
+		// The builder is deleted even if the context was cancelled.
+		apiResponse, err2 := withRetryNoResult(context.WithoutCancel(ctx), i, "ServersDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteServer(context.WithoutCancel(ctx), dc.ID, result.ServerID)
+		})
+		i.audit(ctx, auditEvent{Action: "delete", ResourceType: "server", ResourceID: result.ServerID, Name: serverName, Datacenter: dc.ID}, apiResponse, err2)
+		if err2 != nil {
+			err = errors.Join(err, fmt.Errorf("deleting builder server %s: %w", result.ServerID, err2))
+			return
+		}
+		i.log.Info("Deleted builder server", "id", result.ServerID)
+	}()
+
+	volumeID, err := i.waitForPowerOff(ctx, dc.ID, result.ServerID, opts.Timeout)
+	if err != nil {
+		return result, err
+	}
+
+	snapshot, apiResponse, err := withRetry(ctx, i, "VolumesCreateSnapshotPost", func(ctx context.Context) (compute.Snapshot, *shared.APIResponse, error) {
+		return i.api.CreateSnapshot(ctx, dc.ID, volumeID, opts.Name, "Baked by fleeting-plugin-ionos from "+opts.BaseImage)
+	})
+	i.audit(ctx, auditEvent{Action: "create", ResourceType: "snapshot", ResourceID: shared.ToValueDefault(snapshot.Id), Name: opts.Name, Datacenter: dc.ID}, apiResponse, err)
+	if err != nil {
+		return result, fmt.Errorf("creating snapshot: %w", err)
+	}
+	if err := i.waitForLocation(ctx, apiResponse); err != nil {
+		return result, fmt.Errorf("creating snapshot: %w", err)
+	}
+	result.SnapshotID = *snapshot.Id
+	result.Name = opts.Name
+	i.log.Info("Created snapshot", "id", result.SnapshotID, "name", opts.Name)
+
+	if err := i.labelGoldenImage(ctx, result.SnapshotID); err != nil {
+		return result, fmt.Errorf("labeling snapshot: %w", err)
+	}
+	if opts.Promote {
+		if _, err := i.promoteImage(ctx, result.SnapshotID); err != nil {
+			return result, err
+		}
+		result.Promoted = true
+	}
+	return result, nil
+}
+
+// waitForPowerOff waits for the provisioning of the builder server to power
+// it off and returns the ID of its boot volume.
+func (i *InstanceGroup) waitForPowerOff(ctx context.Context, datacenterID, id string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(bakePollInterval)
+	defer ticker.Stop()
+	for {
+		server, _, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, datacenterID, id, 2)
+		})
+		if err != nil {
+			return "", fmt.Errorf("getting builder server: %w", err)
+		}
+		state := ""
+		if server.Metadata != nil && server.Metadata.State != nil {
+			state = *server.Metadata.State
+		}
+		if state == "AVAILABLE" && server.Properties.VmState != nil && *server.Properties.VmState == "SHUTOFF" {
+			if server.Entities == nil || server.Entities.Volumes == nil || server.Entities.Volumes.Items == nil || len(*server.Entities.Volumes.Items) == 0 {
+				return "", fmt.Errorf("builder server %s has no volume", id)
+			}
+			return *(*server.Entities.Volumes.Items)[0].Id, nil
+		}
+		i.log.Debug("Waiting for builder server to power off", "id", id, "state", state)
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("waiting for builder server %s to power off: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package ionos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// BastionConfig configures a jump host for instances on private LANs.
+type BastionConfig struct {
+	// Address is host:port of the bastion's SSH server.
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	KeyPath  string `json:"key_path"`
+	// HostKey is the bastion's public host key in authorized_keys format.
+	// Without it the host key is not verified.
+	HostKey string `json:"host_key"`
+}
+
+// bastion forwards connections to instances through the jump host. The
+// runner only knows how to connect to an address and port, so each instance
+// gets a listener on localhost that is tunneled to it over SSH, and
+// ConnectInfo returns the local address.
+type bastion struct {
+	cfg    BastionConfig
+	config *ssh.ClientConfig
+
+	mu      sync.Mutex
+	client  *ssh.Client
+	tunnels map[string]*tunnel
+}
+
+type tunnel struct {
+	target   string
+	listener net.Listener
+}
+
+// initBastion validates the bastion config and prepares the SSH client
+// config. The connection itself is opened on first use.
+func (i *InstanceGroup) initBastion() error {
+	if i.Bastion.Address == "" {
+		return nil
+	}
+	if i.Bastion.Username == "" || i.Bastion.KeyPath == "" {
+		return fmt.Errorf("bastion requires username and key_path")
+	}
+	key, err := os.ReadFile(i.Bastion.KeyPath)
+	if err != nil {
+		return fmt.Errorf("reading bastion key_path: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("parsing bastion key: %w", err)
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if i.Bastion.HostKey != "" {
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(i.Bastion.HostKey))
+		if err != nil {
+			return fmt.Errorf("parsing bastion host_key: %w", err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	} else {
+		i.log.Warn("bastion.host_key is not set, the host key of the bastion is not verified")
+	}
+
+	i.bastion = &bastion{
+		cfg: i.Bastion,
+		config: &ssh.ClientConfig{
+			User:            i.Bastion.Username,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         probeDialTimeout,
+		},
+		tunnels: make(map[string]*tunnel),
+	}
+	return nil
+}
+
+// tunnelTo returns the local port that is forwarded to addr:port of an
+// instance, opening the tunnel if needed.
+func (i *InstanceGroup) tunnelTo(instance string, addr string, port int) (int, error) {
+	b := i.bastion
+	target := net.JoinHostPort(addr, strconv.Itoa(port))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.tunnels[instance]; ok {
+		if t.target == target {
+			return t.listener.Addr().(*net.TCPAddr).Port, nil
+		}
+		t.listener.Close()
+		delete(b.tunnels, instance)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("opening tunnel to %s: %w", target, err)
+	}
+	t := &tunnel{target: target, listener: listener}
+	b.tunnels[instance] = t
+	go i.serveTunnel(t)
+
+	i.log.Debug("Opened tunnel through bastion", "instance", instance, "target", target, "local", listener.Addr().String())
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (i *InstanceGroup) serveTunnel(t *tunnel) {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			remote, err := i.bastion.dial(t.target)
+			if err != nil {
+				i.log.Error("Failed to connect through bastion", "target", t.target, "err", err)
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() { _, _ = io.Copy(remote, conn); done <- struct{}{} }()
+			go func() { _, _ = io.Copy(conn, remote); done <- struct{}{} }()
+			<-done
+		}()
+	}
+}
+
+// dial connects to target through the bastion, reconnecting to the bastion
+// once if the connection broke.
+func (b *bastion) dial(target string) (net.Conn, error) {
+	client, err := b.connect(false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial("tcp", target)
+	if err == nil {
+		return conn, nil
+	}
+	client, err2 := b.connect(true)
+	if err2 != nil {
+		return nil, errors.Join(err, err2)
+	}
+	return client.Dial("tcp", target)
+}
+
+func (b *bastion) connect(reconnect bool) (*ssh.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.client != nil && !reconnect {
+		return b.client, nil
+	}
+	if b.client != nil {
+		b.client.Close()
+		b.client = nil
+	}
+	client, err := ssh.Dial("tcp", b.cfg.Address, b.config)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to bastion %s: %w", b.cfg.Address, err)
+	}
+	b.client = client
+	return client, nil
+}
+
+// closeTunnel closes the tunnel of a deleted instance.
+func (i *InstanceGroup) closeTunnel(instance string) {
+	if i.bastion == nil {
+		return
+	}
+	b := i.bastion
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.tunnels[instance]; ok {
+		t.listener.Close()
+		delete(b.tunnels, instance)
+	}
+}
+
+// closeBastion closes all tunnels and the bastion connection.
+func (i *InstanceGroup) closeBastion() error {
+	if i.bastion == nil {
+		return nil
+	}
+	b := i.bastion
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for instance, t := range b.tunnels {
+		t.listener.Close()
+		delete(b.tunnels, instance)
+	}
+	if b.client == nil {
+		return nil
+	}
+	err := b.client.Close()
+	b.client = nil
+	return err
+}
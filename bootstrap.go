@@ -0,0 +1,133 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// BootstrapOptions describes the infrastructure Bootstrap provisions.
+type BootstrapOptions struct {
+	// DatacenterName and Location are used to create a datacenter if the
+	// config has no datacenter_id.
+	DatacenterName string
+	Location       string
+}
+
+// BootstrapResult holds the IDs of the provisioned infrastructure.
+type BootstrapResult struct {
+	DatacenterID string `json:"datacenter_id"`
+	Location     string `json:"location"`
+	LanID        int32  `json:"lan_id"`
+	NATGatewayID string `json:"nat_gateway_id,omitempty"`
+	ImageID      string `json:"image,omitempty"`
+}
+
+// Bootstrap provisions the prerequisites of the group: the datacenter unless
+// datacenter_id is set, the private LAN unless lan_id is set and the NAT
+// gateway if nat_gateway is configured. It checks that the configured image
+// exists in the location of the datacenter. Images have to be uploaded over
+// FTP beforehand. Bootstrap is called instead of Init.
+func (i *InstanceGroup) Bootstrap(ctx context.Context, logger hclog.Logger, opts BootstrapOptions) (BootstrapResult, error) {
+	ctx = withOperation(ctx, "Bootstrap")
+	i.log = newRedactingLogger(logger)
+	if err := i.initAPI(ctx); err != nil {
+		return BootstrapResult{}, err
+	}
+
+	var result BootstrapResult
+	datacenter, err := i.bootstrapDatacenter(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+	result.DatacenterID = *datacenter.Id
+	if datacenter.Properties != nil && datacenter.Properties.Location != nil {
+		result.Location = *datacenter.Properties.Location
+	}
+
+	result.LanID = i.ServerSpec.LanID
+	if result.LanID == 0 {
+		if result.LanID, err = i.EnsureLan(ctx, result.DatacenterID); err != nil {
+			return result, err
+		}
+	}
+
+	if i.NATGateway.enabled() {
+		if err := i.validateNATGateway(); err != nil {
+			return result, err
+		}
+		if result.NATGatewayID, err = i.EnsureNATGateway(ctx, result.DatacenterID, result.LanID); err != nil {
+			return result, err
+		}
+	}
+
+	if i.ServerSpec.Image != "" && i.ServerSpec.Image != imageActive {
+		if err := i.checkImageLocation(ctx, result.Location); err != nil {
+			return result, err
+		}
+		result.ImageID = i.ServerSpec.Image
+	}
+	return result, nil
+}
+
+func (i *InstanceGroup) bootstrapDatacenter(ctx context.Context, opts BootstrapOptions) (compute.Datacenter, error) {
+	if i.DatacenterId != "" {
+		datacenter, _, err := withRetry(ctx, i, "DatacentersFindById", func(ctx context.Context) (compute.Datacenter, *shared.APIResponse, error) {
+			return i.api.GetDatacenter(ctx, i.DatacenterId)
+		})
+		if err != nil {
+			return compute.Datacenter{}, fmt.Errorf("getting datacenter %s: %w", i.DatacenterId, err)
+		}
+		return datacenter, nil
+	}
+
+	if opts.DatacenterName == "" || opts.Location == "" {
+		return compute.Datacenter{}, fmt.Errorf("a datacenter name and location are required to create the datacenter")
+	}
+	datacenter := compute.Datacenter{
+		Properties: &compute.DatacenterProperties{
+			Name:        &opts.DatacenterName,
+			Location:    &opts.Location,
+			Description: StrPtr("Created by fleeting-plugin-ionos"),
+		},
+	}
+	if i.dryRun("would create datacenter", "payload", payload(datacenter)) {
+		datacenter.Id = StrPtr("")
+		return datacenter, nil
+	}
+	created, apiResponse, err := withRetry(ctx, i, "DatacentersPost", func(ctx context.Context) (compute.Datacenter, *shared.APIResponse, error) {
+		return i.api.CreateDatacenter(ctx, datacenter)
+	})
+	i.audit(ctx, auditEvent{Action: "create", ResourceType: "datacenter", ResourceID: shared.ToValueDefault(created.Id), Name: opts.DatacenterName}, apiResponse, err)
+	if err != nil {
+		return compute.Datacenter{}, fmt.Errorf("creating datacenter: %w", err)
+	}
+	if err := i.waitForLocation(ctx, apiResponse); err != nil {
+		return compute.Datacenter{}, fmt.Errorf("creating datacenter: %w", err)
+	}
+	i.log.Info("Created datacenter", "id", *created.Id, "location", opts.Location)
+	i.DatacenterId = *created.Id
+	return created, nil
+}
+
+// checkImageLocation checks that the image exists and, for private images,
+// that it was uploaded to the location of the datacenter.
+func (i *InstanceGroup) checkImageLocation(ctx context.Context, location string) error {
+	image, _, err := withRetry(ctx, i, "ImagesFindById", func(ctx context.Context) (compute.Image, *shared.APIResponse, error) {
+		return i.api.GetImage(ctx, i.ServerSpec.Image)
+	})
+	if err != nil {
+		return fmt.Errorf("getting image %s: %w", i.ServerSpec.Image, err)
+	}
+	if image.Properties == nil || image.Properties.Location == nil || location == "" {
+		return nil
+	}
+	if imageLocation := *image.Properties.Location; !strings.EqualFold(imageLocation, location) {
+		return fmt.Errorf("image %s is in %s, the datacenter is in %s", i.ServerSpec.Image, imageLocation, location)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = Duration(time.Minute)
+)
+
+// ErrCircuitOpen is returned without calling the API while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("IONOS API circuit breaker is open")
+
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive failed API calls that opens the
+	// breaker, a negative value disables it.
+	Threshold int      `json:"threshold"`
+	Cooldown  Duration `json:"cooldown"`
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.Threshold == 0 {
+		c.Threshold = defaultBreakerThreshold
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = defaultBreakerCooldown
+	}
+	return c
+}
+
+// circuitBreaker fast-fails API calls for a cool-down period after
+// consecutive failures, so an unavailable API does not cost a full timeout
+// on every fleeting call. Once the cool-down has passed, calls go through
+// again and the first failure reopens the breaker.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allowAPICall returns ErrCircuitOpen while the breaker is open.
+func (i *InstanceGroup) allowAPICall() error {
+	if i.CircuitBreaker.withDefaults().Threshold < 0 {
+		return nil
+	}
+	b := &i.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return fmt.Errorf("%w, retrying in %s", ErrCircuitOpen, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// recordAPICall updates the breaker with the outcome of an API call. Only
+// connection errors, timeouts and 5xx/429 responses count as failures, any
+// other response shows that the API is reachable.
+func (i *InstanceGroup) recordAPICall(ctx context.Context, apiResponse *shared.APIResponse, err error) {
+	cfg := i.CircuitBreaker.withDefaults()
+	if cfg.Threshold < 0 {
+		return
+	}
+	failed := err != nil && ctx.Err() == nil && (apiResponse == nil || apiResponse.Response == nil || isRetryable(apiResponse))
+
+	b := &i.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		if b.failures >= cfg.Threshold {
+			i.log.Info("IONOS API circuit breaker closed")
+			i.setBreakerGauge(false)
+		}
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= cfg.Threshold {
+		b.openUntil = time.Now().Add(time.Duration(cfg.Cooldown))
+		i.log.Warn("IONOS API circuit breaker opened", "failures", b.failures, "cooldown", cfg.Cooldown, "err", err)
+		i.setBreakerGauge(true)
+	}
+}
+
+func (i *InstanceGroup) setBreakerGauge(open bool) {
+	if i.metrics == nil {
+		return
+	}
+	if open {
+		i.metrics.circuitOpen.Set(1)
+	} else {
+		i.metrics.circuitOpen.Set(0)
+	}
+}
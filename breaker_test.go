@@ -0,0 +1,72 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// apiCall is the outcome of one API call fed to the circuit breaker.
+type apiCall struct {
+	response *shared.APIResponse
+	err      error
+}
+
+var (
+	callOK          = apiCall{response(http.StatusOK), nil}
+	callUnavailable = apiCall{response(http.StatusServiceUnavailable), apiError(http.StatusServiceUnavailable, "unavailable")}
+	callBadRequest  = apiCall{response(http.StatusBadRequest), apiError(http.StatusBadRequest, "invalid")}
+	callRefused     = apiCall{nil, errors.New("connection refused")}
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		calls     []apiCall
+		wantOpen  bool
+	}{
+		{"one failure", 2, []apiCall{callUnavailable}, false},
+		{"consecutive failures", 2, []apiCall{callUnavailable, callRefused}, true},
+		// A client error shows the API is reachable and resets the count.
+		{"reset by a client error", 2, []apiCall{callUnavailable, callBadRequest, callUnavailable}, false},
+		{"closed by a success", 2, []apiCall{callUnavailable, callRefused, callOK}, false},
+		{"disabled", -1, []apiCall{callRefused, callRefused, callRefused}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.CircuitBreaker = CircuitBreakerConfig{Threshold: tt.threshold, Cooldown: Duration(time.Hour)}
+			for _, call := range tt.calls {
+				i.recordAPICall(context.Background(), call.response, call.err)
+			}
+			err := i.allowAPICall()
+			if open := errors.Is(err, ErrCircuitOpen); open != tt.wantOpen {
+				t.Errorf("allowAPICall = %v, want open %t", err, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerFailsFastWithoutCallingTheAPI(t *testing.T) {
+	i := newTestGroup(nil)
+	i.CircuitBreaker = CircuitBreakerConfig{Threshold: 1, Cooldown: Duration(time.Hour)}
+	i.Retry.MaxAttempts = 1
+	i.recordAPICall(context.Background(), callRefused.response, callRefused.err)
+
+	called := false
+	_, _, err := withRetry(context.Background(), i, "Test", func(ctx context.Context) (struct{}, *shared.APIResponse, error) {
+		called = true
+		return struct{}{}, response(http.StatusOK), nil
+	})
+	if called {
+		t.Error("API called while the breaker is open")
+	}
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrClassTransient) {
+		t.Errorf("withRetry error %v, want a transient %v", err, ErrCircuitOpen)
+	}
+}
@@ -0,0 +1,118 @@
+package ionos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+// serverCache keeps the group server list and single servers for
+// server_cache_ttl, so bursts of Update and Heartbeat calls are served
+// without hitting the API each time.
+type serverCache struct {
+	mu      sync.Mutex
+	list    []compute.Server
+	listed  time.Time
+	servers map[string]cachedServer
+
+	// snapshot is the instance states reported by the last Update.
+	snapshot   []instanceState
+	snapshotAt time.Time
+}
+
+type instanceState struct {
+	id    string
+	state provider.State
+}
+
+type cachedServer struct {
+	server  compute.Server
+	fetched time.Time
+}
+
+func (c *serverCache) getList(ttl time.Duration) ([]compute.Server, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.list == nil || time.Since(c.listed) >= ttl {
+		return nil, false
+	}
+	return c.list, true
+}
+
+func (c *serverCache) setList(servers []compute.Server) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if servers == nil {
+		servers = []compute.Server{}
+	}
+	c.list = servers
+	c.listed = time.Now()
+}
+
+func (c *serverCache) getServer(id string, ttl time.Duration) (compute.Server, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.servers[id]
+	if !ok || time.Since(cached.fetched) >= ttl {
+		return compute.Server{}, false
+	}
+	return cached.server, true
+}
+
+func (c *serverCache) setServer(server compute.Server) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.servers == nil {
+		c.servers = make(map[string]cachedServer)
+	}
+	c.servers[*server.Id] = cachedServer{server: server, fetched: time.Now()}
+}
+
+func (c *serverCache) getSnapshot(ttl time.Duration) ([]instanceState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot == nil || time.Since(c.snapshotAt) >= ttl {
+		return nil, false
+	}
+	return c.snapshot, true
+}
+
+func (c *serverCache) setSnapshot(states []instanceState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if states == nil {
+		states = []instanceState{}
+	}
+	c.snapshot = states
+	c.snapshotAt = time.Now()
+}
+
+// invalidate drops everything after the group changed.
+func (c *serverCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = nil
+	c.servers = nil
+	c.snapshot = nil
+}
+
+// cachedGroupServers returns the group servers, from the cache if enabled and
+// fresh.
+func (i *InstanceGroup) cachedGroupServers(ctx context.Context) ([]compute.Server, error) {
+	ttl := time.Duration(i.ServerCacheTTL)
+	if ttl <= 0 {
+		return i.listGroupServers(ctx)
+	}
+	if servers, ok := i.serverCache.getList(ttl); ok {
+		return servers, nil
+	}
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i.serverCache.setList(servers)
+	return servers, nil
+}
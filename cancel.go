@@ -0,0 +1,25 @@
+package ionos
+
+import (
+	"context"
+)
+
+// cleanupCancelledIncrease deletes the servers an Increase created before
+// its context was cancelled, so a cancelled scale-up does not leave part of
+// the batch behind. It reports the number of servers deleted.
+func (i *InstanceGroup) cleanupCancelledIncrease(ctx context.Context, ids []string) int {
+	// The deletions must run although ctx is done, they are bounded by the
+	// delete timeout of each call.
+	ctx = context.WithoutCancel(ctx)
+
+	deleted := 0
+	for _, id := range ids {
+		if err := i.deleteInstance(ctx, id, nil); err != nil {
+			i.log.Error("Failed to delete instance of cancelled increase", "id", id, "err", err)
+			continue
+		}
+		deleted++
+	}
+	i.log.Info("Deleted instances of cancelled increase", "deleted", deleted, "created", len(ids))
+	return deleted
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/codecentric/fleeting-plugin-ionos"
+)
+
+// runBakeImage builds a golden image snapshot from the base image and a
+// provisioning user data file.
+func runBakeImage(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("bake-image", &opts)
+	name := fs.String("name", "", "name of the snapshot to create, e.g. runner-golden-v3")
+	baseImage := fs.String("base-image", "", "image to provision, defaults to server_spec.image")
+	userDataFile := fs.String("user-data-file", "", "cloud-init config that provisions the server and powers it off")
+	timeout := fs.Duration("timeout", 0, "how long provisioning may take (default 30m)")
+	promote := fs.Bool("promote", false, "make the snapshot the active golden image")
+	fs.Parse(args)
+
+	if *userDataFile == "" {
+		return fmt.Errorf("-user-data-file is required")
+	}
+	userData, err := os.ReadFile(*userDataFile)
+	if err != nil {
+		return fmt.Errorf("reading user data: %w", err)
+	}
+
+	group, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := group.BakeImage(ctx, opts.logger(), ionos.BakeOptions{
+		Name:      *name,
+		BaseImage: *baseImage,
+		UserData:  string(userData),
+		Timeout:   *timeout,
+		Promote:   *promote,
+	})
+	return errors.Join(err, opts.print(result, func() {
+		if result.SnapshotID == "" {
+			return
+		}
+		fmt.Printf("baked snapshot %s (%s)\n", result.Name, result.SnapshotID)
+		if result.Promoted {
+			fmt.Println("promoted to the active golden image")
+		}
+	}))
+}
+
+// runPromoteImage makes a baked snapshot the active golden image.
+func runPromoteImage(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("promote-image", &opts)
+	fs.Parse(args)
+
+	refs, err := argOrPrompt(fs.Args(), "Golden image ID or name: ")
+	if err != nil {
+		return err
+	}
+	group, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+	id, err := group.PromoteImage(ctx, opts.logger(), refs[0])
+	if err != nil {
+		return err
+	}
+	return opts.print(map[string]string{"active": id}, func() {
+		fmt.Printf("active golden image %s\n", id)
+	})
+}
+
+// runRollbackImage makes the previously promoted golden image active again.
+func runRollbackImage(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("rollback-image", &opts)
+	fs.Parse(args)
+
+	group, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+	id, err := group.RollbackImage(ctx, opts.logger())
+	if err != nil {
+		return err
+	}
+	return opts.print(map[string]string{"active": id}, func() {
+		fmt.Printf("active golden image %s\n", id)
+	})
+}
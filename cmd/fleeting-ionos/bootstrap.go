@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codecentric/fleeting-plugin-ionos"
+)
+
+// runBootstrap provisions the infrastructure the config refers to and prints
+// the resulting plugin_config settings.
+func runBootstrap(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("bootstrap", &opts)
+	name := fs.String("datacenter-name", "fleeting", "name of the datacenter to create if the config has no datacenter_id")
+	location := fs.String("location", "", "location of the datacenter to create, e.g. de/fra")
+	fs.Parse(args)
+
+	group, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := group.Bootstrap(ctx, opts.logger(), ionos.BootstrapOptions{
+		DatacenterName: *name,
+		Location:       *location,
+	})
+	return errors.Join(err, opts.print(result, func() {
+		if result.DatacenterID == "" {
+			return
+		}
+		fmt.Println("[runners.autoscaler.plugin_config]")
+		fmt.Printf("  datacenter_id = %q\n", result.DatacenterID)
+		fmt.Println()
+		fmt.Println("[runners.autoscaler.plugin_config.server_spec]")
+		fmt.Printf("  lan_id = %d\n", result.LanID)
+		if result.ImageID != "" {
+			fmt.Printf("  image = %q\n", result.ImageID)
+		}
+		if result.NATGatewayID != "" {
+			fmt.Printf("  # NAT gateway %s\n", result.NATGatewayID)
+		}
+	}))
+}
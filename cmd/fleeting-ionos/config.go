@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecentric/fleeting-plugin-ionos"
+	"github.com/hashicorp/go-hclog"
+)
+
+// options holds the flags shared by all subcommands.
+type options struct {
+	config   string
+	logLevel string
+	output   string
+}
+
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	defaultConfig := os.Getenv("FLEETING_IONOS_CONFIG")
+	if defaultConfig == "" {
+		defaultConfig = "plugin_config.json"
+	}
+	fs.StringVar(&opts.config, "config", defaultConfig, "path to the plugin config as JSON (env FLEETING_IONOS_CONFIG)")
+	fs.StringVar(&opts.logLevel, "log-level", "info", "log level (trace, debug, info, warn, error)")
+	fs.StringVar(&opts.output, "output", "text", "output format (text, json)")
+	return fs
+}
+
+// instanceGroup loads the plugin config and initializes the instance group
+// for a single command, without the background tasks of the plugin.
+func (o *options) instanceGroup(ctx context.Context) (*ionos.InstanceGroup, error) {
+	return o.initInstanceGroup(ctx, false)
+}
+
+// provisionedInstanceGroup is instanceGroup for commands that create
+// instances, which need the image and network set up like GitLab Runner
+// does through Init.
+func (o *options) provisionedInstanceGroup(ctx context.Context) (*ionos.InstanceGroup, error) {
+	return o.initInstanceGroup(ctx, true)
+}
+
+func (o *options) initInstanceGroup(ctx context.Context, provision bool) (*ionos.InstanceGroup, error) {
+	group, err := o.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := group.InitCLI(ctx, o.logger(), provision); err != nil {
+		return nil, fmt.Errorf("initializing instance group: %w", err)
+	}
+	return group, nil
+}
+
+// loadConfig loads the plugin config without initializing the instance
+// group. The token falls back to IONOS_TOKEN.
+func (o *options) loadConfig() (*ionos.InstanceGroup, error) {
+	data, err := os.ReadFile(o.config)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	group := &ionos.InstanceGroup{}
+	if err := json.Unmarshal(data, group); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", o.config, err)
+	}
+	if group.Token == "" {
+		group.Token = os.Getenv("IONOS_TOKEN")
+	}
+	return group, nil
+}
+
+func (o *options) logger() hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:   "fleeting-ionos",
+		Level:  hclog.LevelFromString(o.logLevel),
+		Output: os.Stderr,
+	})
+}
+
+// print writes v as JSON with -output json, or calls text otherwise.
+func (o *options) print(v any, text func()) error {
+	switch o.output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "text", "":
+		text()
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", o.output)
+}
+
+// argOrPrompt returns the positional arguments, or asks for a single value on
+// stdin when none were given.
+func argOrPrompt(args []string, prompt string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	fmt.Print(prompt)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("no input given")
+	}
+	return []string{input}, nil
+}
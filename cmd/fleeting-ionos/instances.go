@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codecentric/fleeting-plugin-ionos"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+func runIncrease(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("increase", &opts)
+	count := fs.Int("n", 1, "number of instances to create")
+	fs.Parse(args)
+
+	group, err := opts.provisionedInstanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	succeeded, err := group.Increase(ctx, *count)
+	type failure struct {
+		ionos.CreateResult
+		Error string `json:"error"`
+	}
+	result := struct {
+		Requested int       `json:"requested"`
+		Succeeded int       `json:"succeeded"`
+		Failed    []failure `json:"failed,omitempty"`
+	}{Requested: *count, Succeeded: succeeded}
+	var increaseErr *ionos.IncreaseError
+	if errors.As(err, &increaseErr) {
+		for _, r := range increaseErr.Failed() {
+			result.Failed = append(result.Failed, failure{r, r.Err.Error()})
+		}
+	}
+	return errors.Join(err, opts.print(result, func() {
+		fmt.Printf("requested %d of %d instances\n", succeeded, *count)
+		for _, f := range result.Failed {
+			fmt.Printf("failed #%d %s in %s (%s): %s\n", f.Index, f.Name, f.DatacenterID, f.Class, f.Error)
+		}
+	}))
+}
+
+func runDecrease(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("decrease", &opts)
+	fs.Parse(args)
+
+	instances, err := argOrPrompt(fs.Args(), "Enter uuid or name of server to delete: ")
+	if err != nil {
+		return err
+	}
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	instances, err = group.ResolveInstances(ctx, instances)
+	if err != nil {
+		return err
+	}
+
+	succeeded, err := group.Decrease(ctx, instances)
+	result := struct {
+		Deleted []string `json:"deleted"`
+	}{succeeded}
+	return errors.Join(err, opts.print(result, func() {
+		for _, id := range succeeded {
+			fmt.Println("deleted", id)
+		}
+	}))
+}
+
+func runConnectInfo(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("connect-info", &opts)
+	fs.Parse(args)
+
+	instances, err := argOrPrompt(fs.Args(), "Enter uuid or name of server to connect: ")
+	if err != nil {
+		return err
+	}
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	instances, err = group.ResolveInstances(ctx, instances)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]provider.ConnectInfo, 0, len(instances))
+	for _, instance := range instances {
+		info, err2 := group.ConnectInfo(ctx, instance)
+		if err2 != nil {
+			err = errors.Join(err, err2)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return errors.Join(err, opts.print(infos, func() {
+		for _, info := range infos {
+			fmt.Printf("info: %+v\n", info)
+		}
+	}))
+}
+
+func runUpdate(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("update", &opts)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	type instanceState struct {
+		ID    string         `json:"id"`
+		State provider.State `json:"state"`
+	}
+	states := []instanceState{}
+	err = group.Update(ctx, func(instance string, state provider.State) {
+		states = append(states, instanceState{instance, state})
+	})
+	return errors.Join(err, opts.print(states, func() {
+		for _, s := range states {
+			fmt.Println(s.ID, s.State)
+		}
+	}))
+}
+
+// runList prints the group instances as a table.
+func runList(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("list", &opts)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	instances, err := group.ListInstances(ctx)
+	if err != nil {
+		return err
+	}
+	return opts.print(instances, func() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tID\tSTATE\tINTERNAL IP\tEXTERNAL IP\tZONE\tAGE")
+		for _, inst := range instances {
+			age := "-"
+			if !inst.Created.IsZero() {
+				age = time.Since(inst.Created).Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", inst.Name, inst.ID, inst.State,
+				orDash(inst.InternalIP), orDash(inst.ExternalIP), orDash(inst.Zone), age)
+		}
+		w.Flush()
+	})
+}
+
+// runStatus prints a one screen summary of the group for on-call engineers.
+func runStatus(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("status", &opts)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	status, err := group.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return opts.print(status, func() {
+		states := slices.Sorted(maps.Keys(status.Instances))
+		counts := make([]string, 0, len(states))
+		total := 0
+		for _, state := range states {
+			counts = append(counts, fmt.Sprintf("%s %d", state, status.Instances[state]))
+			total += status.Instances[state]
+		}
+		fmt.Printf("instances:  %d of max %d", total, status.MaxSize)
+		if len(counts) > 0 {
+			fmt.Printf(" (%s)", strings.Join(counts, ", "))
+		}
+		fmt.Println()
+
+		if q := status.Quota; q != nil {
+			fmt.Printf("quota:      %d cores, %d MB RAM left, room for %d more instances\n", q.CoresAvailable, q.RAMAvailable, q.Instances)
+		} else {
+			fmt.Printf("quota:      unknown: %s\n", status.QuotaError)
+		}
+
+		fmt.Printf("pending:    %d requests\n", len(status.PendingRequests))
+		for _, req := range status.PendingRequests {
+			fmt.Println("  ", req.Started.Local().Format(time.DateTime), req.RequestID, req.Action, req.ServerID, req.Status)
+		}
+		fmt.Printf("failed:     %d recent requests\n", len(status.FailedRequests))
+		for _, req := range status.FailedRequests {
+			fmt.Println("  ", req.Started.Local().Format(time.DateTime), req.RequestID, req.Action, req.ServerID, req.Message)
+		}
+	})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// runRequests lists the server requests of the group, e.g. to find the ID
+// of a failed create request to quote to IONOS support.
+func runRequests(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("requests", &opts)
+	since := fs.Duration("since", 24*time.Hour, "list requests created within this duration")
+	status := fs.String("status", "", `only list requests with this status: "pending", "done" or "failed"`)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	requests, err := group.ListRequests(ctx, time.Now().Add(-*since), *status)
+	if err != nil {
+		return err
+	}
+	if requests == nil {
+		requests = []ionos.RequestInfo{}
+	}
+	return opts.print(requests, func() {
+		for _, req := range requests {
+			fmt.Println(req.Started.Local().Format(time.DateTime), req.RequestID, req.Action, req.ServerID, req.Status, req.Message)
+		}
+	})
+}
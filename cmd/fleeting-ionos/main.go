@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"increase", "Create new instances", runIncrease},
+	{"decrease", "Delete instances by UUID or name", runDecrease},
+	{"connect-info", "Show the connect info of an instance", runConnectInfo},
+	{"update", "List the group instances and their state", runUpdate},
+	{"list", "Show the group instances with their addresses, zone and age", runList},
+	{"status", "Summarize instance states, pending and failed requests and quota headroom", runStatus},
+	{"requests", "List the recent server requests of the group and their status", runRequests},
+	{"sweep-volumes", "Delete servers of failed creations and unattached group volumes", runSweepVolumes},
+	{"reap", "Delete group instances older than a TTL", runReap},
+	{"cost", "Estimate the cost of the group instances", runCost},
+	{"doctor", "Check the config against the IONOS API", runDoctor},
+	{"bootstrap", "Create the datacenter, LAN and NAT gateway for the config", runBootstrap},
+	{"bake-image", "Provision a builder server and snapshot it as golden image", runBakeImage},
+	{"promote-image", "Make a golden image the active one", runPromoteImage},
+	{"rollback-image", "Make the previously active golden image active again", runRollbackImage},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(ctx, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if name != "help" && name != "-h" && name != "--help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: fleeting-ionos <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'fleeting-ionos <command> -h' for the flags of a command.\n")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// runSweepVolumes deletes the servers of failed create requests and the
+// group volumes that are not attached to a server.
+func runSweepVolumes(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("sweep-volumes", &opts)
+	since := fs.Duration("since", 24*time.Hour, "also delete the servers of create requests that failed within this duration")
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	servers, err := group.CollectFailedCreations(ctx, time.Now().Add(-*since))
+	for _, id := range servers {
+		fmt.Println("deleted server of failed creation", id)
+	}
+	deleted, err2 := group.SweepVolumes(ctx)
+	for _, id := range deleted {
+		fmt.Println("deleted volume", id)
+	}
+	return errors.Join(err, err2)
+}
+
+// runReap deletes group instances created more than -ttl ago. This process
+// does not know which instances the runner uses, so it should only be run
+// while the runner manager is stopped.
+func runReap(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("reap", &opts)
+	ttl := fs.Duration("ttl", time.Hour, "minimum age of the instances to delete")
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	reaped, err := group.ReapOrphans(ctx, *ttl)
+	for _, id := range reaped {
+		fmt.Println("deleted", id)
+	}
+	return err
+}
+
+func runCost(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("cost", &opts)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	estimate, err := group.EstimateCost(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("instances:     %d\n", estimate.Instances)
+	fmt.Printf("per instance:  %.4f %s/hour\n", estimate.InstanceHour, estimate.Currency)
+	fmt.Printf("hourly:        %.2f %s\n", estimate.Hourly, estimate.Currency)
+	fmt.Printf("monthly:       %.2f %s\n", estimate.Monthly, estimate.Currency)
+	return nil
+}
+
+// runDoctor checks the config against the IONOS API and fails if any check
+// does not pass.
+func runDoctor(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("doctor", &opts)
+	fs.Parse(args)
+
+	group, err := opts.instanceGroup(ctx)
+	if err != nil {
+		return err
+	}
+	defer group.Shutdown(ctx)
+
+	failed := 0
+	for _, result := range group.Diagnose(ctx) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", result.Name, result.Err)
+		} else {
+			fmt.Printf("ok    %s\n", result.Name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"gitlab.com/gitlab-org/fleeting/fleeting/plugin"
 )
 
+// main serves the instance group over the fleeting plugin protocol. plugin.Main
+// also handles the "version" subcommand and -version flag, using the values
+// injected into the ionos package via -ldflags.
 func main() {
 	plugin.Main(&ionos.InstanceGroup{}, ionos.Version)
 }
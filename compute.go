@@ -0,0 +1,292 @@
+package ionos
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// computeAPI is the subset of the IONOS Cloud API the plugin uses. The SDK
+// backed implementation is set up by Init unless one has been injected
+// before, e.g. a mock.
+type computeAPI interface {
+	CreateServer(ctx context.Context, datacenterID string, server compute.Server) (compute.Server, *shared.APIResponse, error)
+	DeleteServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+	StopServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+	SuspendServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+	StartServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+	ResumeServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+	GetServer(ctx context.Context, datacenterID, id string, depth int32) (compute.Server, *shared.APIResponse, error)
+	// ListServers lists the servers whose name contains name. A zero limit
+	// returns the API default page size.
+	ListServers(ctx context.Context, datacenterID, name string, depth, offset, limit int32) (compute.Servers, *shared.APIResponse, error)
+	// ListNics lists the NICs of a server with their properties.
+	ListNics(ctx context.Context, datacenterID, serverID string) (compute.Nics, *shared.APIResponse, error)
+
+	ListTemplates(ctx context.Context) (compute.Templates, *shared.APIResponse, error)
+	GetTemplate(ctx context.Context, id string) (compute.Template, *shared.APIResponse, error)
+	GetImage(ctx context.Context, id string) (compute.Image, *shared.APIResponse, error)
+	ListImages(ctx context.Context) (compute.Images, *shared.APIResponse, error)
+	ListSnapshots(ctx context.Context) (compute.Snapshots, *shared.APIResponse, error)
+	GetSnapshot(ctx context.Context, id string) (compute.Snapshot, *shared.APIResponse, error)
+	CreateSnapshot(ctx context.Context, datacenterID, volumeID, name, description string) (compute.Snapshot, *shared.APIResponse, error)
+	DeleteSnapshot(ctx context.Context, id string) (*shared.APIResponse, error)
+
+	GetDatacenter(ctx context.Context, id string) (compute.Datacenter, *shared.APIResponse, error)
+	CreateDatacenter(ctx context.Context, datacenter compute.Datacenter) (compute.Datacenter, *shared.APIResponse, error)
+	GetLan(ctx context.Context, datacenterID, id string) (compute.Lan, *shared.APIResponse, error)
+	ListLans(ctx context.Context, datacenterID string) (compute.Lans, *shared.APIResponse, error)
+	CreateLan(ctx context.Context, datacenterID string, lan compute.LanPost) (compute.LanPost, *shared.APIResponse, error)
+	GetIPBlock(ctx context.Context, id string) (compute.IpBlock, *shared.APIResponse, error)
+	ListNATGateways(ctx context.Context, datacenterID string) (compute.NatGateways, *shared.APIResponse, error)
+	CreateNATGateway(ctx context.Context, datacenterID string, gateway compute.NatGateway) (compute.NatGateway, *shared.APIResponse, error)
+	CreateNATGatewayRule(ctx context.Context, datacenterID, gatewayID string, rule compute.NatGatewayRule) (compute.NatGatewayRule, *shared.APIResponse, error)
+	ListContracts(ctx context.Context) (compute.Contracts, *shared.APIResponse, error)
+
+	ListVolumes(ctx context.Context, datacenterID string) (compute.Volumes, *shared.APIResponse, error)
+	DeleteVolume(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error)
+
+	AddServerLabel(ctx context.Context, datacenterID, id, key, value string) (compute.LabelResource, *shared.APIResponse, error)
+	DeleteServerLabel(ctx context.Context, datacenterID, id, key string) (*shared.APIResponse, error)
+	AddVolumeLabel(ctx context.Context, datacenterID, id, key, value string) (compute.LabelResource, *shared.APIResponse, error)
+	// ListLabels lists the labels with the given key on all resources.
+	ListLabels(ctx context.Context, key string) (compute.Labels, *shared.APIResponse, error)
+	AddSnapshotLabel(ctx context.Context, id, key, value string) (compute.LabelResource, *shared.APIResponse, error)
+	DeleteSnapshotLabel(ctx context.Context, id, key string) (*shared.APIResponse, error)
+	AddDatacenterLabel(ctx context.Context, id, key, value string) (compute.LabelResource, *shared.APIResponse, error)
+	DeleteDatacenterLabel(ctx context.Context, id, key string) (*shared.APIResponse, error)
+
+	WaitForRequest(ctx context.Context, path string) (*shared.APIResponse, error)
+	// ListRequests lists the requests of the contract created after the
+	// given time, with their status.
+	ListRequests(ctx context.Context, createdAfter time.Time) (compute.Requests, *shared.APIResponse, error)
+	// Config returns the configuration used for API requests.
+	Config() *shared.Configuration
+}
+
+// sdkCompute implements computeAPI with the IONOS SDK.
+type sdkCompute struct {
+	client *compute.APIClient
+}
+
+var _ computeAPI = (*sdkCompute)(nil)
+
+// initAPI sets up the SDK backed API client unless one has been injected.
+func (i *InstanceGroup) initAPI(ctx context.Context) error {
+	if i.api != nil {
+		return nil
+	}
+	apiURL := i.APIURL
+	if apiURL == "" {
+		apiURL = os.Getenv(shared.IonosApiUrlEnvVar)
+	}
+	httpClient, err := i.HTTP.client()
+	if err != nil {
+		return err
+	}
+	if i.Vault.enabled() {
+		if err := i.initVault(ctx, httpClient); err != nil {
+			return err
+		}
+	}
+	// The token can be reloaded at runtime, so it is set by the transport
+	// instead of the SDK configuration.
+	i.apiToken = newTokenTransport(httpClient.Transport)
+	token, err := i.loadAPIToken(ctx)
+	if err != nil {
+		return err
+	}
+	i.apiToken.set(token)
+	httpClient = &http.Client{Transport: i.apiToken, Timeout: httpClient.Timeout}
+
+	cfg := shared.NewConfiguration("", "", "", apiURL)
+	// Retries are handled by withRetry, so the SDK only makes a single attempt.
+	cfg.MaxRetries = 1
+	cfg.HTTPClient = httpClient
+	i.api = newSDKCompute(cfg)
+	return nil
+}
+
+func newSDKCompute(cfg *shared.Configuration) *sdkCompute {
+	return &sdkCompute{client: compute.NewAPIClient(cfg)}
+}
+
+func (c *sdkCompute) CreateServer(ctx context.Context, datacenterID string, server compute.Server) (compute.Server, *shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersPost(ctx, datacenterID).Server(server).Execute()
+}
+
+func (c *sdkCompute) DeleteServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersDelete(ctx, datacenterID, id).DeleteVolumes(true).Execute()
+}
+
+func (c *sdkCompute) StopServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersStopPost(ctx, datacenterID, id).Execute()
+}
+
+func (c *sdkCompute) SuspendServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersSuspendPost(ctx, datacenterID, id).Execute()
+}
+
+func (c *sdkCompute) StartServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersStartPost(ctx, datacenterID, id).Execute()
+}
+
+func (c *sdkCompute) ResumeServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersResumePost(ctx, datacenterID, id).Execute()
+}
+
+func (c *sdkCompute) GetServer(ctx context.Context, datacenterID, id string, depth int32) (compute.Server, *shared.APIResponse, error) {
+	return c.client.ServersApi.DatacentersServersFindById(ctx, datacenterID, id).Depth(depth).Execute()
+}
+
+func (c *sdkCompute) ListNics(ctx context.Context, datacenterID, serverID string) (compute.Nics, *shared.APIResponse, error) {
+	return c.client.NetworkInterfacesApi.DatacentersServersNicsGet(ctx, datacenterID, serverID).Depth(1).Execute()
+}
+
+func (c *sdkCompute) ListServers(ctx context.Context, datacenterID, name string, depth, offset, limit int32) (compute.Servers, *shared.APIResponse, error) {
+	req := c.client.ServersApi.DatacentersServersGet(ctx, datacenterID).Filter("name", name).Depth(depth)
+	if limit > 0 {
+		req = req.Offset(offset).Limit(limit)
+	}
+	return req.Execute()
+}
+
+func (c *sdkCompute) ListTemplates(ctx context.Context) (compute.Templates, *shared.APIResponse, error) {
+	return c.client.TemplatesApi.TemplatesGet(ctx).Depth(1).Execute()
+}
+
+func (c *sdkCompute) GetTemplate(ctx context.Context, id string) (compute.Template, *shared.APIResponse, error) {
+	return c.client.TemplatesApi.TemplatesFindById(ctx, id).Execute()
+}
+
+func (c *sdkCompute) GetImage(ctx context.Context, id string) (compute.Image, *shared.APIResponse, error) {
+	return c.client.ImagesApi.ImagesFindById(ctx, id).Depth(0).Execute()
+}
+
+func (c *sdkCompute) ListImages(ctx context.Context) (compute.Images, *shared.APIResponse, error) {
+	return c.client.ImagesApi.ImagesGet(ctx).Depth(1).Execute()
+}
+
+func (c *sdkCompute) ListSnapshots(ctx context.Context) (compute.Snapshots, *shared.APIResponse, error) {
+	return c.client.SnapshotsApi.SnapshotsGet(ctx).Depth(1).Execute()
+}
+
+func (c *sdkCompute) GetSnapshot(ctx context.Context, id string) (compute.Snapshot, *shared.APIResponse, error) {
+	return c.client.SnapshotsApi.SnapshotsFindById(ctx, id).Execute()
+}
+
+func (c *sdkCompute) CreateSnapshot(ctx context.Context, datacenterID, volumeID, name, description string) (compute.Snapshot, *shared.APIResponse, error) {
+	return c.client.VolumesApi.DatacentersVolumesCreateSnapshotPost(ctx, datacenterID, volumeID).Name(name).Description(description).Execute()
+}
+
+func (c *sdkCompute) DeleteSnapshot(ctx context.Context, id string) (*shared.APIResponse, error) {
+	return c.client.SnapshotsApi.SnapshotsDelete(ctx, id).Execute()
+}
+
+func (c *sdkCompute) GetDatacenter(ctx context.Context, id string) (compute.Datacenter, *shared.APIResponse, error) {
+	return c.client.DataCentersApi.DatacentersFindById(ctx, id).Depth(0).Execute()
+}
+
+func (c *sdkCompute) CreateDatacenter(ctx context.Context, datacenter compute.Datacenter) (compute.Datacenter, *shared.APIResponse, error) {
+	return c.client.DataCentersApi.DatacentersPost(ctx).Datacenter(datacenter).Execute()
+}
+
+func (c *sdkCompute) GetLan(ctx context.Context, datacenterID, id string) (compute.Lan, *shared.APIResponse, error) {
+	return c.client.LANsApi.DatacentersLansFindById(ctx, datacenterID, id).Depth(0).Execute()
+}
+
+func (c *sdkCompute) ListLans(ctx context.Context, datacenterID string) (compute.Lans, *shared.APIResponse, error) {
+	return c.client.LANsApi.DatacentersLansGet(ctx, datacenterID).Depth(1).Execute()
+}
+
+func (c *sdkCompute) CreateLan(ctx context.Context, datacenterID string, lan compute.LanPost) (compute.LanPost, *shared.APIResponse, error) {
+	return c.client.LANsApi.DatacentersLansPost(ctx, datacenterID).Lan(lan).Execute()
+}
+
+func (c *sdkCompute) GetIPBlock(ctx context.Context, id string) (compute.IpBlock, *shared.APIResponse, error) {
+	return c.client.IPBlocksApi.IpblocksFindById(ctx, id).Execute()
+}
+
+func (c *sdkCompute) ListNATGateways(ctx context.Context, datacenterID string) (compute.NatGateways, *shared.APIResponse, error) {
+	return c.client.NATGatewaysApi.DatacentersNatgatewaysGet(ctx, datacenterID).Depth(3).Execute()
+}
+
+func (c *sdkCompute) CreateNATGateway(ctx context.Context, datacenterID string, gateway compute.NatGateway) (compute.NatGateway, *shared.APIResponse, error) {
+	return c.client.NATGatewaysApi.DatacentersNatgatewaysPost(ctx, datacenterID).NatGateway(gateway).Execute()
+}
+
+func (c *sdkCompute) CreateNATGatewayRule(ctx context.Context, datacenterID, gatewayID string, rule compute.NatGatewayRule) (compute.NatGatewayRule, *shared.APIResponse, error) {
+	return c.client.NATGatewaysApi.DatacentersNatgatewaysRulesPost(ctx, datacenterID, gatewayID).NatGatewayRule(rule).Execute()
+}
+
+func (c *sdkCompute) ListContracts(ctx context.Context) (compute.Contracts, *shared.APIResponse, error) {
+	return c.client.ContractResourcesApi.ContractsGet(ctx).Execute()
+}
+
+func (c *sdkCompute) ListVolumes(ctx context.Context, datacenterID string) (compute.Volumes, *shared.APIResponse, error) {
+	return c.client.VolumesApi.DatacentersVolumesGet(ctx, datacenterID).Depth(1).Execute()
+}
+
+func (c *sdkCompute) DeleteVolume(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return c.client.VolumesApi.DatacentersVolumesDelete(ctx, datacenterID, id).Execute()
+}
+
+func (c *sdkCompute) AddServerLabel(ctx context.Context, datacenterID, id, key, value string) (compute.LabelResource, *shared.APIResponse, error) {
+	label := compute.LabelResource{
+		Properties: &compute.LabelResourceProperties{Key: &key, Value: &value},
+	}
+	return c.client.LabelsApi.DatacentersServersLabelsPost(ctx, datacenterID, id).Label(label).Execute()
+}
+
+func (c *sdkCompute) DeleteServerLabel(ctx context.Context, datacenterID, id, key string) (*shared.APIResponse, error) {
+	return c.client.LabelsApi.DatacentersServersLabelsDelete(ctx, datacenterID, id, key).Execute()
+}
+
+func (c *sdkCompute) AddVolumeLabel(ctx context.Context, datacenterID, id, key, value string) (compute.LabelResource, *shared.APIResponse, error) {
+	label := compute.LabelResource{
+		Properties: &compute.LabelResourceProperties{Key: &key, Value: &value},
+	}
+	return c.client.LabelsApi.DatacentersVolumesLabelsPost(ctx, datacenterID, id).Label(label).Execute()
+}
+
+func (c *sdkCompute) AddDatacenterLabel(ctx context.Context, id, key, value string) (compute.LabelResource, *shared.APIResponse, error) {
+	label := compute.LabelResource{
+		Properties: &compute.LabelResourceProperties{Key: &key, Value: &value},
+	}
+	return c.client.LabelsApi.DatacentersLabelsPost(ctx, id).Label(label).Execute()
+}
+
+func (c *sdkCompute) DeleteDatacenterLabel(ctx context.Context, id, key string) (*shared.APIResponse, error) {
+	return c.client.LabelsApi.DatacentersLabelsDelete(ctx, id, key).Execute()
+}
+
+func (c *sdkCompute) AddSnapshotLabel(ctx context.Context, id, key, value string) (compute.LabelResource, *shared.APIResponse, error) {
+	label := compute.LabelResource{
+		Properties: &compute.LabelResourceProperties{Key: &key, Value: &value},
+	}
+	return c.client.LabelsApi.SnapshotsLabelsPost(ctx, id).Label(label).Execute()
+}
+
+func (c *sdkCompute) DeleteSnapshotLabel(ctx context.Context, id, key string) (*shared.APIResponse, error) {
+	return c.client.LabelsApi.SnapshotsLabelsDelete(ctx, id, key).Execute()
+}
+
+func (c *sdkCompute) ListLabels(ctx context.Context, key string) (compute.Labels, *shared.APIResponse, error) {
+	return c.client.LabelsApi.LabelsGet(ctx).Filter("key", key).Depth(1).Execute()
+}
+
+func (c *sdkCompute) WaitForRequest(ctx context.Context, path string) (*shared.APIResponse, error) {
+	return c.client.WaitForRequest(ctx, path)
+}
+
+func (c *sdkCompute) ListRequests(ctx context.Context, createdAfter time.Time) (compute.Requests, *shared.APIResponse, error) {
+	return c.client.RequestsApi.RequestsGet(ctx).Depth(2).FilterCreatedAfter(createdAfter.UTC().Format("2006-01-02 15:04:05")).Execute()
+}
+
+func (c *sdkCompute) Config() *shared.Configuration {
+	return c.client.GetConfig()
+}
@@ -0,0 +1,401 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// mockCompute is a computeAPI backed by a fixed list of servers and labels.
+// Calls the tests do not expect panic on the nil embedded interface.
+type mockCompute struct {
+	computeAPI
+
+	config *shared.Configuration
+	// delay slows down GetServer, to widen races between callers.
+	delay time.Duration
+	// pageLinks adds pagination links to ListServers responses.
+	pageLinks bool
+	// lingering keeps deleted servers in the server list.
+	lingering bool
+	// requestErr fails and requestStuck blocks the requests waited for.
+	requestErr   error
+	requestStuck bool
+
+	mu          sync.Mutex
+	servers     []compute.Server
+	labels      []compute.Label
+	datacenters []compute.Datacenter
+	templates   []compute.Template
+	volumes     []compute.Volume
+	images      []compute.Image
+	snapshots   []compute.Snapshot
+	ipBlocks    []compute.IpBlock
+	limits      compute.ResourceLimits
+	// createErrs fail the next CreateServer calls in order, nil entries
+	// let a call through.
+	createErrs []error
+	// lostCreates is the number of CreateServer calls that create the
+	// server but fail as if the response got lost.
+	lostCreates int
+
+	posted []compute.Server
+	// postedTo lists the datacenter of each posted server.
+	postedTo []string
+	deleted  []string
+	// powered lists the power actions, e.g. "stop server-1".
+	powered   []string
+	listCalls int
+}
+
+func (m *mockCompute) Config() *shared.Configuration {
+	return m.config
+}
+
+func (m *mockCompute) GetDatacenter(ctx context.Context, id string) (compute.Datacenter, *shared.APIResponse, error) {
+	for _, datacenter := range m.datacenters {
+		if *datacenter.Id == id {
+			return datacenter, response(http.StatusOK), nil
+		}
+	}
+	return compute.Datacenter{}, response(http.StatusNotFound), apiError(http.StatusNotFound, "datacenter not found")
+}
+
+func (m *mockCompute) ListImages(ctx context.Context) (compute.Images, *shared.APIResponse, error) {
+	items := slices.Clone(m.images)
+	return compute.Images{Items: &items}, response(http.StatusOK), nil
+}
+
+func (m *mockCompute) ListSnapshots(ctx context.Context) (compute.Snapshots, *shared.APIResponse, error) {
+	items := slices.Clone(m.snapshots)
+	return compute.Snapshots{Items: &items}, response(http.StatusOK), nil
+}
+
+func (m *mockCompute) GetIPBlock(ctx context.Context, id string) (compute.IpBlock, *shared.APIResponse, error) {
+	for _, block := range m.ipBlocks {
+		if *block.Id == id {
+			return block, response(http.StatusOK), nil
+		}
+	}
+	return compute.IpBlock{}, response(http.StatusNotFound), apiError(http.StatusNotFound, "IP block not found")
+}
+
+// CreateServer records the posted server and adds it as BUSY, unless the next
+// of createErrs fails the call.
+func (m *mockCompute) CreateServer(ctx context.Context, datacenterID string, server compute.Server) (compute.Server, *shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.posted = append(m.posted, server)
+	m.postedTo = append(m.postedTo, datacenterID)
+	if len(m.createErrs) > 0 {
+		err := m.createErrs[0]
+		m.createErrs = m.createErrs[1:]
+		var apiErr shared.GenericOpenAPIError
+		if errors.As(err, &apiErr) {
+			return compute.Server{}, response(apiErr.StatusCode()), err
+		}
+		if err != nil {
+			return compute.Server{}, nil, err
+		}
+	}
+	server.Id = StrPtr(fmt.Sprintf("server-%d", len(m.servers)+1))
+	server.Metadata = &compute.DatacenterElementMetadata{State: StrPtr("BUSY")}
+	m.servers = append(m.servers, server)
+	if m.lostCreates > 0 {
+		m.lostCreates--
+		return compute.Server{}, nil, errors.New("connection reset by peer")
+	}
+	apiResponse := response(http.StatusAccepted)
+	apiResponse.Header.Set("Location", "/requests/create-"+*server.Id+"/status")
+	return server, apiResponse, nil
+}
+
+func (m *mockCompute) GetServer(ctx context.Context, datacenterID, id string, depth int32) (compute.Server, *shared.APIResponse, error) {
+	time.Sleep(m.delay)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, server := range m.servers {
+		if *server.Id == id {
+			return server, response(http.StatusOK), nil
+		}
+	}
+	return compute.Server{}, response(http.StatusNotFound), apiError(http.StatusNotFound, "server not found")
+}
+
+// ListServers pages through the servers before filtering them by name, like
+// the API, so pages can be short while there are more servers. With
+// pageLinks it links the next page.
+func (m *mockCompute) ListServers(ctx context.Context, datacenterID, name string, depth, offset, limit int32) (compute.Servers, *shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listCalls++
+	page := m.servers
+	end := len(m.servers)
+	if limit > 0 {
+		end = min(int(offset+limit), len(m.servers))
+		page = m.servers[min(int(offset), end):end]
+	}
+	var items []compute.Server
+	for _, server := range page {
+		if server.Properties == nil || strings.Contains(*server.Properties.Name, name) {
+			items = append(items, server)
+		}
+	}
+	servers := compute.Servers{Items: &items}
+	if m.pageLinks {
+		servers.Links = &compute.PaginationLinks{}
+		if end < len(m.servers) {
+			servers.Links.Next = StrPtr("next")
+		}
+	}
+	return servers, response(http.StatusOK), nil
+}
+
+func (m *mockCompute) ListNics(ctx context.Context, datacenterID, serverID string) (compute.Nics, *shared.APIResponse, error) {
+	server, apiResponse, err := m.GetServer(ctx, datacenterID, serverID, 2)
+	if err != nil || server.Entities == nil || server.Entities.Nics == nil {
+		return compute.Nics{}, apiResponse, err
+	}
+	return *server.Entities.Nics, apiResponse, nil
+}
+
+// DeleteServer removes the server, unless the mock keeps deleted servers
+// lingering, and returns the Location of the delete request.
+func (m *mockCompute) DeleteServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, id)
+	if !m.lingering {
+		m.servers = slices.DeleteFunc(m.servers, func(server compute.Server) bool { return *server.Id == id })
+	}
+	apiResponse := response(http.StatusAccepted)
+	apiResponse.Header.Set("Location", "/requests/delete-"+id+"/status")
+	return apiResponse, nil
+}
+
+// WaitForRequest fails with requestErr, or blocks until ctx is done if
+// requestStuck is set.
+func (m *mockCompute) WaitForRequest(ctx context.Context, path string) (*shared.APIResponse, error) {
+	if m.requestStuck {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return response(http.StatusOK), m.requestErr
+}
+
+func (m *mockCompute) StopServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return m.power("stop", id, "SHUTOFF")
+}
+
+func (m *mockCompute) SuspendServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return m.power("suspend", id, "SUSPENDED")
+}
+
+func (m *mockCompute) StartServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return m.power("start", id, "RUNNING")
+}
+
+func (m *mockCompute) ResumeServer(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	return m.power("resume", id, "RUNNING")
+}
+
+// power records a power action and sets the VM state of the server.
+func (m *mockCompute) power(action, id, vmState string) (*shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.powered = append(m.powered, action+" "+id)
+	for _, server := range m.servers {
+		if *server.Id == id {
+			server.Properties.VmState = &vmState
+		}
+	}
+	return response(http.StatusAccepted), nil
+}
+
+func (m *mockCompute) AddServerLabel(ctx context.Context, datacenterID, id, key, value string) (compute.LabelResource, *shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labels = append(m.labels, testLabel(id, key, value))
+	return compute.LabelResource{}, response(http.StatusCreated), nil
+}
+
+func (m *mockCompute) DeleteServerLabel(ctx context.Context, datacenterID, id, key string) (*shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labels = slices.DeleteFunc(m.labels, func(label compute.Label) bool {
+		return *label.Properties.ResourceId == id && *label.Properties.Key == key
+	})
+	return response(http.StatusOK), nil
+}
+
+// ListVolumes returns no items at all if the mock has no volumes, like a
+// sparse API response.
+func (m *mockCompute) ListVolumes(ctx context.Context, datacenterID string) (compute.Volumes, *shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.volumes == nil {
+		return compute.Volumes{}, response(http.StatusOK), nil
+	}
+	items := slices.Clone(m.volumes)
+	return compute.Volumes{Items: &items}, response(http.StatusOK), nil
+}
+
+func (m *mockCompute) DeleteVolume(ctx context.Context, datacenterID, id string) (*shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, id)
+	return response(http.StatusAccepted), nil
+}
+
+func (m *mockCompute) ListLabels(ctx context.Context, key string) (compute.Labels, *shared.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []compute.Label
+	for _, label := range m.labels {
+		if *label.Properties.Key == key {
+			items = append(items, label)
+		}
+	}
+	return compute.Labels{Items: &items}, response(http.StatusOK), nil
+}
+
+func (m *mockCompute) GetTemplate(ctx context.Context, id string) (compute.Template, *shared.APIResponse, error) {
+	for _, template := range m.templates {
+		if *template.Id == id {
+			return template, response(http.StatusOK), nil
+		}
+	}
+	return compute.Template{}, response(http.StatusNotFound), apiError(http.StatusNotFound, "template not found")
+}
+
+func (m *mockCompute) ListContracts(ctx context.Context) (compute.Contracts, *shared.APIResponse, error) {
+	items := []compute.Contract{{Properties: &compute.ContractProperties{ResourceLimits: &m.limits}}}
+	return compute.Contracts{Items: &items}, response(http.StatusOK), nil
+}
+
+// newTestGroup returns an instance group named "runner" in datacenter dc1
+// that calls api instead of the IONOS API.
+func newTestGroup(api computeAPI) *InstanceGroup {
+	i := &InstanceGroup{
+		DatacenterId: "dc1",
+		ServerSpec:   ServerSpec{Name: "runner"},
+		Retry:        RetryConfig{InitialBackoff: Duration(1), MaxBackoff: Duration(1)},
+		api:          api,
+		log:          hclog.NewNullLogger(),
+		registry:     newRegistry(),
+	}
+	i.metrics = newMetrics(i.groupLabel())
+	return i
+}
+
+func testDatacenter(id, location string) compute.Datacenter {
+	return compute.Datacenter{Id: &id, Properties: &compute.DatacenterProperties{Name: &id, Location: &location}}
+}
+
+func testServer(id, name, state string) compute.Server {
+	return compute.Server{
+		Id:         &id,
+		Properties: &compute.ServerProperties{Name: &name, VmState: StrPtr("RUNNING")},
+		Metadata:   &compute.DatacenterElementMetadata{State: &state},
+	}
+}
+
+// withNICs adds a boot volume and NICs to a server, each NIC given as the
+// LAN and its IP.
+func withNICs(server compute.Server, nics map[int32]string) compute.Server {
+	var items []compute.Nic
+	for lan, ip := range nics {
+		items = append(items, compute.Nic{Properties: &compute.NicProperties{Lan: &lan, Ips: &[]string{ip}}})
+	}
+	server.Entities = &compute.ServerEntities{
+		Nics:    &compute.Nics{Items: &items},
+		Volumes: &compute.AttachedVolumes{Items: &[]compute.Volume{{Id: StrPtr("volume")}}},
+	}
+	return server
+}
+
+// testVolume returns a volume, attached to a server or not.
+func testVolume(id, name, state string, attached bool) compute.Volume {
+	v := compute.Volume{
+		Id:         &id,
+		Properties: &compute.VolumeProperties{Name: &name},
+		Metadata:   &compute.DatacenterElementMetadata{State: &state},
+	}
+	if attached {
+		v.Properties.BootServer = StrPtr("server")
+	}
+	return v
+}
+
+func testLabel(id, key, value string) compute.Label {
+	return compute.Label{Properties: &compute.LabelProperties{
+		Key:          &key,
+		Value:        &value,
+		ResourceId:   &id,
+		ResourceType: StrPtr("server"),
+	}}
+}
+
+// testConfig returns an API configuration that sends raw requests to url.
+func testConfig(url string) *shared.Configuration {
+	cfg := shared.NewConfiguration("", "", "token", url)
+	cfg.HTTPClient = http.DefaultClient
+	return cfg
+}
+
+func response(status int) *shared.APIResponse {
+	return &shared.APIResponse{Response: &http.Response{StatusCode: status, Header: http.Header{}}}
+}
+
+func apiError(status int, message string) error {
+	return *shared.NewGenericOpenAPIError(message, []byte(message), nil, status)
+}
+
+func TestSDKComputeListServers(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		offset int32
+		limit  int32
+		want   url.Values
+	}{
+		{"default page", 0, 0, url.Values{"depth": {"3"}, "filter.name": {"runner"}}},
+		{"page", 20, 10, url.Values{"depth": {"3"}, "filter.name": {"runner"}, "offset": {"20"}, "limit": {"10"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"items":[{"id":"server"}]}`))
+			}))
+			defer srv.Close()
+
+			servers, _, err := newSDKCompute(testConfig(srv.URL)).ListServers(context.Background(), "dc1", "runner", 3, tc.offset, tc.limit)
+			if err != nil {
+				t.Fatalf("ListServers: %v", err)
+			}
+			if servers.Items == nil || len(*servers.Items) != 1 {
+				t.Errorf("ListServers returned %+v", servers)
+			}
+			if got.URL.Path != "/datacenters/dc1/servers" {
+				t.Errorf("ListServers requested %s", got.URL.Path)
+			}
+			if query := got.URL.Query(); !maps.EqualFunc(query, tc.want, slices.Equal) {
+				t.Errorf("ListServers sent the query %v, want %v", query, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+const (
+	osLinux   = "linux"
+	osWindows = "windows"
+
+	// IONOS Cloud servers, ENTERPRISE and CUBE, are x86-64 only.
+	archAMD64 = "amd64"
+)
+
+// isWindows reports whether instances run Windows, as configured by os or,
+// if os is not set, derived from licence_type or the licence type of the
+// image.
+func (i *InstanceGroup) isWindows() bool {
+	if i.ServerSpec.OS == "" {
+		return i.image.isWindows() || strings.HasPrefix(strings.ToUpper(i.ServerSpec.LicenceType), "WINDOWS")
+	}
+	return strings.EqualFold(i.ServerSpec.OS, osWindows)
+}
+
+// connectorConfig returns the connector config from the runner with defaults
+// for the configured OS filled in. Windows instances are reached over WinRM
+// as Administrator, whose password is the image password. Linux instances
+// are reached over SSH as the default user of the image and use the managed
+// SSH key unless the runner configures a key. OS and Arch are filled in so
+// fleeting can pick the matching runner helper binaries.
+func (i *InstanceGroup) connectorConfig() provider.ConnectorConfig {
+	cfg := i.settings.ConnectorConfig
+	if cfg.Arch == "" {
+		cfg.Arch = archAMD64
+	}
+	if !i.isWindows() {
+		i.linuxConnectorDefaults(&cfg)
+		if len(cfg.Key) == 0 && i.sshKey != nil {
+			cfg.Key = i.sshKey.privateKey
+		}
+		return cfg
+	}
+
+	if cfg.OS == "" {
+		cfg.OS = osWindows
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = provider.ProtocolWinRM
+	}
+	if cfg.ProtocolPort == 0 {
+		cfg.ProtocolPort = provider.DefaultProtocolPorts[cfg.Protocol]
+	}
+	if cfg.Username == "" {
+		cfg.Username = "Administrator"
+	}
+	if cfg.Password == "" {
+		cfg.Password = i.ServerSpec.ImagePassword
+	}
+	return cfg
+}
+
+// privateNIC returns the NIC of a server in its private LAN. Only the NICs
+// are fetched, which is faster than fetching the server at depth 2 on the
+// path between job assignment and job start.
+func (i *InstanceGroup) privateNIC(ctx context.Context, datacenterID, instance string) (compute.Nic, error) {
+	nics, _, err := withRetry(ctx, i, "NicsGet", func(ctx context.Context) (compute.Nics, *shared.APIResponse, error) {
+		return i.api.ListNics(ctx, datacenterID, instance)
+	})
+	if err != nil {
+		return compute.Nic{}, fmt.Errorf("failed to get NICs of server %v: %w", instance, err)
+	}
+	if nics.Items == nil {
+		return compute.Nic{}, fmt.Errorf("server has no NIC")
+	}
+	nic, ok := i.findPrivateNIC(*nics.Items, datacenterID)
+	if !ok {
+		return compute.Nic{}, fmt.Errorf("server has no NIC in the private LAN")
+	}
+	return nic, nil
+}
+
+// privateNICName is the name the plugin gives the NIC in the private LAN.
+const privateNICName = "privateNIC"
+
+// findPrivateNIC returns the NIC of a server in the datacenter's private LAN.
+func (i *InstanceGroup) findPrivateNIC(nics []compute.Nic, datacenterID string) (compute.Nic, bool) {
+	return findPrivateNIC(nics, i.lanOf(datacenterID), i.ServerSpec.PublicLanID)
+}
+
+// findPrivateNIC returns the NIC in the private LAN lanID. If lanID is not
+// known, e.g. for the servers of an autoscaling group or node pool, which
+// the plugin neither creates nor provisions the LAN for, it returns the NIC
+// named privateNIC by the plugin or else the only NIC outside the public LAN
+// publicLanID, preferring one with a private IP if there are several. The
+// API does not list NICs in creation order, so the first NIC may be the
+// public one.
+func findPrivateNIC(nics []compute.Nic, lanID, publicLanID int32) (compute.Nic, bool) {
+	var candidates, private []compute.Nic
+	for _, nic := range nics {
+		if nic.Properties == nil {
+			continue
+		}
+		if lanID != 0 {
+			if nic.Properties.Lan != nil && *nic.Properties.Lan == lanID {
+				return nic, true
+			}
+			continue
+		}
+		if nic.Properties.Name != nil && *nic.Properties.Name == privateNICName {
+			return nic, true
+		}
+		if publicLanID != 0 && nic.Properties.Lan != nil && *nic.Properties.Lan == publicLanID {
+			continue
+		}
+		candidates = append(candidates, nic)
+		if ip, err := nicIP(nic); err == nil {
+			if addr, err := netip.ParseAddr(ip); err == nil && addr.IsPrivate() {
+				private = append(private, nic)
+			}
+		}
+	}
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], true
+	case len(private) == 1:
+		return private[0], true
+	}
+	return compute.Nic{}, false
+}
+
+// nicIP returns the first IP of a NIC.
+func nicIP(nic compute.Nic) (string, error) {
+	if nic.Properties == nil || nic.Properties.Ips == nil || len(*nic.Properties.Ips) == 0 {
+		return "", fmt.Errorf("server NIC has no IP")
+	}
+	return (*nic.Properties.Ips)[0], nil
+}
+
+// waitForAvailable fetches a server without its NICs and volumes and, if connect_wait is set,
+// polls with backoff until it is AVAILABLE or the wait expires.
+func (i *InstanceGroup) waitForAvailable(ctx context.Context, instance string) (compute.Server, error) {
+	deadline := time.Now().Add(time.Duration(i.ConnectWait))
+	cfg := i.Retry.withDefaults()
+
+	dc := i.datacenterOf(ctx, instance)
+	for attempt := 1; ; attempt++ {
+		server, _, err := withRetry(ctx, i, "ConnectInfo", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, instance, propertiesDepth)
+		})
+		if err != nil {
+			return compute.Server{}, fmt.Errorf("failed to get server with ID: %v, error: %w", instance, err)
+		}
+
+		state := *server.Metadata.State
+		if state == "AVAILABLE" {
+			return server, nil
+		}
+
+		wait := backoff(cfg, attempt)
+		if remaining := time.Until(deadline); remaining <= 0 {
+			return compute.Server{}, fmt.Errorf("server is not in the AVAILABLE State")
+		} else if wait > remaining {
+			wait = remaining
+		}
+		i.log.Debug("Waiting for server to become AVAILABLE", "instance", instance, "state", state, "wait", wait)
+
+		select {
+		case <-ctx.Done():
+			return compute.Server{}, fmt.Errorf("waiting for server %v to become AVAILABLE: %w", instance, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+func TestFindPrivateNIC(t *testing.T) {
+	nic := func(name string, lan int32, ip string) compute.Nic {
+		return compute.Nic{Properties: &compute.NicProperties{Name: &name, Lan: &lan, Ips: &[]string{ip}}}
+	}
+	public := nic("", 1, "203.0.113.10")
+	private := nic("", 3, "10.7.222.10")
+	named := nic(privateNICName, 3, "10.7.222.11")
+	other := nic("", 4, "10.7.223.10")
+
+	tests := []struct {
+		name        string
+		nics        []compute.Nic
+		lanID       int32
+		publicLanID int32
+		want        *compute.Nic
+	}{
+		{name: "known LAN", nics: []compute.Nic{public, private}, lanID: 3, want: &private},
+		{name: "known LAN missing", nics: []compute.Nic{public}, lanID: 3},
+		{name: "named by the plugin", nics: []compute.Nic{public, named}, want: &named},
+		{name: "only NIC", nics: []compute.Nic{public}, want: &public},
+		{name: "outside the public LAN", nics: []compute.Nic{public, private}, publicLanID: 1, want: &private},
+		{name: "only private IP", nics: []compute.Nic{public, private}, want: &private},
+		{name: "ambiguous", nics: []compute.Nic{private, other}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findPrivateNIC(tt.nics, tt.lanID, tt.publicLanID)
+			if ok != (tt.want != nil) {
+				t.Fatalf("findPrivateNIC() found %v, want %v", ok, tt.want != nil)
+			}
+			if ok && (*got.Properties.Lan != *tt.want.Properties.Lan || (*got.Properties.Ips)[0] != (*tt.want.Properties.Ips)[0]) {
+				t.Errorf("findPrivateNIC() = %s, want %s", (*got.Properties.Ips)[0], (*tt.want.Properties.Ips)[0])
+			}
+		})
+	}
+}
+
+// fakeAutoscalingGroup serves the VM Auto Scaling endpoints
+// autoscalingBackend uses for a group with the given member servers.
+type fakeAutoscalingGroup struct {
+	mu      sync.Mutex
+	members []string
+	target  int
+}
+
+func (f *fakeAutoscalingGroup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/groups/group/servers":
+		var items []map[string]any
+		for _, id := range f.members {
+			items = append(items, map[string]any{"properties": map[string]any{"datacenterServer": map[string]any{"id": id}}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(autoscalingGroup{ID: "group", Properties: map[string]any{"targetReplicaCount": f.target}})
+	case r.Method == http.MethodPut:
+		var body struct {
+			Properties struct {
+				TargetReplicaCount int `json:"targetReplicaCount"`
+			} `json:"properties"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.target = body.Properties.TargetReplicaCount
+	}
+}
+
+func TestBackendConnectInfoAndHeartbeat(t *testing.T) {
+	// Servers of an autoscaling group have no NIC named by the plugin, and
+	// Init does not know their private LAN.
+	api := &mockCompute{servers: []compute.Server{
+		withNICs(testServer("healthy", "asg-1", "AVAILABLE"), map[int32]string{1: "203.0.113.10", 3: "10.7.222.10"}),
+		withNICs(testServer("unhealthy", "asg-2", "FAILED"), map[int32]string{1: "203.0.113.11", 3: "10.7.222.11"}),
+	}}
+	group := &fakeAutoscalingGroup{members: []string{"healthy", "unhealthy"}, target: 2}
+	server := httptest.NewServer(group)
+	defer server.Close()
+
+	i := newTestGroup(api)
+	i.Autoscaling = AutoscalingConfig{GroupID: "group", APIURL: server.URL}
+	i.ServerSpec.PublicLanID = 1
+	i.ReplaceAfter = 1
+	api.config = testConfig(server.URL)
+	i.startBackground()
+	defer i.stopBackground()
+
+	info, err := i.ConnectInfo(context.Background(), "healthy")
+	if err != nil {
+		t.Fatalf("ConnectInfo: %v", err)
+	}
+	if info.InternalAddr != "10.7.222.10" {
+		t.Errorf("ConnectInfo internal address %q, want the private IP 10.7.222.10", info.InternalAddr)
+	}
+
+	if err := i.Heartbeat(context.Background(), "healthy"); err != nil {
+		t.Errorf("Heartbeat of a healthy backend instance: %v", err)
+	}
+
+	if err := i.Heartbeat(context.Background(), "unhealthy"); !errors.Is(err, provider.ErrInstanceUnhealthy) {
+		t.Errorf("Heartbeat of an unhealthy backend instance = %v, want %v", err, provider.ErrInstanceUnhealthy)
+	}
+	// Wait for the replacement to finish.
+	i.bgWG.Wait()
+	if len(api.deleted) != 1 || api.deleted[0] != "unhealthy" {
+		t.Errorf("replacement deleted %v, want [unhealthy]", api.deleted)
+	}
+	if group.target != 1 {
+		t.Errorf("autoscaling target %d after replacing an instance, want 1", group.target)
+	}
+}
@@ -0,0 +1,124 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// hoursPerMonth is the average number of hours in a month, as used by the
+// IONOS price list.
+const hoursPerMonth = 730
+
+// Pricing holds the hourly rates used for cost estimates. IONOS prices
+// depend on the contract and location, so there are no defaults.
+type Pricing struct {
+	Currency      string  `json:"currency"`
+	CoreHour      float64 `json:"core_hour"`
+	RamGBHour     float64 `json:"ram_gb_hour"`
+	StorageGBHour float64 `json:"storage_gb_hour"`
+	// CubeHour is the hourly price of 'CUBE' templates by name or ID,
+	// including their storage. Templates without a price are estimated from
+	// their resources.
+	CubeHour map[string]float64 `json:"cube_hour"`
+}
+
+func (p Pricing) configured() bool {
+	return p.CoreHour != 0 || p.RamGBHour != 0 || p.StorageGBHour != 0 || len(p.CubeHour) > 0
+}
+
+// CostEstimate is the estimated cost of the group instances.
+type CostEstimate struct {
+	Currency     string
+	Instances    int
+	InstanceHour float64
+	Hourly       float64
+	Monthly      float64
+}
+
+// instanceCost returns the estimated hourly cost of a single instance.
+func (i *InstanceGroup) instanceCost(ctx context.Context) (float64, error) {
+	p := i.Pricing
+
+	if i.ServerSpec.Type != "CUBE" {
+		return float64(i.ServerSpec.Cores)*p.CoreHour +
+			float64(i.ServerSpec.Ram)/1024*p.RamGBHour +
+			float64(i.ServerSpec.StorageSize)*p.StorageGBHour, nil
+	}
+
+	spec := i.spec()
+	if i.ServerSpec.selectsTemplate() && spec.TemplateID == "" {
+		var err error
+		if spec, err = i.resolveSpec(ctx); err != nil {
+			return 0, err
+		}
+	}
+	for _, key := range []string{spec.TemplateName, spec.TemplateID} {
+		if price, ok := p.CubeHour[key]; ok && key != "" {
+			return price, nil
+		}
+	}
+
+	templateID := spec.TemplateID
+	if templateID == "" {
+		var err error
+		if templateID, err = i.getTemplateID(ctx, spec.TemplateName); err != nil {
+			return 0, err
+		}
+	}
+	template, _, err := withRetry(ctx, i, "TemplatesFindById", func(ctx context.Context) (compute.Template, *shared.APIResponse, error) {
+		return i.api.GetTemplate(ctx, templateID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("getting template %v: %w", templateID, err)
+	}
+	props := template.Properties
+	return float64(*props.Cores)*p.CoreHour +
+		float64(*props.Ram)/1024*p.RamGBHour +
+		float64(*props.StorageSize)*p.StorageGBHour, nil
+}
+
+// EstimateCost estimates the cost of the group instances that are not
+// deallocated from the configured pricing.
+func (i *InstanceGroup) EstimateCost(ctx context.Context) (CostEstimate, error) {
+	instanceHour, err := i.instanceCost(ctx)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	count, err := i.countInstances(ctx)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	estimate := CostEstimate{
+		Currency:     i.Pricing.Currency,
+		Instances:    count,
+		InstanceHour: instanceHour,
+		Hourly:       float64(count) * instanceHour,
+	}
+	estimate.Monthly = estimate.Hourly * hoursPerMonth
+	return estimate, nil
+}
+
+// recordCost updates the cost metrics for the given number of instances. The
+// per-instance cost is looked up once and reused.
+func (i *InstanceGroup) recordCost(ctx context.Context, count int) {
+	i.costMu.Lock()
+	defer i.costMu.Unlock()
+
+	if i.costPerHour == nil {
+		cost, err := i.instanceCost(ctx)
+		if err != nil {
+			i.log.Warn("Failed to estimate instance cost", "err", err)
+			return
+		}
+		i.costPerHour = &cost
+	}
+
+	hourly := float64(count) * *i.costPerHour
+	i.metrics.instanceHourlyFee.Set(*i.costPerHour)
+	i.metrics.estimatedHourly.Set(hourly)
+	i.metrics.estimatedMonthly.Set(hourly * hoursPerMonth)
+}
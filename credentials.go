@@ -0,0 +1,156 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// minCredentialReload limits how often a 401 response reloads the
+// credentials, so a revoked token does not hammer Vault or the disk.
+const minCredentialReload = 10 * time.Second
+
+// tokenTransport sets the bearer token of API requests from a source that
+// can change while the plugin runs, e.g. Vault. The SDK configuration only
+// holds a static token, so it is left empty when the transport is used.
+type tokenTransport struct {
+	base http.RoundTripper
+
+	mu    sync.RWMutex
+	token string
+}
+
+func newTokenTransport(base http.RoundTripper) *tokenTransport {
+	return &tokenTransport{base: base}
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	token := t.token
+	t.mu.RUnlock()
+	if token != "" && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *tokenTransport) get() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.token
+}
+
+// set replaces the token for subsequent requests and reports whether it
+// changed.
+func (t *tokenTransport) set(token string) bool {
+	secrets.add(token)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changed := t.token != token
+	t.token = token
+	return changed
+}
+
+// loadAPIToken reads the IONOS token from its source: Vault, ionos_token_file,
+// ionos_token or IONOS_TOKEN, in that order.
+func (i *InstanceGroup) loadAPIToken(ctx context.Context) (string, error) {
+	switch {
+	case i.vault != nil:
+		i.vault.invalidate()
+		token, err := i.vault.apiToken(ctx)
+		if err != nil {
+			// The Vault token may have been revoked as well.
+			if loginErr := i.vault.login(ctx); loginErr != nil {
+				return "", err
+			}
+			token, err = i.vault.apiToken(ctx)
+		}
+		return token, err
+	case i.TokenFile != "":
+		data, err := os.ReadFile(i.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("reading ionos_token_file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case i.Token != "":
+		return i.Token, nil
+	}
+	return os.Getenv(shared.IonosTokenEnvVar), nil
+}
+
+// reloadCredentials reads the token from its source again and reports
+// whether it changed, so a rotated token is used without restarting the
+// plugin.
+func (i *InstanceGroup) reloadCredentials(ctx context.Context) (bool, error) {
+	if i.apiToken == nil {
+		return false, nil
+	}
+	token, err := i.loadAPIToken(ctx)
+	if err != nil {
+		return false, fmt.Errorf("reloading credentials: %w", err)
+	}
+	if token == "" {
+		return false, fmt.Errorf("reloading credentials: token is empty")
+	}
+
+	i.reloadMu.Lock()
+	i.reloadedAt = time.Now()
+	i.reloadMu.Unlock()
+
+	changed := i.apiToken.set(token)
+	if changed {
+		i.log.Info("Reloaded IONOS credentials")
+	}
+	return changed, nil
+}
+
+// reloadUnauthorized reloads the credentials after a 401 response and
+// reports whether the request should be repeated with the new token.
+func (i *InstanceGroup) reloadUnauthorized(ctx context.Context) bool {
+	i.reloadMu.Lock()
+	recent := time.Since(i.reloadedAt) < minCredentialReload
+	i.reloadMu.Unlock()
+	if recent {
+		return false
+	}
+	changed, err := i.reloadCredentials(ctx)
+	if err != nil {
+		i.log.Error("Failed to reload credentials after 401", "err", err)
+	}
+	return changed
+}
+
+// startReloadSignal reloads the credentials on SIGHUP, e.g. after the token
+// file has been replaced.
+func (i *InstanceGroup) startReloadSignal() {
+	if i.apiToken == nil {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	i.bgWG.Add(1)
+	go func() {
+		defer i.bgWG.Done()
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-i.bgCtx.Done():
+				return
+			case <-signals:
+				if _, err := i.reloadCredentials(i.bgCtx); err != nil {
+					i.log.Error("Failed to reload credentials", "err", err)
+				}
+			}
+		}
+	}()
+}
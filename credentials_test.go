@@ -0,0 +1,59 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+func TestWithRetryReloadsCredentialsOn401(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		rotate bool
+		tokens []string
+		class  ErrorClass
+	}{
+		{"rotated token", true, []string{"old-token", "new-token"}, ""},
+		{"same token", false, []string{"old-token"}, ErrClassAuth},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(tokenFile, []byte("old-token"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			i := newTestGroup(nil)
+			i.TokenFile = tokenFile
+			i.apiToken = newTokenTransport(nil)
+			i.apiToken.set("old-token")
+
+			var tokens []string
+			_, err := withRetryNoResult(context.Background(), i, "Test", func(ctx context.Context) (*shared.APIResponse, error) {
+				tokens = append(tokens, i.apiToken.get())
+				if i.apiToken.get() != "old-token" {
+					return response(http.StatusOK), nil
+				}
+				if tc.rotate {
+					// The token is rotated while the request is on its way.
+					if err := os.WriteFile(tokenFile, []byte("new-token"), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return response(http.StatusUnauthorized), apiError(http.StatusUnauthorized, "unauthorized")
+			})
+			if tc.class == "" && err != nil {
+				t.Fatalf("withRetry: %v", err)
+			}
+			if tc.class != "" && !errors.Is(err, tc.class) {
+				t.Errorf("withRetry error %v is not of class %s", err, tc.class)
+			}
+			if len(tokens) != len(tc.tokens) || tokens[len(tokens)-1] != tc.tokens[len(tc.tokens)-1] {
+				t.Errorf("withRetry used the tokens %v, want %v", tokens, tc.tokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// selectsTemplate reports whether the 'CUBE' template is picked by minimum
+// resources instead of template_id/template_name.
+func (s ServerSpec) selectsTemplate() bool {
+	return s.TemplateID == "" && s.TemplateName == "" &&
+		(s.MinCores > 0 || s.MinRam > 0 || s.MinStorage > 0)
+}
+
+// selectTemplate returns the cheapest 'CUBE' template with at least
+// min_cores, min_ram and min_storage. Templates are priced with the cube_hour
+// rates, or estimated from their resources like instanceCost does. Without
+// pricing, the smallest template wins.
+func (i *InstanceGroup) selectTemplate(ctx context.Context) (compute.Template, error) {
+	templates, _, err := withRetry(ctx, i, "TemplatesGet", func(ctx context.Context) (compute.Templates, *shared.APIResponse, error) {
+		return i.api.ListTemplates(ctx)
+	})
+	if err != nil {
+		return compute.Template{}, fmt.Errorf("listing templates: %w", err)
+	}
+
+	spec := i.ServerSpec
+	var best *compute.Template
+	var bestPrice float64
+	for _, template := range *templates.Items {
+		props := template.Properties
+		if props == nil || props.Cores == nil || props.Ram == nil || props.StorageSize == nil {
+			continue
+		}
+		if int32(*props.Cores) < spec.MinCores || int32(*props.Ram) < spec.MinRam || *props.StorageSize < spec.MinStorage {
+			continue
+		}
+		price := i.templatePrice(template)
+		if best == nil || price < bestPrice || (price == bestPrice && smallerTemplate(template, *best)) {
+			best = &template
+			bestPrice = price
+		}
+	}
+	if best == nil {
+		return compute.Template{}, fmt.Errorf("no CUBE template with at least %d cores, %d MB RAM and %v GB storage", spec.MinCores, spec.MinRam, spec.MinStorage)
+	}
+
+	i.log.Info("Selected CUBE template", "template", *best.Properties.Name, "id", *best.Id,
+		"cores", *best.Properties.Cores, "ram", *best.Properties.Ram, "storage_size", *best.Properties.StorageSize,
+		"price_hour", bestPrice)
+	return *best, nil
+}
+
+func (i *InstanceGroup) templatePrice(template compute.Template) float64 {
+	p := i.Pricing
+	for _, key := range []string{*template.Properties.Name, *template.Id} {
+		if price, ok := p.CubeHour[key]; ok {
+			return price
+		}
+	}
+	props := template.Properties
+	return float64(*props.Cores)*p.CoreHour +
+		float64(*props.Ram)/1024*p.RamGBHour +
+		float64(*props.StorageSize)*p.StorageGBHour
+}
+
+func smallerTemplate(a, b compute.Template) bool {
+	pa, pb := a.Properties, b.Properties
+	if *pa.Cores != *pb.Cores {
+		return *pa.Cores < *pb.Cores
+	}
+	if *pa.Ram != *pb.Ram {
+		return *pa.Ram < *pb.Ram
+	}
+	return *pa.StorageSize < *pb.StorageSize
+}
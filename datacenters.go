@@ -0,0 +1,222 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// DatacenterConfig is one of the datacenters instances are spread across.
+type DatacenterConfig struct {
+	ID string `json:"id"`
+	// Weight is the share of new instances created in the datacenter,
+	// defaults to 1.
+	Weight int `json:"weight"`
+	// LanID overrides server_spec.lan_id, as LAN IDs are per datacenter.
+	LanID int32 `json:"lan_id"`
+}
+
+func (i *InstanceGroup) validateDatacenters() error {
+	if len(i.Datacenters) == 0 {
+		if i.DatacenterId == "" {
+			return fmt.Errorf("one of datacenter_id/datacenters is required")
+		}
+		return nil
+	}
+	for n, dc := range i.Datacenters {
+		if dc.ID == "" {
+			return fmt.Errorf("datacenters[%d]: id is required", n)
+		}
+		if dc.Weight < 0 {
+			return fmt.Errorf("datacenters[%d]: weight must not be negative", n)
+		}
+	}
+	return nil
+}
+
+// verifyDatacenters fetches the datacenters during Init, so a wrong ID fails
+// with a clear message, and logs them for operators. Datacenters in different
+// locations are rejected, see commonLocation. With checkWrite the token's
+// permission to modify them is checked as well.
+func (i *InstanceGroup) verifyDatacenters(ctx context.Context, checkWrite bool) error {
+	var locations []datacenterLocation
+	for _, dc := range i.datacenters() {
+		if dc.ID == "" {
+			continue
+		}
+		datacenter, _, err := withRetry(ctx, i, "DatacentersFindById", func(ctx context.Context) (compute.Datacenter, *shared.APIResponse, error) {
+			return i.api.GetDatacenter(ctx, dc.ID)
+		})
+		if errors.Is(err, ErrClassNotFound) || errors.Is(err, ErrClassAuth) {
+			return fmt.Errorf("datacenter %s does not exist or the ionos token cannot access it: %w", dc.ID, err)
+		}
+		if err != nil {
+			return fmt.Errorf("getting datacenter %s: %w", dc.ID, err)
+		}
+
+		var name, location string
+		var version int32
+		if props := datacenter.Properties; props != nil {
+			name = shared.ToValueDefault(props.Name)
+			location = shared.ToValueDefault(props.Location)
+			version = shared.ToValueDefault(props.Version)
+		}
+		locations = append(locations, datacenterLocation{id: dc.ID, location: location})
+		i.log.Info("Using datacenter", "id", dc.ID, "name", name, "location", location, "version", version)
+
+		if checkWrite && !i.DryRun && i.backend() == nil {
+			if err := i.checkWritePermission(ctx, dc.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The backend's own configuration defines the image.
+	if i.backend() != nil {
+		return nil
+	}
+	location, err := commonLocation(locations)
+	if err != nil {
+		return err
+	}
+	i.imageLocation = location
+	return nil
+}
+
+type datacenterLocation struct {
+	id       string
+	location string
+}
+
+// commonLocation returns the location shared by the datacenters. Images and
+// snapshots exist in a single location, and all datacenters create servers
+// from the same one, so datacenters in different locations are an error.
+func commonLocation(dcs []datacenterLocation) (string, error) {
+	var common datacenterLocation
+	for _, dc := range dcs {
+		switch {
+		case dc.location == "":
+		case common.location == "":
+			common = dc
+		case !strings.EqualFold(dc.location, common.location):
+			return "", fmt.Errorf("datacenter %s is in %s and datacenter %s in %s, but images are per location, so all datacenters must be in the same one", common.id, common.location, dc.id, dc.location)
+		}
+	}
+	return common.location, nil
+}
+
+// datacenterLocation returns the location of the datacenters, fetching them
+// if Init has not done so yet.
+func (i *InstanceGroup) datacenterLocation(ctx context.Context) (string, error) {
+	if i.imageLocation != "" {
+		return i.imageLocation, nil
+	}
+	var locations []datacenterLocation
+	for _, dc := range i.datacenters() {
+		if dc.ID == "" {
+			continue
+		}
+		datacenter, _, err := withRetry(ctx, i, "DatacentersFindById", func(ctx context.Context) (compute.Datacenter, *shared.APIResponse, error) {
+			return i.api.GetDatacenter(ctx, dc.ID)
+		})
+		if err != nil {
+			return "", fmt.Errorf("getting datacenter %s: %w", dc.ID, err)
+		}
+		if datacenter.Properties != nil {
+			locations = append(locations, datacenterLocation{id: dc.ID, location: shared.ToValueDefault(datacenter.Properties.Location)})
+		}
+	}
+	location, err := commonLocation(locations)
+	if err != nil {
+		return "", err
+	}
+	i.imageLocation = location
+	return location, nil
+}
+
+// datacenters returns the configured datacenters, or datacenter_id alone.
+func (i *InstanceGroup) datacenters() []DatacenterConfig {
+	if len(i.Datacenters) == 0 {
+		return []DatacenterConfig{{ID: i.DatacenterId, Weight: 1}}
+	}
+	return i.Datacenters
+}
+
+// lanID returns the private LAN of instances in the datacenter, the LAN
+// created by Init if none is configured.
+func (i *InstanceGroup) lanID(dc DatacenterConfig) int32 {
+	if dc.LanID != 0 {
+		return dc.LanID
+	}
+	if i.ServerSpec.LanID != 0 {
+		return i.ServerSpec.LanID
+	}
+	return i.autoLans[dc.ID]
+}
+
+// lanOf returns the private LAN of instances in the datacenter with the
+// given ID.
+func (i *InstanceGroup) lanOf(datacenterID string) int32 {
+	for _, dc := range i.datacenters() {
+		if dc.ID == datacenterID {
+			return i.lanID(dc)
+		}
+	}
+	return i.ServerSpec.LanID
+}
+
+// nextDatacenter picks the datacenter for a new instance using smooth
+// weighted round-robin, so instances are interleaved across datacenters in
+// proportion to their weights.
+func (i *InstanceGroup) nextDatacenter() DatacenterConfig {
+	dcs := i.datacenters()
+
+	i.dcMu.Lock()
+	defer i.dcMu.Unlock()
+
+	if len(i.dcCurrent) != len(dcs) {
+		i.dcCurrent = make([]int, len(dcs))
+	}
+	best, total := 0, 0
+	for n, dc := range dcs {
+		weight := max(dc.Weight, 1)
+		total += weight
+		i.dcCurrent[n] += weight
+		if i.dcCurrent[n] > i.dcCurrent[best] {
+			best = n
+		}
+	}
+	i.dcCurrent[best] -= total
+	return dcs[best]
+}
+
+// datacenterOf returns the datacenter of an instance. It is known for the
+// instances the plugin created or listed, others are looked up.
+func (i *InstanceGroup) datacenterOf(ctx context.Context, instance string) string {
+	dcs := i.datacenters()
+	if len(dcs) == 1 {
+		return dcs[0].ID
+	}
+	if rec, ok := i.registry.get(instance); ok && rec.DatacenterID != "" {
+		return rec.DatacenterID
+	}
+
+	for _, dc := range dcs {
+		_, apiResponse, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc.ID, instance, 0)
+		})
+		if err == nil {
+			i.registry.setDatacenter(instance, dc.ID)
+			return dc.ID
+		}
+		if !apiResponse.HttpNotFound() {
+			i.log.Warn("Failed to look up instance datacenter", "id", instance, "datacenter", dc.ID, "err", err)
+		}
+	}
+	// Let the caller's request fail with a not found error.
+	return dcs[0].ID
+}
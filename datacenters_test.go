@@ -0,0 +1,50 @@
+package ionos
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestVerifyDatacentersLocation(t *testing.T) {
+	api := &mockCompute{datacenters: []compute.Datacenter{
+		testDatacenter("fra-1", "de/fra"),
+		testDatacenter("fra-2", "de/fra"),
+		testDatacenter("txl-1", "de/txl"),
+	}}
+
+	tests := []struct {
+		name        string
+		datacenters []string
+		autoscaling bool
+		location    string
+		err         string
+	}{
+		{name: "single datacenter", datacenters: []string{"txl-1"}, location: "de/txl"},
+		{name: "same location", datacenters: []string{"fra-1", "fra-2"}, location: "de/fra"},
+		{name: "mixed locations", datacenters: []string{"fra-1", "fra-2", "txl-1"}, err: "datacenter fra-1 is in de/fra and datacenter txl-1 in de/txl"},
+		{name: "mixed locations with a backend", datacenters: []string{"fra-1", "txl-1"}, autoscaling: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestGroup(api)
+			i.DatacenterId = ""
+			for _, id := range tt.datacenters {
+				i.Datacenters = append(i.Datacenters, DatacenterConfig{ID: id})
+			}
+			if tt.autoscaling {
+				i.Autoscaling.GroupID = "group"
+			}
+
+			err := i.verifyDatacenters(context.Background(), false)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("verifyDatacenters() = %v, want %q", err, tt.err)
+			}
+			if err == nil && i.imageLocation != tt.location {
+				t.Errorf("image location %q, want %q", i.imageLocation, tt.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const defaultDeleteWaitTimeout = Duration(5 * time.Minute)
+
+// awaitDelete waits for the delete request of an instance to reach DONE,
+// for wait_for_delete, so Decrease does not report capacity as freed that
+// the datacenter still holds. A request that is not tracked, e.g. because it
+// returned no Location, counts as done.
+func (i *InstanceGroup) awaitDelete(ctx context.Context, id string, done <-chan error) error {
+	if done == nil {
+		return nil
+	}
+	timeout := i.DeleteWaitTimeout
+	if timeout <= 0 {
+		timeout = defaultDeleteWaitTimeout
+	}
+	timer := time.NewTimer(time.Duration(timeout))
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("delete request of instance %v failed: %w", id, err)
+		}
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("delete request of instance %v not done after %s", id, time.Duration(timeout))
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for delete request of instance %v: %w", id, ctx.Err())
+	}
+}
+
+// verifyDeleted polls a deleted instance until the API returns 404, at most
+// delete_wait_timeout, for verify_delete. Servers that linger after their
+// delete request, e.g. because of an API hiccup, are reported as an error
+// and kept in the registry.
+func (i *InstanceGroup) verifyDeleted(ctx context.Context, dc, id string) error {
+	timeout := i.DeleteWaitTimeout
+	if timeout <= 0 {
+		timeout = defaultDeleteWaitTimeout
+	}
+	deadline := time.Now().Add(time.Duration(timeout))
+	cfg := i.Retry.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		_, apiResponse, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, id, 0)
+		})
+		if apiResponse.HttpNotFound() {
+			return nil
+		}
+		if err != nil {
+			i.log.Debug("Failed to check deleted instance", "id", id, "err", err)
+		}
+
+		wait := backoff(cfg, attempt)
+		if remaining := time.Until(deadline); remaining <= 0 {
+			return fmt.Errorf("instance %v still exists %s after its deletion", id, time.Duration(timeout))
+		} else if wait > remaining {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("verifying deletion of instance %v: %w", id, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
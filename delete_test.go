@@ -0,0 +1,82 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestDecreaseWaitsForDelete(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		wait  bool
+		err   error
+		stuck bool
+		ok    bool
+	}{
+		{"done", true, nil, false, true},
+		{"failed", true, errors.New("request FAILED"), false, false},
+		{"not done in time", true, nil, true, false},
+		{"not waiting", false, nil, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{
+				servers:      []compute.Server{testServer("server", "runner-1-aaaa", "AVAILABLE")},
+				labels:       []compute.Label{testLabel("server", labelGroup, "runner")},
+				requestErr:   tc.err,
+				requestStuck: tc.stuck,
+			}
+			i := newTestGroup(api)
+			i.WaitForDelete = tc.wait
+			i.DeleteWaitTimeout = Duration(10 * time.Millisecond)
+			i.registry.touch("server")
+			i.startBackground()
+			defer i.stopBackground()
+
+			succeeded, err := i.Decrease(context.Background(), []string{"server"})
+			if (err == nil) != tc.ok || (len(succeeded) == 1) != tc.ok {
+				t.Errorf("Decrease = %v, %v, want success %t", succeeded, err, tc.ok)
+			}
+			// Instances whose deletion is not confirmed stay known.
+			if _, known := i.registry.get("server"); known == tc.ok {
+				t.Errorf("Decrease left the instance known %t", known)
+			}
+		})
+	}
+}
+
+func TestDecreaseVerifiesDelete(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		verify    bool
+		lingering bool
+		ok        bool
+	}{
+		{"gone", true, false, true},
+		{"lingering", true, true, false},
+		{"not verifying", false, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{
+				servers:   []compute.Server{testServer("server", "runner-1-aaaa", "AVAILABLE")},
+				labels:    []compute.Label{testLabel("server", labelGroup, "runner")},
+				lingering: tc.lingering,
+			}
+			i := newTestGroup(api)
+			i.VerifyDelete = tc.verify
+			i.DeleteWaitTimeout = Duration(10 * time.Millisecond)
+			i.registry.touch("server")
+
+			succeeded, err := i.Decrease(context.Background(), []string{"server"})
+			if (err == nil) != tc.ok || (len(succeeded) == 1) != tc.ok {
+				t.Errorf("Decrease = %v, %v, want success %t", succeeded, err, tc.ok)
+			}
+			if _, known := i.registry.get("server"); known == tc.ok {
+				t.Errorf("Decrease left the instance known %t", known)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package ionos
+
+import "fmt"
+
+const (
+	// propertiesDepth returns servers with their properties and metadata,
+	// which is all Update, Increase, Decrease and ConnectInfo need.
+	propertiesDepth = 1
+	// nicDepth also returns the NICs and volumes of a server.
+	nicDepth = 2
+	// maxDepth is the largest depth the API accepts.
+	maxDepth = 10
+)
+
+// DepthConfig overrides how deep the API resolves servers. At depth 1 a
+// server comes with its properties, at depth 2 also with its NICs and
+// volumes, at depth 3 also with their firewall rules. Listing the servers
+// of a datacenter at depth 2 returns all of that for every server, so lists
+// default to depth 1 and only calls that look at NICs or volumes go deeper.
+type DepthConfig struct {
+	// List applies to listing the servers of the datacenters, e.g. in Update.
+	List int32 `json:"list"`
+	// Server applies to fetching a single server in Heartbeat, which checks
+	// its NICs and volumes.
+	Server int32 `json:"server"`
+}
+
+func (c DepthConfig) validate() error {
+	if c.List < 0 || c.List > maxDepth {
+		return fmt.Errorf("depth.list must be between 1 and %d, got %d", maxDepth, c.List)
+	}
+	if c.Server != 0 && (c.Server < nicDepth || c.Server > maxDepth) {
+		return fmt.Errorf("depth.server must be between %d and %d, got %d", nicDepth, maxDepth, c.Server)
+	}
+	return nil
+}
+
+// list returns the depth for listing servers.
+func (c DepthConfig) list() int32 {
+	if c.List <= 0 {
+		return propertiesDepth
+	}
+	return c.List
+}
+
+// listWithNICs returns the depth for listing servers with their NICs.
+func (c DepthConfig) listWithNICs() int32 {
+	return max(c.list(), nicDepth)
+}
+
+// server returns the depth for fetching a single server.
+func (c DepthConfig) server() int32 {
+	if c.Server <= 0 {
+		return nicDepth
+	}
+	return c.Server
+}
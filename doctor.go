@@ -0,0 +1,96 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// CheckResult is the outcome of a single Diagnose check, Err is nil if it
+// passed.
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// Diagnose verifies the configuration against the IONOS API: credentials,
+// datacenters, LANs, image and template, quota headroom for one instance and
+// the user data. All checks run even if earlier ones fail.
+func (i *InstanceGroup) Diagnose(ctx context.Context) []CheckResult {
+	var results []CheckResult
+	check := func(name string, fn func() error) {
+		results = append(results, CheckResult{Name: name, Err: fn()})
+	}
+
+	check("configuration", i.validateConfig)
+
+	check("credentials", func() error {
+		_, err := i.resourceLimits(ctx)
+		return err
+	})
+
+	for _, dc := range i.datacenters() {
+		check(fmt.Sprintf("datacenter %s", dc.ID), func() error {
+			_, _, err := withRetry(ctx, i, "DatacentersFindById", func(ctx context.Context) (compute.Datacenter, *shared.APIResponse, error) {
+				return i.api.GetDatacenter(ctx, dc.ID)
+			})
+			return err
+		})
+
+		lans := []int32{i.lanID(dc)}
+		if i.ServerSpec.PublicLanID != 0 {
+			lans = append(lans, i.ServerSpec.PublicLanID)
+		}
+		for _, lanID := range lans {
+			check(fmt.Sprintf("LAN %d in datacenter %s", lanID, dc.ID), func() error {
+				_, _, err := withRetry(ctx, i, "LansFindById", func(ctx context.Context) (compute.Lan, *shared.APIResponse, error) {
+					return i.api.GetLan(ctx, dc.ID, fmt.Sprint(lanID))
+				})
+				return err
+			})
+		}
+	}
+
+	if i.ServerSpec.Image != "" {
+		check(fmt.Sprintf("image %s", i.ServerSpec.Image), func() error {
+			_, _, err := withRetry(ctx, i, "ImagesFindById", func(ctx context.Context) (compute.Image, *shared.APIResponse, error) {
+				return i.api.GetImage(ctx, i.ServerSpec.Image)
+			})
+			return err
+		})
+	}
+
+	if i.ServerSpec.Type == "CUBE" {
+		check("template", func() error {
+			spec := i.spec()
+			if err := i.resolveTemplate(ctx, &spec); err != nil {
+				return err
+			}
+			if spec.TemplateID == "" {
+				return errors.New("no template configured")
+			}
+			_, _, err := withRetry(ctx, i, "TemplatesFindById", func(ctx context.Context) (compute.Template, *shared.APIResponse, error) {
+				return i.api.GetTemplate(ctx, spec.TemplateID)
+			})
+			return err
+		})
+	}
+
+	check("quota headroom", func() error {
+		current, err := i.countInstances(ctx)
+		if err != nil {
+			return err
+		}
+		return i.checkQuota(ctx, current, 1)
+	})
+
+	if i.hasUserData() {
+		check("user data", func() error {
+			return i.validateUserData(ctx)
+		})
+	}
+	return results
+}
@@ -0,0 +1,25 @@
+package ionos
+
+import (
+	"encoding/json"
+)
+
+// dryRun logs the mutation that would be made and reports whether dry_run
+// is enabled, in which case the caller skips the API call.
+func (i *InstanceGroup) dryRun(msg string, args ...any) bool {
+	if !i.DryRun {
+		return false
+	}
+	i.log.Info("Dry run: "+msg, args...)
+	return true
+}
+
+// payload renders a request body for dry run logs, with user data and
+// passwords masked.
+func payload(body any) string {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err.Error()
+	}
+	return redactJSON(data)
+}
@@ -0,0 +1,111 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// ErrorClass categorizes failed IONOS API calls, so callers can tell
+// errors that need an operator ("fix your token") from errors that go away
+// on their own ("retry later"). Use errors.Is(err, ErrClassAuth) etc.
+type ErrorClass string
+
+const (
+	ErrClassAuth        ErrorClass = "auth"
+	ErrClassCapacity    ErrorClass = "capacity"
+	ErrClassNotFound    ErrorClass = "not_found"
+	ErrClassRateLimited ErrorClass = "rate_limited"
+	ErrClassTransient   ErrorClass = "transient"
+	ErrClassInvalid     ErrorClass = "invalid"
+)
+
+func (c ErrorClass) Error() string {
+	return string(c)
+}
+
+// APIError wraps the error of a failed IONOS API call with its class.
+type APIError struct {
+	Op         string
+	Class      ErrorClass
+	StatusCode int
+	RequestID  string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("ionos %s: %s: %v", e.Op, e.Class, e.Err)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return secrets.redact(msg)
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// ClassifyError returns the class of an error returned by the plugin, or
+// an empty class if it is not related to the IONOS API.
+func ClassifyError(err error) ErrorClass {
+	var class ErrorClass
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &class):
+		return class
+	case errors.Is(err, ErrQuotaExceeded):
+		return ErrClassCapacity
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, context.DeadlineExceeded):
+		return ErrClassTransient
+	}
+	return ""
+}
+
+// classifyAPIError wraps err of the API call op in an APIError.
+func classifyAPIError(op string, apiResponse *shared.APIResponse, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	e := &APIError{Op: op, RequestID: requestID(apiResponse), Err: err}
+	if apiResponse == nil || apiResponse.Response == nil {
+		e.Class = ErrClassTransient
+		return e
+	}
+	e.StatusCode = apiResponse.StatusCode
+	switch {
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		e.Class = ErrClassAuth
+	case e.StatusCode == http.StatusNotFound:
+		e.Class = ErrClassNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		e.Class = ErrClassRateLimited
+	case isCapacityError(err) || isQuotaError(err):
+		e.Class = ErrClassCapacity
+	case e.StatusCode >= 500:
+		e.Class = ErrClassTransient
+	default:
+		e.Class = ErrClassInvalid
+	}
+	return e
+}
+
+// isQuotaError reports whether the API rejected a request because it
+// exceeds the resource limits of the contract.
+func isQuotaError(err error) bool {
+	var apiErr shared.GenericOpenAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusUnprocessableEntity {
+		return false
+	}
+	body := strings.ToLower(string(apiErr.Body()))
+	return strings.Contains(body, "quota") || strings.Contains(body, "limit exceeded") || strings.Contains(body, "resource limit")
+}
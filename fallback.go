@@ -0,0 +1,89 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const defaultFallbackVolumeType = "SSD Standard"
+
+// EnterpriseFallback is the 'ENTERPRISE' spec used when a 'CUBE' server
+// cannot be created for lack of capacity. Unset cores, ram and storage_size
+// are taken from the CUBE template.
+type EnterpriseFallback struct {
+	Cores       int32   `json:"cores"`
+	Ram         int32   `json:"ram"`
+	StorageSize float32 `json:"storage_size"`
+	CpuFamily   string  `json:"cpu_family"`
+	VolumeType  string  `json:"volume_type"`
+}
+
+// isCapacityError reports whether the API rejected a server because the
+// datacenter has no capacity left for it.
+func isCapacityError(err error) bool {
+	var apiErr shared.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode() {
+	case http.StatusUnprocessableEntity, http.StatusServiceUnavailable:
+	default:
+		return false
+	}
+	body := strings.ToLower(string(apiErr.Body()))
+	for _, hint := range []string{"capacity", "not enough", "exhausted", "insufficient"} {
+		if strings.Contains(body, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// toEnterprise turns the create request of a 'CUBE' server into the
+// equivalent 'ENTERPRISE' server configured by enterprise_fallback.
+func (i *InstanceGroup) toEnterprise(ctx context.Context, serverData *compute.Server) error {
+	fallback := *i.ServerSpec.EnterpriseFallback
+
+	if fallback.Cores == 0 || fallback.Ram == 0 || fallback.StorageSize == 0 {
+		templateID := *serverData.Properties.TemplateUuid
+		template, _, err := withRetry(ctx, i, "TemplatesFindById", func(ctx context.Context) (compute.Template, *shared.APIResponse, error) {
+			return i.api.GetTemplate(ctx, templateID)
+		})
+		if err != nil {
+			return fmt.Errorf("getting template %v: %w", templateID, err)
+		}
+		if fallback.Cores == 0 {
+			fallback.Cores = int32(*template.Properties.Cores)
+		}
+		if fallback.Ram == 0 {
+			fallback.Ram = int32(*template.Properties.Ram)
+		}
+		if fallback.StorageSize == 0 {
+			fallback.StorageSize = *template.Properties.StorageSize
+		}
+	}
+	if fallback.VolumeType == "" {
+		fallback.VolumeType = defaultFallbackVolumeType
+	}
+	fallback.VolumeType = i.ServerSpec.volumeType(fallback.VolumeType)
+
+	props := serverData.Properties
+	props.Type = StrPtr("ENTERPRISE")
+	props.TemplateUuid = nil
+	props.Cores = &fallback.Cores
+	props.Ram = &fallback.Ram
+	if fallback.CpuFamily != "" {
+		props.CpuFamily = &fallback.CpuFamily
+	}
+
+	volume := &(*serverData.Entities.Volumes.Items)[0]
+	volume.Properties.Type = &fallback.VolumeType
+	volume.Properties.Size = &fallback.StorageSize
+	return nil
+}
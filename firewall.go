@@ -0,0 +1,93 @@
+package ionos
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// FirewallRule is an ingress rule applied to the NICs of instances.
+type FirewallRule struct {
+	Name      string `json:"name,omitempty"`
+	Protocol  string `json:"protocol"`
+	PortStart int32  `json:"port_start,omitempty"`
+	PortEnd   int32  `json:"port_end,omitempty"`
+	SourceIP  string `json:"source_ip,omitempty"`
+}
+
+func validateFirewallRules(rules []FirewallRule) error {
+	for n, rule := range rules {
+		protocol := strings.ToUpper(rule.Protocol)
+		switch protocol {
+		case "TCP", "UDP":
+		case "ICMP", "ANY":
+			if rule.PortStart != 0 || rule.PortEnd != 0 {
+				return fmt.Errorf("firewall_rules[%d]: ports are only allowed for TCP and UDP", n)
+			}
+		default:
+			return fmt.Errorf("firewall_rules[%d]: protocol can be 'TCP', 'UDP', 'ICMP' or 'ANY'", n)
+		}
+		if rule.PortStart < 0 || rule.PortEnd > 65535 || (rule.PortEnd != 0 && rule.PortEnd < rule.PortStart) {
+			return fmt.Errorf("firewall_rules[%d]: invalid port range %d-%d", n, rule.PortStart, rule.PortEnd)
+		}
+		if rule.SourceIP != "" && net.ParseIP(rule.SourceIP) == nil {
+			if _, _, err := net.ParseCIDR(rule.SourceIP); err != nil {
+				return fmt.Errorf("firewall_rules[%d]: source_ip must be an IP or CIDR: %w", n, err)
+			}
+		}
+	}
+	return nil
+}
+
+// nicFirewallRules returns the rules for the NICs of instances: the configured
+// firewall_rules plus, for each of manager_cidrs, a rule that allows the
+// connector port, SSH or WinRM, from there. Enabling the firewall drops all
+// other ingress traffic, so instances are closed off from the rest of the
+// LAN.
+func (i *InstanceGroup) nicFirewallRules() []FirewallRule {
+	rules := slices.Clone(i.ServerSpec.FirewallRules)
+	if len(i.ServerSpec.ManagerCIDRs) == 0 {
+		return rules
+	}
+	protocol, port := connectorPort(i.connectorConfig())
+	for _, cidr := range i.ServerSpec.ManagerCIDRs {
+		rules = append(rules, FirewallRule{
+			Name:      "fleeting-" + string(protocol),
+			Protocol:  "TCP",
+			PortStart: int32(port),
+			SourceIP:  cidr,
+		})
+	}
+	return rules
+}
+
+// firewallRules converts the configured rules for the NIC entities of a
+// server create request.
+func firewallRules(rules []FirewallRule) *compute.FirewallRules {
+	items := make([]compute.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		properties := compute.FirewallruleProperties{
+			Protocol: StrPtr(strings.ToUpper(rule.Protocol)),
+			Type:     StrPtr("INGRESS"),
+		}
+		if rule.Name != "" {
+			properties.Name = StrPtr(rule.Name)
+		}
+		if rule.SourceIP != "" {
+			properties.SourceIp = StrPtr(rule.SourceIP)
+		}
+		if rule.PortStart != 0 {
+			start, end := rule.PortStart, rule.PortEnd
+			if end == 0 {
+				end = start
+			}
+			properties.PortRangeStart = &start
+			properties.PortRangeEnd = &end
+		}
+		items = append(items, compute.FirewallRule{Properties: &properties})
+	}
+	return &compute.FirewallRules{Items: &items}
+}
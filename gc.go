@@ -0,0 +1,133 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// failedCreateLookback is how far back CollectFailedCreations looks for
+// failed create requests when run with the volume sweep.
+const failedCreateLookback = 24 * time.Hour
+
+// collectFailedCreate deletes what a create request that ended FAILED left
+// behind: the server, if it was created, with its NICs and volumes, and the
+// volume named after it if it was created but never attached.
+func (i *InstanceGroup) collectFailedCreate(ctx context.Context, datacenterID, id, serverName string) error {
+	var err error
+	if id != "" {
+		_, apiResponse, err2 := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, datacenterID, id, 0)
+		})
+		switch {
+		case apiResponse.HttpNotFound():
+			i.registry.remove(id)
+		case err2 != nil:
+			err = fmt.Errorf("getting server of failed creation %s: %w", id, err2)
+		default:
+			if err2 := i.deleteInstance(ctx, id, nil); err2 != nil {
+				err = fmt.Errorf("deleting server of failed creation %s: %w", id, err2)
+			} else {
+				i.log.Info("Deleted server of failed creation", "id", id, "name", serverName)
+			}
+		}
+	}
+	if serverName == "" {
+		return err
+	}
+
+	volumes, _, err2 := withRetry(ctx, i, "VolumesGet", func(ctx context.Context) (compute.Volumes, *shared.APIResponse, error) {
+		return i.api.ListVolumes(ctx, datacenterID)
+	})
+	if err2 != nil {
+		return errors.Join(err, fmt.Errorf("listing volumes: %w", err2))
+	}
+	if volumes.Items == nil {
+		return err
+	}
+	for _, volume := range *volumes.Items {
+		if volume.Properties == nil || volume.Properties.Name == nil || *volume.Properties.Name != serverName ||
+			volume.Properties.BootServer != nil || *volume.Metadata.State != "AVAILABLE" {
+			continue
+		}
+		volumeID := *volume.Id
+		if i.dryRun("would delete volume of failed creation", "id", volumeID, "name", serverName) {
+			continue
+		}
+		apiResponse, err2 := withRetryNoResult(ctx, i, "VolumesDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteVolume(ctx, datacenterID, volumeID)
+		})
+		i.audit(ctx, auditEvent{Action: "delete", ResourceType: "volume", ResourceID: volumeID, Name: serverName, Datacenter: datacenterID}, apiResponse, err2)
+		if err2 != nil {
+			err = errors.Join(err, fmt.Errorf("deleting volume of failed creation %s: %w", volumeID, err2))
+			continue
+		}
+		i.log.Info("Deleted volume of failed creation", "id", volumeID, "name", serverName)
+	}
+	return err
+}
+
+// collectOnFailure collects the resources of a create request in the
+// background once done reports that it failed.
+func (i *InstanceGroup) collectOnFailure(done <-chan error, datacenterID, id, serverName string) {
+	if done == nil {
+		return
+	}
+	i.runBackground("failed-create-gc", func(ctx context.Context) error {
+		select {
+		case err := <-done:
+			if err == nil || ctx.Err() != nil {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+		i.log.Warn("Server creation failed, deleting its resources", "id", id, "name", serverName)
+		err := i.collectFailedCreate(ctx, datacenterID, id, serverName)
+		if err != nil && ctx.Err() != nil {
+			i.log.Warn("Shutting down before deleting the resources of a failed creation", "id", id, "name", serverName)
+			return nil
+		}
+		return err
+	})
+}
+
+// CollectFailedCreations deletes the servers of the group's create requests
+// that ended FAILED after since, including those of earlier plugin runs. It
+// returns the IDs of the deleted servers. Volumes of failed creations that
+// were never attached are left to SweepVolumes.
+func (i *InstanceGroup) CollectFailedCreations(ctx context.Context, since time.Time) ([]string, error) {
+	ctx = withOperation(ctx, "CollectFailedCreations")
+	requests, err := i.ListRequests(ctx, since, requestFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, req := range requests {
+		if req.Action != "create" || req.ServerID == "" {
+			continue
+		}
+		dc := i.datacenterOf(ctx, req.ServerID)
+		_, apiResponse, err2 := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, req.ServerID, 0)
+		})
+		if apiResponse.HttpNotFound() {
+			continue
+		}
+		if err2 == nil {
+			err2 = i.deleteInstance(ctx, req.ServerID, nil)
+		}
+		if err2 != nil {
+			err = errors.Join(err, fmt.Errorf("deleting server of failed creation %s: %w", req.ServerID, err2))
+			continue
+		}
+		i.log.Info("Deleted server of failed creation", "id", req.ServerID, "request_id", req.RequestID)
+		deleted = append(deleted, req.ServerID)
+	}
+	return deleted, err
+}
@@ -0,0 +1,47 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestCollectOnFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		requestErr error
+		deleted    []string
+	}{
+		{"done", nil, nil},
+		// The server and the volume named after it that never got attached
+		// are deleted, other volumes are left alone.
+		{"failed", errors.New("request FAILED"), []string{"server-1", "orphan"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "runner-1-aaaa"
+			api := &mockCompute{
+				requestErr: tc.requestErr,
+				volumes: []compute.Volume{
+					testVolume("orphan", name, "AVAILABLE", false),
+					testVolume("attached", name, "AVAILABLE", true),
+					testVolume("other", "runner-2-bbbb", "AVAILABLE", false),
+				},
+			}
+			i := newTestGroup(api)
+			i.startBackground()
+			defer i.stopBackground()
+
+			serverData := compute.Server{Properties: &compute.ServerProperties{Name: &name}}
+			if _, err := i.postServer(context.Background(), DatacenterConfig{ID: "dc1"}, name, serverData); err != nil {
+				t.Fatal(err)
+			}
+			i.bgWG.Wait()
+			if !slices.Equal(api.deleted, tc.deleted) {
+				t.Errorf("deleted %v after the create request, want %v", api.deleted, tc.deleted)
+			}
+		})
+	}
+}
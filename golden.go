@@ -0,0 +1,227 @@
+package ionos
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	// imageActive as server_spec.image refers to the active golden image of
+	// the group.
+	imageActive = "active"
+
+	defaultKeepImages = 3
+
+	// labelGolden marks the snapshots baked for a group, labelPromoted holds
+	// the time they were last promoted. The active golden image is the most
+	// recently promoted one.
+	labelGolden   = "fleeting-golden"
+	labelPromoted = "fleeting-promoted"
+)
+
+// goldenImage is a snapshot baked for the group.
+type goldenImage struct {
+	privateImage
+	promoted int64
+}
+
+// goldenImages returns the snapshots baked for the group, most recently
+// promoted first and newest first among those never promoted.
+func (i *InstanceGroup) goldenImages(ctx context.Context) ([]goldenImage, error) {
+	golden, err := i.resourceLabel(ctx, "snapshot", labelGolden)
+	if err != nil {
+		return nil, fmt.Errorf("listing golden image labels: %w", err)
+	}
+	promoted, err := i.resourceLabel(ctx, "snapshot", labelPromoted)
+	if err != nil {
+		return nil, fmt.Errorf("listing golden image labels: %w", err)
+	}
+	snapshots, _, err := withRetry(ctx, i, "SnapshotsGet", func(ctx context.Context) (compute.Snapshots, *shared.APIResponse, error) {
+		return i.api.ListSnapshots(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+
+	var images []goldenImage
+	if snapshots.Items != nil {
+		for _, snapshot := range *snapshots.Items {
+			if golden[*snapshot.Id] != i.groupLabel() || snapshot.Properties == nil || snapshot.Properties.Name == nil {
+				continue
+			}
+			image := goldenImage{privateImage: newPrivateImage(*snapshot.Id, snapshot.Properties.Name, snapshot.Properties.Location, snapshot.Metadata)}
+			image.promoted, _ = strconv.ParseInt(promoted[*snapshot.Id], 10, 64)
+			images = append(images, image)
+		}
+	}
+	slices.SortFunc(images, func(a, b goldenImage) int {
+		if c := cmp.Compare(b.promoted, a.promoted); c != 0 {
+			return c
+		}
+		return b.created.Compare(a.created)
+	})
+	return images, nil
+}
+
+// activeImage returns the active golden image from goldenImages.
+func activeImage(images []goldenImage) (goldenImage, bool) {
+	if len(images) == 0 || images[0].promoted == 0 {
+		return goldenImage{}, false
+	}
+	return images[0], true
+}
+
+// resolveActiveImage returns the active golden image. A change from the
+// current image is logged.
+func (i *InstanceGroup) resolveActiveImage(ctx context.Context, current string) (string, error) {
+	images, err := i.goldenImages(ctx)
+	if err != nil {
+		return "", err
+	}
+	active, ok := activeImage(images)
+	if !ok {
+		return "", fmt.Errorf("no golden image has been promoted for group %s", i.groupLabel())
+	}
+	if active.id != current {
+		i.log.Info("Selected active golden image", "image", active.name, "id", active.id)
+	}
+	return active.id, nil
+}
+
+// labelGoldenImage marks a baked snapshot as golden image of the group.
+func (i *InstanceGroup) labelGoldenImage(ctx context.Context, id string) error {
+	_, _, err := withRetry(ctx, i, "SnapshotsLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+		return i.api.AddSnapshotLabel(ctx, id, labelGolden, i.groupLabel())
+	})
+	return err
+}
+
+// PromoteImage makes the golden image with the given ID or name the active
+// one and deletes all but the keep_images newest golden images. Instances
+// created afterwards use it where server_spec.image is "active".
+// PromoteImage is called instead of Init.
+func (i *InstanceGroup) PromoteImage(ctx context.Context, logger hclog.Logger, ref string) (string, error) {
+	ctx = withOperation(ctx, "PromoteImage")
+	i.log = newRedactingLogger(logger)
+	if err := i.initAPI(ctx); err != nil {
+		return "", err
+	}
+	return i.promoteImage(ctx, ref)
+}
+
+func (i *InstanceGroup) promoteImage(ctx context.Context, ref string) (string, error) {
+	images, err := i.goldenImages(ctx)
+	if err != nil {
+		return "", err
+	}
+	n := slices.IndexFunc(images, func(image goldenImage) bool {
+		return image.id == ref || image.name == ref
+	})
+	if n < 0 {
+		return "", fmt.Errorf("no golden image %s for group %s", ref, i.groupLabel())
+	}
+	image := images[n]
+
+	if i.dryRun("would promote golden image", "image", image.name, "id", image.id) {
+		return image.id, nil
+	}
+	if image.promoted != 0 {
+		if err := i.deleteSnapshotLabel(ctx, image.id, labelPromoted); err != nil {
+			return "", err
+		}
+	}
+	_, _, err = withRetry(ctx, i, "SnapshotsLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+		return i.api.AddSnapshotLabel(ctx, image.id, labelPromoted, strconv.FormatInt(time.Now().UnixNano(), 10))
+	})
+	if err != nil {
+		return "", fmt.Errorf("promoting golden image %s: %w", image.id, err)
+	}
+	i.log.Info("Promoted golden image", "image", image.name, "id", image.id)
+
+	return image.id, i.pruneGoldenImages(ctx, image.id)
+}
+
+// pruneGoldenImages deletes all but the keep_images newest golden images,
+// never the active one.
+func (i *InstanceGroup) pruneGoldenImages(ctx context.Context, activeID string) error {
+	images, err := i.goldenImages(ctx)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(images, func(a, b goldenImage) int { return b.created.Compare(a.created) })
+
+	keep := i.KeepImages
+	if keep <= 0 {
+		keep = defaultKeepImages
+	}
+	var errs error
+	for n, image := range images {
+		if n < keep || image.id == activeID {
+			continue
+		}
+		if i.dryRun("would delete golden image", "image", image.name, "id", image.id) {
+			continue
+		}
+		apiResponse, err := withRetryNoResult(ctx, i, "SnapshotsDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteSnapshot(ctx, image.id)
+		})
+		i.audit(ctx, auditEvent{Action: "delete", ResourceType: "snapshot", ResourceID: image.id, Name: image.name}, apiResponse, err)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("deleting golden image %s: %w", image.id, err))
+			continue
+		}
+		i.log.Info("Deleted old golden image", "image", image.name, "id", image.id)
+	}
+	return errs
+}
+
+// RollbackImage makes the previously promoted golden image the active one
+// again and returns its ID. RollbackImage is called instead of Init.
+func (i *InstanceGroup) RollbackImage(ctx context.Context, logger hclog.Logger) (string, error) {
+	ctx = withOperation(ctx, "RollbackImage")
+	i.log = newRedactingLogger(logger)
+	if err := i.initAPI(ctx); err != nil {
+		return "", err
+	}
+
+	images, err := i.goldenImages(ctx)
+	if err != nil {
+		return "", err
+	}
+	active, ok := activeImage(images)
+	if !ok {
+		return "", fmt.Errorf("no golden image has been promoted for group %s", i.groupLabel())
+	}
+	previous, ok := activeImage(images[1:])
+	if !ok {
+		return "", fmt.Errorf("golden image %s was the first promoted, there is nothing to roll back to", active.name)
+	}
+
+	if i.dryRun("would roll back golden image", "from", active.name, "to", previous.name) {
+		return previous.id, nil
+	}
+	if err := i.deleteSnapshotLabel(ctx, active.id, labelPromoted); err != nil {
+		return "", err
+	}
+	i.log.Info("Rolled back golden image", "from", active.name, "to", previous.name, "id", previous.id)
+	return previous.id, nil
+}
+
+func (i *InstanceGroup) deleteSnapshotLabel(ctx context.Context, id, key string) error {
+	_, err := withRetryNoResult(ctx, i, "SnapshotsLabelsDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+		return i.api.DeleteSnapshotLabel(ctx, id, key)
+	})
+	if err != nil {
+		return fmt.Errorf("removing label %s of golden image %s: %w", key, id, err)
+	}
+	return nil
+}
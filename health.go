@@ -0,0 +1,92 @@
+package ionos
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// healthState tracks the outcome of recent API calls and Updates for the
+// health endpoint.
+type healthState struct {
+	mu                sync.Mutex
+	lastUpdate        time.Time
+	lastAPICall       time.Time
+	apiReachable      bool
+	credentialsFailed bool
+	lastError         string
+}
+
+// healthReport is the JSON body of /healthz.
+type healthReport struct {
+	Status            string     `json:"status"`
+	APIReachable      bool       `json:"api_reachable"`
+	CredentialsValid  bool       `json:"credentials_valid"`
+	CircuitOpen       bool       `json:"circuit_open"`
+	LastAPICall       *time.Time `json:"last_api_call,omitempty"`
+	LastUpdate        *time.Time `json:"last_update,omitempty"`
+	LastUpdateSeconds *float64   `json:"last_update_age_seconds,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+}
+
+// recordAPICall notes whether the API answered and accepted the token. A
+// 403 is about a single resource and says nothing about the token.
+func (h *healthState) recordAPICall(apiResponse *shared.APIResponse, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastAPICall = time.Now()
+	if apiResponse == nil || apiResponse.Response == nil {
+		h.apiReachable = false
+	} else {
+		h.apiReachable = apiResponse.StatusCode < http.StatusInternalServerError
+		h.credentialsFailed = apiResponse.StatusCode == http.StatusUnauthorized
+	}
+	h.lastError = ""
+	if err != nil {
+		h.lastError = err.Error()
+	}
+}
+
+func (h *healthState) recordUpdate() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastUpdate = time.Now()
+}
+
+// healthHandler serves the health of the plugin as JSON, with status 503
+// while the API is unreachable or rejects the token.
+func (i *InstanceGroup) healthHandler(w http.ResponseWriter, r *http.Request) {
+	h := &i.health
+	h.mu.Lock()
+	report := healthReport{
+		APIReachable:     h.apiReachable,
+		CredentialsValid: !h.credentialsFailed,
+		LastError:        h.lastError,
+	}
+	if !h.lastAPICall.IsZero() {
+		lastAPICall := h.lastAPICall
+		report.LastAPICall = &lastAPICall
+	}
+	if !h.lastUpdate.IsZero() {
+		lastUpdate := h.lastUpdate
+		age := time.Since(lastUpdate).Seconds()
+		report.LastUpdate = &lastUpdate
+		report.LastUpdateSeconds = &age
+	}
+	h.mu.Unlock()
+	report.CircuitOpen = i.allowAPICall() != nil
+
+	status := http.StatusOK
+	report.Status = "ok"
+	if !report.APIReachable || !report.CredentialsValid || report.CircuitOpen {
+		status = http.StatusServiceUnavailable
+		report.Status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
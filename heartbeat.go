@@ -0,0 +1,102 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+const defaultBootGracePeriod = Duration(10 * time.Minute)
+
+// checkServerHealth verifies that a server fetched with depth 2 is usable:
+// it is AVAILABLE (or BUSY while still booting), running, has an IP on its
+// NIC in the private LAN of the datacenter and an attached volume.
+func (i *InstanceGroup) checkServerHealth(server compute.Server, datacenterID string) error {
+	state := *server.Metadata.State
+	switch state {
+	case "AVAILABLE":
+	case "BUSY":
+		grace := i.BootGracePeriod
+		if grace <= 0 {
+			grace = defaultBootGracePeriod
+		}
+		created := server.Metadata.CreatedDate
+		if created == nil || time.Since(created.Time) > time.Duration(grace) {
+			return fmt.Errorf("%w: server is still BUSY after the boot grace period of %s", provider.ErrInstanceUnhealthy, time.Duration(grace))
+		}
+		// Volumes and NICs may not be ready while the server is being created.
+		return nil
+	default:
+		return fmt.Errorf("%w: server is in state %s", provider.ErrInstanceUnhealthy, state)
+	}
+
+	if vmState := server.Properties.VmState; vmState != nil && *vmState != "RUNNING" {
+		return fmt.Errorf("%w: server VM is %s", provider.ErrInstanceUnhealthy, *vmState)
+	}
+
+	if server.Entities == nil || server.Entities.Volumes == nil || server.Entities.Volumes.Items == nil || len(*server.Entities.Volumes.Items) == 0 {
+		return fmt.Errorf("%w: server has no attached volume", provider.ErrInstanceUnhealthy)
+	}
+
+	if server.Entities.Nics == nil || server.Entities.Nics.Items == nil || len(*server.Entities.Nics.Items) == 0 {
+		return fmt.Errorf("%w: server has no NIC", provider.ErrInstanceUnhealthy)
+	}
+	nic, ok := i.findPrivateNIC(*server.Entities.Nics.Items, datacenterID)
+	if !ok {
+		return fmt.Errorf("%w: server has no NIC in the private LAN", provider.ErrInstanceUnhealthy)
+	}
+	if nic.Properties == nil || nic.Properties.Ips == nil || len(*nic.Properties.Ips) == 0 {
+		return fmt.Errorf("%w: server NIC has no IP", provider.ErrInstanceUnhealthy)
+	}
+	return nil
+}
+
+// recordHeartbeat counts the consecutive heartbeats that found an instance
+// unhealthy and, after replace_after of them, deletes it in the background,
+// so a frozen VM does not hold a slot forever and the runner creates a
+// replacement. With a backend, the instance is removed through it like in
+// Decrease, so the autoscaling group or node pool does not recreate it behind
+// the runner's back. Errors talking to the API do not count.
+func (i *InstanceGroup) recordHeartbeat(instance string, err error) {
+	if i.ReplaceAfter <= 0 {
+		return
+	}
+	if err == nil {
+		i.registry.heartbeatPassed(instance)
+		return
+	}
+	if !errors.Is(err, provider.ErrInstanceUnhealthy) {
+		return
+	}
+	failures := i.registry.heartbeatFailed(instance)
+	if failures < i.ReplaceAfter || !i.registry.setReplacing(instance, true) {
+		return
+	}
+
+	i.log.Warn("Replacing instance failing heartbeats", "id", instance, "failures", failures, "err", err)
+	if i.metrics != nil {
+		i.metrics.instancesReplaced.Inc()
+	}
+	i.runBackground("replace-unhealthy", func(ctx context.Context) error {
+		if err := i.replaceInstance(ctx, instance); err != nil {
+			i.registry.setReplacing(instance, false)
+			return fmt.Errorf("replacing instance %v: %w", instance, err)
+		}
+		i.serverCache.invalidate()
+		return nil
+	})
+}
+
+// replaceInstance deletes an instance that kept failing heartbeats, through
+// the backend if there is one.
+func (i *InstanceGroup) replaceInstance(ctx context.Context, instance string) error {
+	if b := i.backend(); b != nil {
+		_, err := i.backendDecrease(ctx, b, []string{instance})
+		return err
+	}
+	return i.deleteInstance(ctx, instance, nil)
+}
@@ -0,0 +1,47 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestHeartbeatReplacesUnhealthyInstances(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		replaceAfter int
+		// states are the server states seen by consecutive heartbeats.
+		states  []string
+		deleted []string
+	}{
+		{"replaced", 3, []string{"INACTIVE", "INACTIVE", "INACTIVE"}, []string{"server"}},
+		{"too few failures", 3, []string{"INACTIVE", "INACTIVE"}, nil},
+		{"reset by a healthy heartbeat", 3, []string{"INACTIVE", "INACTIVE", "AVAILABLE", "INACTIVE"}, nil},
+		// Once deleted, the instance is not found and not replaced again.
+		{"replaced once", 2, []string{"INACTIVE", "INACTIVE", "INACTIVE", "INACTIVE"}, []string{"server"}},
+		{"disabled", 0, []string{"INACTIVE", "INACTIVE", "INACTIVE", "INACTIVE"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := withNICs(testServer("server", "runner-1-aaaa", "AVAILABLE"), map[int32]string{1: "10.0.0.2"})
+			api := &mockCompute{
+				servers: []compute.Server{server},
+				labels:  []compute.Label{testLabel("server", labelGroup, "runner")},
+			}
+			i := newTestGroup(api)
+			i.ReplaceAfter = tc.replaceAfter
+			i.startBackground()
+			defer i.stopBackground()
+
+			for _, state := range tc.states {
+				*server.Metadata.State = state
+				_ = i.Heartbeat(context.Background(), "server")
+				i.bgWG.Wait()
+			}
+			if !slices.Equal(api.deleted, tc.deleted) {
+				t.Errorf("heartbeats deleted %v, want %v", api.deleted, tc.deleted)
+			}
+		})
+	}
+}
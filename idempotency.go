@@ -0,0 +1,53 @@
+package ionos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const reconcileTimeout = 30 * time.Second
+
+// newIdempotencyToken returns a random token that is embedded in the name of
+// a server, so a create whose response got lost can be recognized later.
+func newIdempotencyToken() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// reconcileCreate looks for a server created by an earlier attempt of the
+// same create. It runs on its own context, as the caller's context may have
+// timed out, which is the most common cause for a lost response.
+func (i *InstanceGroup) reconcileCreate(ctx context.Context, datacenterID string, name string) (compute.Server, bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reconcileTimeout)
+	defer cancel()
+
+	servers, _, err := withRetry(ctx, i, "ServersGet", func(ctx context.Context) (compute.Servers, *shared.APIResponse, error) {
+		return i.api.ListServers(ctx, datacenterID, name, 1, 0, 0)
+	})
+	if err != nil {
+		i.log.Warn("Failed to check for an existing server", "name", name, "err", err)
+		return compute.Server{}, false
+	}
+	if servers.Items == nil {
+		return compute.Server{}, false
+	}
+	for _, server := range *servers.Items {
+		if *server.Properties.Name == name {
+			i.log.Info("Found server from an earlier create attempt", "id", *server.Id, "name", name)
+			return server, true
+		}
+	}
+	return compute.Server{}, false
+}
+
+// mayHaveCreated reports whether a failed create request may still have
+// created the server, i.e. no response was received or the API failed.
+func mayHaveCreated(apiResponse *shared.APIResponse) bool {
+	return apiResponse == nil || apiResponse.Response == nil || apiResponse.StatusCode >= 500
+}
@@ -0,0 +1,50 @@
+package ionos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestPostServerFindsEarlierAttempt(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		attempts    int
+		lostCreates int
+		errs        []error
+		posted      int
+		err         bool
+	}{
+		{"created", 3, 0, nil, 1, false},
+		// The retry finds the server of the lost response instead of
+		// posting it again.
+		{"lost response", 3, 1, nil, 1, false},
+		{"lost response on the last attempt", 1, 1, nil, 1, false},
+		{"server error without server", 3, 0, []error{apiError(http.StatusInternalServerError, "failed")}, 2, false},
+		{"client error", 3, 0, []error{apiError(http.StatusBadRequest, "invalid")}, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{lostCreates: tc.lostCreates, createErrs: tc.errs}
+			i := newTestGroup(api)
+			i.Retry.MaxAttempts = tc.attempts
+			name := i.newServerName(1)
+
+			serverData := compute.Server{Properties: &compute.ServerProperties{Name: &name}}
+			server, err := i.postServer(context.Background(), DatacenterConfig{ID: "dc1"}, name, serverData)
+			if (err != nil) != tc.err {
+				t.Fatalf("postServer error %v, want error %t", err, tc.err)
+			}
+			if len(api.posted) != tc.posted {
+				t.Errorf("postServer posted %d times, want %d", len(api.posted), tc.posted)
+			}
+			if tc.err {
+				return
+			}
+			if len(api.servers) != 1 || server.Id == nil || *server.Id != *api.servers[0].Id {
+				t.Errorf("postServer returned %v with the servers %d", server.Id, len(api.servers))
+			}
+		})
+	}
+}
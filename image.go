@@ -0,0 +1,174 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+// imageInfo is the metadata of the configured image that connector defaults
+// are derived from.
+type imageInfo struct {
+	name        string
+	aliases     []string
+	licenceType string
+	// size is the minimum volume size in GB.
+	size float32
+}
+
+// distroUsers maps distributions to the default user of their cloud images.
+var distroUsers = []struct {
+	distro string
+	user   string
+}{
+	{"ubuntu", "ubuntu"},
+	{"debian", "debian"},
+	{"centos", "centos"},
+	{"rocky", "rocky"},
+	{"alma", "almalinux"},
+	{"fedora", "fedora"},
+}
+
+// resolveImage fetches the metadata of the configured image, which may also
+// be a snapshot. A failure only costs the derived connector defaults and the
+// checks of validateImage, so it is logged and not returned.
+func (i *InstanceGroup) resolveImage(ctx context.Context) {
+	id := i.spec().Image
+	if id == "" {
+		return
+	}
+	image, apiResponse, err := withRetry(ctx, i, "ImagesFindById", func(ctx context.Context) (compute.Image, *shared.APIResponse, error) {
+		return i.api.GetImage(ctx, id)
+	})
+	if err != nil && apiResponse.HttpNotFound() {
+		i.resolveSnapshot(ctx, id)
+		return
+	}
+	if err != nil {
+		i.log.Warn("Failed to look up image, connector defaults are not derived from it", "image", id, "err", err)
+		return
+	}
+	if image.Properties == nil {
+		return
+	}
+	info := &imageInfo{}
+	if image.Properties.Name != nil {
+		info.name = *image.Properties.Name
+	}
+	if image.Properties.ImageAliases != nil {
+		info.aliases = *image.Properties.ImageAliases
+	}
+	if image.Properties.LicenceType != nil {
+		info.licenceType = *image.Properties.LicenceType
+	}
+	if image.Properties.Size != nil {
+		info.size = *image.Properties.Size
+	}
+	i.image = info
+}
+
+func (i *InstanceGroup) resolveSnapshot(ctx context.Context, id string) {
+	snapshot, _, err := withRetry(ctx, i, "SnapshotsFindById", func(ctx context.Context) (compute.Snapshot, *shared.APIResponse, error) {
+		return i.api.GetSnapshot(ctx, id)
+	})
+	if err != nil {
+		i.log.Warn("Failed to look up image, connector defaults are not derived from it", "image", id, "err", err)
+		return
+	}
+	if snapshot.Properties == nil {
+		return
+	}
+	info := &imageInfo{}
+	if snapshot.Properties.Name != nil {
+		info.name = *snapshot.Properties.Name
+	}
+	if snapshot.Properties.LicenceType != nil {
+		info.licenceType = *snapshot.Properties.LicenceType
+	}
+	if snapshot.Properties.Size != nil {
+		info.size = *snapshot.Properties.Size
+	}
+	i.image = info
+}
+
+// validateImage checks the volume sizes and the os against the image, which
+// the API would otherwise only reject when creating the first instance.
+func (i *InstanceGroup) validateImage() error {
+	if i.image == nil {
+		return nil
+	}
+
+	sizes := map[string]float32{}
+	if i.ServerSpec.Type == "ENTERPRISE" {
+		sizes["storage_size"] = i.ServerSpec.StorageSize
+	}
+	if fallback := i.ServerSpec.EnterpriseFallback; fallback != nil && fallback.StorageSize != 0 {
+		sizes["enterprise_fallback.storage_size"] = fallback.StorageSize
+	}
+	for n, v := range i.ServerSpecs {
+		if i.specType(&v) != "ENTERPRISE" {
+			continue
+		}
+		size := v.StorageSize
+		if size == 0 {
+			size = i.ServerSpec.StorageSize
+		}
+		sizes[fmt.Sprintf("server_specs[%d].storage_size", n)] = size
+	}
+	for field, size := range sizes {
+		if size != 0 && size < i.image.size {
+			return fmt.Errorf("%s of %v GB is smaller than the %v GB of image %s", field, size, i.image.size, i.image.name)
+		}
+	}
+
+	licence := strings.ToUpper(i.image.licenceType)
+	switch {
+	case strings.EqualFold(i.ServerSpec.OS, osWindows) && licence == "LINUX":
+		return fmt.Errorf("os is windows, but image %s is licensed for Linux", i.image.name)
+	case strings.EqualFold(i.ServerSpec.OS, osLinux) && i.image.isWindows():
+		return fmt.Errorf("os is linux, but image %s is licensed for %s", i.image.name, i.image.licenceType)
+	}
+	return nil
+}
+
+// isWindows reports whether the image is licensed for Windows.
+func (info *imageInfo) isWindows() bool {
+	return info != nil && strings.HasPrefix(strings.ToUpper(info.licenceType), "WINDOWS")
+}
+
+// username returns the default user of the image's distribution, or root.
+// IONOS installs the ssh_keys of a volume for root, so root is also used
+// with manage_ssh_key.
+func (info *imageInfo) username(managedKey bool) string {
+	if info == nil || managedKey {
+		return "root"
+	}
+	names := strings.ToLower(info.name + " " + strings.Join(info.aliases, " "))
+	for _, d := range distroUsers {
+		if strings.Contains(names, d.distro) {
+			return d.user
+		}
+	}
+	return "root"
+}
+
+// linuxConnectorDefaults fills in SSH defaults for Linux images where the
+// runner config leaves them empty.
+func (i *InstanceGroup) linuxConnectorDefaults(cfg *provider.ConnectorConfig) {
+	if cfg.OS == "" {
+		cfg.OS = osLinux
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = provider.ProtocolSSH
+	}
+	if cfg.ProtocolPort == 0 {
+		cfg.ProtocolPort = provider.DefaultProtocolPorts[cfg.Protocol]
+	}
+	if cfg.Username == "" {
+		cfg.Username = i.image.username(i.ManageSSHKey)
+	}
+}
@@ -0,0 +1,137 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// imageRefresh is how long the image selected by image_pattern or the
+// active golden image is used before Increase looks again.
+const imageRefresh = 5 * time.Minute
+
+// refreshImage resolves image_pattern or an "active" image to the image ID
+// of spec. The result is kept for imageRefresh, so rebuilt and promoted
+// images are picked up without a config change.
+func (i *InstanceGroup) refreshImage(ctx context.Context, spec *resolvedSpec) error {
+	if time.Since(i.imageResolvedAt) < imageRefresh {
+		return nil
+	}
+	var image string
+	var err error
+	switch {
+	case i.ServerSpec.ImagePattern != "":
+		image, err = i.resolveImagePattern(ctx, spec.Image)
+	case i.ServerSpec.Image == imageActive:
+		image, err = i.resolveActiveImage(ctx, spec.Image)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	spec.Image = image
+	i.imageResolvedAt = time.Now()
+	return nil
+}
+
+// resolveImagePattern returns the newest private image or snapshot whose
+// name matches image_pattern and that is in the location of the datacenters.
+// A change from the current image is logged.
+func (i *InstanceGroup) resolveImagePattern(ctx context.Context, current string) (string, error) {
+	pattern := i.ServerSpec.ImagePattern
+	location, err := i.datacenterLocation(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	candidates, err := i.privateImages(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var newest *privateImage
+	for n, image := range candidates {
+		if matched, _ := path.Match(pattern, image.name); !matched {
+			continue
+		}
+		if location != "" && image.location != "" && !strings.EqualFold(image.location, location) {
+			continue
+		}
+		if newest == nil || image.created.After(newest.created) {
+			newest = &candidates[n]
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("no private image matching %q in %s", pattern, location)
+	}
+
+	if newest.id != current {
+		i.log.Info("Selected image", "pattern", pattern, "image", newest.name, "id", newest.id, "created", newest.created)
+	}
+	return newest.id, nil
+}
+
+// privateImage is a private HDD image or a snapshot, both of which can be
+// used as the image of a volume.
+type privateImage struct {
+	id       string
+	name     string
+	location string
+	created  time.Time
+}
+
+func (i *InstanceGroup) privateImages(ctx context.Context) ([]privateImage, error) {
+	images, _, err := withRetry(ctx, i, "ImagesGet", func(ctx context.Context) (compute.Images, *shared.APIResponse, error) {
+		return i.api.ListImages(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+	snapshots, _, err := withRetry(ctx, i, "SnapshotsGet", func(ctx context.Context) (compute.Snapshots, *shared.APIResponse, error) {
+		return i.api.ListSnapshots(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+
+	var result []privateImage
+	if images.Items != nil {
+		for _, image := range *images.Items {
+			props := image.Properties
+			if props == nil || props.Name == nil || (props.Public != nil && *props.Public) {
+				continue
+			}
+			if props.ImageType != nil && *props.ImageType != "HDD" {
+				continue
+			}
+			result = append(result, newPrivateImage(*image.Id, props.Name, props.Location, image.Metadata))
+		}
+	}
+	if snapshots.Items != nil {
+		for _, snapshot := range *snapshots.Items {
+			props := snapshot.Properties
+			if props == nil || props.Name == nil {
+				continue
+			}
+			result = append(result, newPrivateImage(*snapshot.Id, props.Name, props.Location, snapshot.Metadata))
+		}
+	}
+	return result, nil
+}
+
+func newPrivateImage(id string, name, location *string, metadata *compute.DatacenterElementMetadata) privateImage {
+	image := privateImage{id: id, name: *name}
+	if location != nil {
+		image.location = *location
+	}
+	if metadata != nil && metadata.CreatedDate != nil {
+		image.created = metadata.CreatedDate.Time
+	}
+	return image
+}
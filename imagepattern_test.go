@@ -0,0 +1,67 @@
+package ionos
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestResolveImagePattern(t *testing.T) {
+	image := func(id, name, location string, age time.Duration) compute.Image {
+		return compute.Image{
+			Id:         &id,
+			Properties: &compute.ImageProperties{Name: &name, Location: &location, ImageType: StrPtr("HDD"), Public: BoolPtr(false)},
+			Metadata:   &compute.DatacenterElementMetadata{CreatedDate: &compute.IonosTime{Time: time.Now().Add(-age)}},
+		}
+	}
+	api := &mockCompute{
+		datacenters: []compute.Datacenter{
+			testDatacenter("fra-1", "de/fra"),
+			testDatacenter("fra-2", "de/fra"),
+			testDatacenter("txl-1", "de/txl"),
+		},
+		images: []compute.Image{
+			image("fra-old", "runner-1.0", "de/fra", 48*time.Hour),
+			image("fra-new", "runner-1.1", "de/fra", 24*time.Hour),
+			image("txl-newest", "runner-1.2", "de/txl", time.Hour),
+			image("fra-other", "builder-2.0", "de/fra", 0),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		datacenters []string
+		pattern     string
+		want        string
+		err         string
+	}{
+		{name: "newest in the location", datacenters: []string{"fra-1", "fra-2"}, pattern: "runner-*", want: "fra-new"},
+		{name: "other location", datacenters: []string{"txl-1"}, pattern: "runner-*", want: "txl-newest"},
+		{name: "mixed locations", datacenters: []string{"fra-1", "txl-1"}, pattern: "runner-*", err: "must be in the same one"},
+		{name: "no match in the location", datacenters: []string{"txl-1"}, pattern: "builder-*", err: `no private image matching "builder-*" in de/txl`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestGroup(api)
+			i.DatacenterId = ""
+			for _, id := range tt.datacenters {
+				i.Datacenters = append(i.Datacenters, DatacenterConfig{ID: id})
+			}
+			i.ServerSpec.ImagePattern = tt.pattern
+
+			got, err := i.resolveImagePattern(context.Background(), "")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("resolveImagePattern() = %q, %v, want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveImagePattern() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
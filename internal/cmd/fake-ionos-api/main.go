@@ -0,0 +1,66 @@
+// Command fake-ionos-api serves an in-memory fake of the IONOS Cloud API
+// until interrupted. Point the plugin or the fleeting-ionos commands at it
+// with IONOS_API_URL to run them without credentials. It is a development
+// tool and not part of the plugin.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/codecentric/fleeting-plugin-ionos/internal/fakeapi"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fake-ionos-api", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8443", "address to listen on")
+	bootDelay := fs.Duration("boot-delay", 5*time.Second, "how long new servers stay BUSY")
+	full := fs.String("full", "", "comma separated datacenter IDs or <datacenter>/<zone> without capacity")
+	deleteDelay := fs.Duration("delete-delay", 0, "how long deleted servers stay BUSY before they are gone")
+	failRequests := fs.Bool("fail-requests", false, "let asynchronous requests end FAILED")
+	fs.Parse(args)
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+
+	fake := fakeapi.New()
+	fake.BootDelay = *bootDelay
+	fake.DeleteDelay = *deleteDelay
+	fake.FailRequests = *failRequests
+	if *full != "" {
+		fake.Full = strings.Split(*full, ",")
+	}
+	fake.SetBaseURL("http://" + listener.Addr().String())
+	server := &http.Server{Handler: fake.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	fmt.Printf("IONOS_API_URL=http://%s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,837 @@
+// Package fakeapi is an in-memory fake of the subset of the IONOS Cloud API
+// used by the plugin: datacenters, LANs, servers with their NICs and volumes,
+// labels, NAT gateways, templates, images, snapshots, contracts and requests. It lets the
+// Increase, Update, ConnectInfo and Decrease lifecycle run without
+// credentials, e.g. in CI.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// Server is a fake Cloud API. All datacenters and LANs exist, servers are
+// BUSY for BootDelay after being created and AVAILABLE afterwards.
+type Server struct {
+	// BootDelay is how long new servers stay BUSY.
+	BootDelay time.Duration
+	// Templates are the CUBE templates returned by the API.
+	Templates []compute.Template
+	// Images are the private images returned by the API. Other image IDs
+	// are served as a public Linux image.
+	Images []compute.Image
+	// Limits are the resource limits of the contract.
+	Limits compute.ResourceLimits
+	// FailRequests makes asynchronous requests end FAILED.
+	FailRequests bool
+	// DeleteDelay is how long deleted servers stay BUSY before they are gone.
+	DeleteDelay time.Duration
+	// Full are datacenter IDs or availability zones, as "<datacenter>/<zone>",
+	// without capacity left. Creating a server there fails with 422.
+	Full []string
+
+	mu      sync.Mutex
+	servers map[string]*server
+	labels  map[string]map[string]string
+	// snapshotLabels are the labels of snapshots, labels those of servers.
+	snapshotLabels map[string]map[string]string
+	// volumeLabels are the labels of volumes.
+	volumeLabels map[string]map[string]string
+	// datacenterLabels are the labels of datacenters.
+	datacenterLabels map[string]map[string]string
+	gateways         map[string][]compute.NatGateway
+	lans             map[string][]compute.Lan
+	snapshots        []compute.Snapshot
+	requests         []request
+	nextID           int
+	nextReq          int
+	baseURL          string
+}
+
+type server struct {
+	datacenterID string
+	created      time.Time
+	// powerState is the VM state of a stopped or suspended server, empty
+	// while it runs.
+	powerState string
+	// powered is when a stopped server was started again, it boots for
+	// BootDelay like a new one.
+	powered time.Time
+	// deleting is set while a deleted server lingers for DeleteDelay.
+	deleting bool
+	data     compute.Server
+}
+
+// New returns a fake with a few default templates and images and generous
+// limits.
+func New() *Server {
+	return &Server{
+		Templates: []compute.Template{
+			template("15c6dd2f-02d2-4987-b439-9a58dd59ecc3", "Basic Cube XS", 1, 1024, 30),
+			template("5f4bc5c8-6a2b-4a14-8c7e-1a0ef7d6f5a1", "Basic Cube S", 2, 2048, 60),
+			template("8b4a9c3e-7e2f-4c2b-9d5b-3c6e2f1a7b90", "Basic Cube M", 4, 4096, 120),
+		},
+		Images: []compute.Image{
+			image("0a7c3f4e-5b1d-4e8a-9c2f-6d3b8e1f4a70", "runner-golden-v1", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)),
+			image("3e9d1b6a-8f2c-4a7d-b5e1-9c4f2a6d8b31", "runner-golden-v2", time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)),
+		},
+		Limits: compute.ResourceLimits{
+			CoresPerServer:   int32Ptr(64),
+			CoresPerContract: int32Ptr(1000),
+			CoresProvisioned: int32Ptr(0),
+			RamPerServer:     int32Ptr(256 * 1024),
+			RamPerContract:   int32Ptr(4096 * 1024),
+			RamProvisioned:   int32Ptr(0),
+		},
+		servers:          make(map[string]*server),
+		labels:           make(map[string]map[string]string),
+		snapshotLabels:   make(map[string]map[string]string),
+		volumeLabels:     make(map[string]map[string]string),
+		datacenterLabels: make(map[string]map[string]string),
+		gateways:         make(map[string][]compute.NatGateway),
+		lans:             make(map[string][]compute.Lan),
+	}
+}
+
+// Start serves the fake on a local port. The URL to configure as API
+// endpoint is the URL of the returned server.
+func (s *Server) Start() *httptest.Server {
+	ts := httptest.NewServer(s.Handler())
+	s.SetBaseURL(ts.URL)
+	return ts
+}
+
+// SetBaseURL sets the URL the fake is reachable at, used for the Location
+// header of asynchronous requests.
+func (s *Server) SetBaseURL(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baseURL = strings.TrimSuffix(url, "/")
+}
+
+// Handler returns the HTTP handler of the fake.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /datacenters", s.createDatacenter)
+	mux.HandleFunc("GET /datacenters/{dc}", s.getDatacenter)
+	mux.HandleFunc("POST /datacenters/{dc}/labels", s.addDatacenterLabel)
+	mux.HandleFunc("DELETE /datacenters/{dc}/labels/{key}", s.deleteDatacenterLabel)
+	mux.HandleFunc("GET /datacenters/{dc}/lans", s.listLans)
+	mux.HandleFunc("POST /datacenters/{dc}/lans", s.createLan)
+	mux.HandleFunc("GET /datacenters/{dc}/lans/{lan}", s.getLan)
+	mux.HandleFunc("GET /datacenters/{dc}/servers", s.listServers)
+	mux.HandleFunc("POST /datacenters/{dc}/servers", s.createServer)
+	mux.HandleFunc("GET /datacenters/{dc}/servers/{id}", s.getServer)
+	mux.HandleFunc("DELETE /datacenters/{dc}/servers/{id}", s.deleteServer)
+	mux.HandleFunc("GET /datacenters/{dc}/servers/{id}/nics", s.listNics)
+	mux.HandleFunc("POST /datacenters/{dc}/servers/{id}/stop", s.powerServer("SHUTOFF"))
+	mux.HandleFunc("POST /datacenters/{dc}/servers/{id}/start", s.powerServer(""))
+	mux.HandleFunc("POST /datacenters/{dc}/servers/{id}/suspend", s.powerServer("SUSPENDED"))
+	mux.HandleFunc("POST /datacenters/{dc}/servers/{id}/resume", s.powerServer(""))
+	mux.HandleFunc("POST /datacenters/{dc}/servers/{id}/labels", s.addLabel)
+	mux.HandleFunc("DELETE /datacenters/{dc}/servers/{id}/labels/{key}", s.deleteLabel)
+	mux.HandleFunc("GET /datacenters/{dc}/volumes", s.listVolumes)
+	mux.HandleFunc("DELETE /datacenters/{dc}/volumes/{id}", s.accepted)
+	mux.HandleFunc("POST /datacenters/{dc}/volumes/{id}/labels", s.addVolumeLabel)
+	mux.HandleFunc("POST /datacenters/{dc}/volumes/{id}/create-snapshot", s.createSnapshot)
+	mux.HandleFunc("GET /datacenters/{dc}/natgateways", s.listNATGateways)
+	mux.HandleFunc("POST /datacenters/{dc}/natgateways", s.createNATGateway)
+	mux.HandleFunc("POST /datacenters/{dc}/natgateways/{id}/rules", s.createNATGatewayRule)
+	mux.HandleFunc("GET /labels", s.listLabels)
+	mux.HandleFunc("GET /templates", s.listTemplates)
+	mux.HandleFunc("GET /templates/{id}", s.getTemplate)
+	mux.HandleFunc("GET /images", s.listImages)
+	mux.HandleFunc("GET /snapshots", s.listSnapshots)
+	mux.HandleFunc("GET /snapshots/{id}", s.getSnapshot)
+	mux.HandleFunc("DELETE /snapshots/{id}", s.deleteSnapshot)
+	mux.HandleFunc("POST /snapshots/{id}/labels", s.addSnapshotLabel)
+	mux.HandleFunc("DELETE /snapshots/{id}/labels/{key}", s.deleteSnapshotLabel)
+	mux.HandleFunc("GET /images/{id}", s.getImage)
+	mux.HandleFunc("GET /contracts", s.listContracts)
+	mux.HandleFunc("GET /requests", s.listRequests)
+	mux.HandleFunc("GET /requests/{id}/status", s.requestStatus)
+	return s.recordRequests(mux)
+}
+
+// Servers returns the IDs of the servers that exist, sorted.
+func (s *Server) Servers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.servers))
+	for id := range s.servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) getDatacenter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("dc")
+	writeJSON(w, http.StatusOK, compute.Datacenter{
+		Id:         &id,
+		Properties: &compute.DatacenterProperties{Name: strPtr("fake"), Location: strPtr("de/fra")},
+	})
+}
+
+func (s *Server) createDatacenter(w http.ResponseWriter, r *http.Request) {
+	var datacenter compute.Datacenter
+	if err := json.NewDecoder(r.Body).Decode(&datacenter); err != nil || datacenter.Properties == nil {
+		writeError(w, http.StatusBadRequest, "invalid datacenter")
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	datacenter.Id = strPtr(fmt.Sprintf("00000000-0000-4000-e000-%012d", s.nextID))
+	s.mu.Unlock()
+
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, datacenter)
+}
+
+func (s *Server) getLan(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("lan")
+	writeJSON(w, http.StatusOK, compute.Lan{
+		Id:         &id,
+		Metadata:   &compute.DatacenterElementMetadata{State: strPtr("AVAILABLE")},
+		Properties: &compute.LanProperties{Name: strPtr("lan-" + id), Public: boolPtr(false)},
+	})
+}
+
+// listLans returns the LANs created through the fake, while getLan makes
+// up any other LAN.
+func (s *Server) listLans(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items := append([]compute.Lan{}, s.lans[r.PathValue("dc")]...)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, compute.Lans{Items: &items})
+}
+
+func (s *Server) createLan(w http.ResponseWriter, r *http.Request) {
+	var lan compute.LanPost
+	if err := json.NewDecoder(r.Body).Decode(&lan); err != nil || lan.Properties == nil {
+		writeError(w, http.StatusBadRequest, "invalid LAN")
+		return
+	}
+
+	dc := r.PathValue("dc")
+	s.mu.Lock()
+	lan.Id = strPtr(strconv.Itoa(len(s.lans[dc]) + 100))
+	s.lans[dc] = append(s.lans[dc], compute.Lan{
+		Id:         lan.Id,
+		Metadata:   &compute.DatacenterElementMetadata{State: strPtr("AVAILABLE")},
+		Properties: &compute.LanProperties{Name: lan.Properties.Name, Public: lan.Properties.Public},
+	})
+	s.mu.Unlock()
+
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, lan)
+}
+
+func (s *Server) listServers(w http.ResponseWriter, r *http.Request) {
+	dc := r.PathValue("dc")
+	name := r.URL.Query().Get("filter.name")
+
+	s.mu.Lock()
+	var items []compute.Server
+	for _, srv := range s.servers {
+		if srv.datacenterID != dc || !strings.Contains(*srv.data.Properties.Name, name) {
+			continue
+		}
+		items = append(items, withDepth(s.view(srv), r))
+	}
+	s.mu.Unlock()
+	sort.Slice(items, func(a, b int) bool { return *items[a].Id < *items[b].Id })
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	links := &compute.PaginationLinks{}
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+		links.Next = strPtr(fmt.Sprintf("?offset=%d&limit=%d", offset+limit, limit))
+	}
+	writeJSON(w, http.StatusOK, compute.Servers{Items: &items, Links: links})
+}
+
+func (s *Server) createServer(w http.ResponseWriter, r *http.Request) {
+	var data compute.Server
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil || data.Properties == nil || data.Properties.Name == nil {
+		writeError(w, http.StatusBadRequest, "invalid server")
+		return
+	}
+	placement := r.PathValue("dc")
+	if zone := data.Properties.AvailabilityZone; zone != nil {
+		placement += "/" + *zone
+	}
+	for _, full := range s.Full {
+		if full == r.PathValue("dc") || full == placement {
+			writeError(w, http.StatusUnprocessableEntity, "insufficient capacity in "+placement)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	n := s.nextID
+	id := fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+	data.Id = &id
+	if data.Entities == nil {
+		data.Entities = &compute.ServerEntities{}
+	}
+	if data.Entities.Nics != nil && data.Entities.Nics.Items != nil {
+		for k := range *data.Entities.Nics.Items {
+			nic := &(*data.Entities.Nics.Items)[k]
+			nic.Id = strPtr(fmt.Sprintf("00000000-0000-4000-9000-%08d%04d", n, k))
+			if nic.Properties.Ips == nil {
+				nic.Properties.Ips = &[]string{fmt.Sprintf("10.%d.%d.%d", k, n/250, n%250+2)}
+			}
+		}
+	}
+	if data.Entities.Volumes != nil && data.Entities.Volumes.Items != nil {
+		for k := range *data.Entities.Volumes.Items {
+			(*data.Entities.Volumes.Items)[k].Id = strPtr(fmt.Sprintf("00000000-0000-4000-a000-%08d%04d", n, k))
+		}
+	}
+	srv := &server{datacenterID: r.PathValue("dc"), created: time.Now(), data: data}
+	s.servers[id] = srv
+	view := s.view(srv)
+	s.mu.Unlock()
+
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, view)
+}
+
+func (s *Server) getServer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	srv, ok := s.servers[r.PathValue("id")]
+	var view compute.Server
+	if ok && srv.datacenterID == r.PathValue("dc") {
+		view = withDepth(s.view(srv), r)
+	} else {
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (s *Server) listNics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	srv, ok := s.servers[r.PathValue("id")]
+	nics := []compute.Nic{}
+	if ok && srv.datacenterID == r.PathValue("dc") {
+		if view := s.view(srv); view.Entities.Nics != nil && view.Entities.Nics.Items != nil {
+			nics = *view.Entities.Nics.Items
+		}
+	} else {
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, compute.Nics{Items: &nics})
+}
+
+func (s *Server) deleteServer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	srv, ok := s.servers[id]
+	if ok && srv.datacenterID == r.PathValue("dc") {
+		if s.DeleteDelay > 0 {
+			srv.deleting = true
+			time.AfterFunc(s.DeleteDelay, func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.removeServer(id)
+			})
+		} else {
+			s.removeServer(id)
+		}
+	} else {
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	s.accepted(w, r)
+}
+
+// removeServer drops a server. It must be called with mu held.
+func (s *Server) removeServer(id string) {
+	delete(s.servers, id)
+	delete(s.labels, id)
+}
+
+// powerServer stops, suspends, starts or resumes a server. A stopped server
+// is AVAILABLE and SHUTOFF, a suspended one AVAILABLE and SUSPENDED.
+func (s *Server) powerServer(powerState string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		srv, ok := s.servers[r.PathValue("id")]
+		if ok && srv.datacenterID == r.PathValue("dc") {
+			if srv.powerState != "" && powerState == "" {
+				srv.powered = time.Now()
+			}
+			srv.powerState = powerState
+		} else {
+			ok = false
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			writeError(w, http.StatusNotFound, "server not found")
+			return
+		}
+		s.accepted(w, r)
+	}
+}
+
+func (s *Server) createSnapshot(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+	s.mu.Lock()
+	s.nextID++
+	snapshot := compute.Snapshot{
+		Id:       strPtr(fmt.Sprintf("00000000-0000-4000-f000-%012d", s.nextID)),
+		Metadata: &compute.DatacenterElementMetadata{CreatedDate: &compute.IonosTime{Time: time.Now()}},
+		Properties: &compute.SnapshotProperties{
+			Name:        strPtr(r.FormValue("name")),
+			Description: strPtr(r.FormValue("description")),
+			Location:    strPtr("de/fra"),
+		},
+	}
+	s.snapshots = append(s.snapshots, snapshot)
+	s.mu.Unlock()
+
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *Server) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := append([]compute.Snapshot{}, s.snapshots...)
+	writeJSON(w, http.StatusOK, compute.Snapshots{Items: &items})
+}
+
+func (s *Server) getSnapshot(w http.ResponseWriter, r *http.Request) {
+	if snapshot, ok := s.snapshot(r.PathValue("id")); ok {
+		writeJSON(w, http.StatusOK, snapshot)
+		return
+	}
+	writeError(w, http.StatusNotFound, "snapshot not found")
+}
+
+func (s *Server) snapshot(id string) (compute.Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snapshot := range s.snapshots {
+		if *snapshot.Id == id {
+			return snapshot, true
+		}
+	}
+	return compute.Snapshot{}, false
+}
+
+func (s *Server) deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	n := len(s.snapshots)
+	s.snapshots = slices.DeleteFunc(s.snapshots, func(snapshot compute.Snapshot) bool { return *snapshot.Id == id })
+	ok := len(s.snapshots) < n
+	delete(s.snapshotLabels, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	}
+	s.accepted(w, r)
+}
+
+func (s *Server) addSnapshotLabel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var label compute.LabelResource
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil || label.Properties == nil || label.Properties.Key == nil || label.Properties.Value == nil {
+		writeError(w, http.StatusBadRequest, "invalid label")
+		return
+	}
+
+	s.mu.Lock()
+	ok := slices.ContainsFunc(s.snapshots, func(snapshot compute.Snapshot) bool { return *snapshot.Id == id })
+	if ok {
+		if s.snapshotLabels[id] == nil {
+			s.snapshotLabels[id] = make(map[string]string)
+		}
+		s.snapshotLabels[id][*label.Properties.Key] = *label.Properties.Value
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, label)
+}
+
+// addVolumeLabel accepts a label on a volume of a server.
+func (s *Server) addVolumeLabel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var label compute.LabelResource
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil || label.Properties == nil || label.Properties.Key == nil || label.Properties.Value == nil {
+		writeError(w, http.StatusBadRequest, "invalid label")
+		return
+	}
+
+	s.mu.Lock()
+	ok := false
+	for _, srv := range s.servers {
+		if srv.data.Entities != nil && srv.data.Entities.Volumes != nil && srv.data.Entities.Volumes.Items != nil {
+			ok = ok || slices.ContainsFunc(*srv.data.Entities.Volumes.Items, func(volume compute.Volume) bool {
+				return volume.Id != nil && *volume.Id == id
+			})
+		}
+	}
+	if ok {
+		if s.volumeLabels[id] == nil {
+			s.volumeLabels[id] = make(map[string]string)
+		}
+		s.volumeLabels[id][*label.Properties.Key] = *label.Properties.Value
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "volume not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, label)
+}
+
+func (s *Server) deleteSnapshotLabel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delete(s.snapshotLabels[r.PathValue("id")], r.PathValue("key"))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addLabel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var label compute.LabelResource
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil || label.Properties == nil || label.Properties.Key == nil || label.Properties.Value == nil {
+		writeError(w, http.StatusBadRequest, "invalid label")
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.servers[id]
+	if ok {
+		if s.labels[id] == nil {
+			s.labels[id] = make(map[string]string)
+		}
+		s.labels[id][*label.Properties.Key] = *label.Properties.Value
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, label)
+}
+
+// addDatacenterLabel accepts a label on a datacenter. Datacenter labels are
+// not stored, the plugin only sets them to check its permissions.
+func (s *Server) addDatacenterLabel(w http.ResponseWriter, r *http.Request) {
+	var label compute.LabelResource
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil || label.Properties == nil || label.Properties.Key == nil {
+		writeError(w, http.StatusBadRequest, "invalid label")
+		return
+	}
+	dc, key := r.PathValue("dc"), *label.Properties.Key
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.datacenterLabels[dc][key]; ok {
+		writeError(w, http.StatusUnprocessableEntity, "label with key "+key+" already exists")
+		return
+	}
+	if s.datacenterLabels[dc] == nil {
+		s.datacenterLabels[dc] = make(map[string]string)
+	}
+	var value string
+	if label.Properties.Value != nil {
+		value = *label.Properties.Value
+	}
+	s.datacenterLabels[dc][key] = value
+	writeJSON(w, http.StatusCreated, label)
+}
+
+func (s *Server) deleteDatacenterLabel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delete(s.datacenterLabels[r.PathValue("dc")], r.PathValue("key"))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteLabel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delete(s.labels[r.PathValue("id")], r.PathValue("key"))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("filter.key")
+
+	s.mu.Lock()
+	items := []compute.Label{}
+	for resourceType, resources := range map[string]map[string]map[string]string{"server": s.labels, "snapshot": s.snapshotLabels, "volume": s.volumeLabels} {
+		for id, labels := range resources {
+			for k, v := range labels {
+				if key != "" && !strings.Contains(k, key) {
+					continue
+				}
+				items = append(items, compute.Label{
+					Properties: &compute.LabelProperties{
+						Key:          strPtr(k),
+						Value:        strPtr(v),
+						ResourceId:   strPtr(id),
+						ResourceType: strPtr(resourceType),
+					},
+				})
+			}
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, compute.Labels{Items: &items})
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request) {
+	dc := r.PathValue("dc")
+
+	s.mu.Lock()
+	items := []compute.Volume{}
+	for _, srv := range s.servers {
+		if srv.datacenterID != dc || srv.data.Entities.Volumes == nil || srv.data.Entities.Volumes.Items == nil {
+			continue
+		}
+		for _, volume := range *srv.data.Entities.Volumes.Items {
+			volume.Metadata = &compute.DatacenterElementMetadata{State: strPtr("AVAILABLE")}
+			volume.Properties.BootServer = srv.data.Id
+			items = append(items, volume)
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, compute.Volumes{Items: &items})
+}
+
+func (s *Server) listNATGateways(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items := append([]compute.NatGateway{}, s.gateways[r.PathValue("dc")]...)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, compute.NatGateways{Items: &items})
+}
+
+func (s *Server) createNATGateway(w http.ResponseWriter, r *http.Request) {
+	var gateway compute.NatGateway
+	if err := json.NewDecoder(r.Body).Decode(&gateway); err != nil || gateway.Properties == nil {
+		writeError(w, http.StatusBadRequest, "invalid NAT gateway")
+		return
+	}
+
+	dc := r.PathValue("dc")
+	s.mu.Lock()
+	s.nextID++
+	gateway.Id = strPtr(fmt.Sprintf("00000000-0000-4000-c000-%012d", s.nextID))
+	gateway.Entities = &compute.NatGatewayEntities{Rules: &compute.NatGatewayRules{Items: &[]compute.NatGatewayRule{}}}
+	s.gateways[dc] = append(s.gateways[dc], gateway)
+	s.mu.Unlock()
+
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, gateway)
+}
+
+func (s *Server) createNATGatewayRule(w http.ResponseWriter, r *http.Request) {
+	var rule compute.NatGatewayRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil || rule.Properties == nil {
+		writeError(w, http.StatusBadRequest, "invalid NAT gateway rule")
+		return
+	}
+
+	s.mu.Lock()
+	found := false
+	for _, gateway := range s.gateways[r.PathValue("dc")] {
+		if *gateway.Id == r.PathValue("id") {
+			s.nextID++
+			rule.Id = strPtr(fmt.Sprintf("00000000-0000-4000-d000-%012d", s.nextID))
+			*gateway.Entities.Rules.Items = append(*gateway.Entities.Rules.Items, rule)
+			found = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		writeError(w, http.StatusNotFound, "NAT gateway not found")
+		return
+	}
+	s.setLocation(w)
+	writeJSON(w, http.StatusAccepted, rule)
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	items := append([]compute.Template(nil), s.Templates...)
+	writeJSON(w, http.StatusOK, compute.Templates{Items: &items})
+}
+
+func (s *Server) getTemplate(w http.ResponseWriter, r *http.Request) {
+	for _, t := range s.Templates {
+		if *t.Id == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, t)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "template not found")
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, compute.Images{Items: &s.Images})
+}
+
+func (s *Server) getImage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	for _, image := range s.Images {
+		if *image.Id == id {
+			writeJSON(w, http.StatusOK, image)
+			return
+		}
+	}
+	if _, ok := s.snapshot(id); ok {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, compute.Image{
+		Id:         &id,
+		Properties: &compute.ImageProperties{Name: strPtr("fake-image"), ImageType: strPtr("HDD"), LicenceType: strPtr("LINUX")},
+	})
+}
+
+func (s *Server) listContracts(w http.ResponseWriter, r *http.Request) {
+	limits := s.Limits
+	writeJSON(w, http.StatusOK, compute.Contracts{
+		Items: &[]compute.Contract{{Properties: &compute.ContractProperties{ResourceLimits: &limits}}},
+	})
+}
+
+func (s *Server) accepted(w http.ResponseWriter, r *http.Request) {
+	s.setLocation(w)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// view returns the server as the API would show it now. It must be called
+// with mu held.
+func (s *Server) view(srv *server) compute.Server {
+	state, vmState := "BUSY", "SHUTOFF"
+	if srv.deleting {
+		state = "BUSY"
+	} else if srv.powerState != "" {
+		state, vmState = "AVAILABLE", srv.powerState
+	} else if time.Since(srv.created) >= s.BootDelay && time.Since(srv.powered) >= s.BootDelay {
+		state, vmState = "AVAILABLE", "RUNNING"
+	}
+	created := compute.IonosTime{Time: srv.created}
+
+	view := srv.data
+	props := *view.Properties
+	props.VmState = &vmState
+	view.Properties = &props
+	view.Metadata = &compute.DatacenterElementMetadata{State: &state, CreatedDate: &created}
+	view.Href = strPtr(fmt.Sprintf("%s/datacenters/%s/servers/%s", s.baseURL, srv.datacenterID, *srv.data.Id))
+	return view
+}
+
+// withDepth leaves out the NICs and volumes of a server below depth 2.
+func withDepth(view compute.Server, r *http.Request) compute.Server {
+	if depth, err := strconv.Atoi(r.URL.Query().Get("depth")); err == nil && depth < 2 {
+		view.Entities = nil
+	}
+	return view
+}
+
+func (s *Server) setLocation(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.nextReq++
+	location := fmt.Sprintf("%s/requests/%08d-0000-4000-b000-000000000000/status", s.baseURL, s.nextReq)
+	s.mu.Unlock()
+	w.Header().Set("Location", location)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"httpStatus": status,
+		"messages":   []map[string]string{{"errorCode": strconv.Itoa(status), "message": message}},
+	})
+}
+
+func template(id, name string, cores, ram, storage float32) compute.Template {
+	return compute.Template{
+		Id: &id,
+		Properties: &compute.TemplateProperties{
+			Name:        &name,
+			Cores:       &cores,
+			Ram:         &ram,
+			StorageSize: &storage,
+		},
+	}
+}
+
+func image(id, name string, created time.Time) compute.Image {
+	return compute.Image{
+		Id:       &id,
+		Metadata: &compute.DatacenterElementMetadata{CreatedDate: &compute.IonosTime{Time: created}},
+		Properties: &compute.ImageProperties{
+			Name:        &name,
+			ImageType:   strPtr("HDD"),
+			LicenceType: strPtr("LINUX"),
+			Location:    strPtr("de/fra"),
+			Public:      boolPtr(false),
+			Size:        float32Ptr(20),
+		},
+	}
+}
+
+func strPtr(s string) *string       { return &s }
+func boolPtr(b bool) *bool          { return &b }
+func int32Ptr(n int32) *int32       { return &n }
+func float32Ptr(f float32) *float32 { return &f }
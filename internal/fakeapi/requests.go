@@ -0,0 +1,147 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// request is an asynchronous request the fake accepted.
+type request struct {
+	id      string
+	created time.Time
+	method  string
+	url     string
+	body    string
+	target  string
+}
+
+var serverPath = regexp.MustCompile(`/servers/([^/]+)`)
+
+// responseRecorder keeps the body written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// recordRequests records the requests answered with a Location header, so
+// they can be listed and their status queried like with the API.
+func (s *Server) recordRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		location := w.Header().Get("Location")
+		if location == "" {
+			return
+		}
+		req := request{
+			id:      strings.TrimSuffix(strings.TrimPrefix(location, s.baseURL+"/requests/"), "/status"),
+			created: time.Now(),
+			method:  r.Method,
+			url:     s.baseURL + r.URL.Path,
+			body:    string(body),
+		}
+		if m := serverPath.FindStringSubmatch(r.URL.Path); m != nil {
+			req.target = m[1]
+		} else {
+			var created struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(rec.body.Bytes(), &created) == nil {
+				req.target = created.ID
+			}
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+	})
+}
+
+// requestStatus returns the status of a request, DONE unless FailRequests
+// is set.
+func (s *Server) requestStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, req := range s.requests {
+		if req.id == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, s.status(req))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, compute.RequestStatus{
+		Metadata: &compute.RequestStatusMetadata{Status: strPtr("DONE"), Message: strPtr("Request has been successfully executed")},
+	})
+}
+
+// listRequests lists the recorded requests, newest first, created after
+// filter.createdAfter if given.
+func (s *Server) listRequests(w http.ResponseWriter, r *http.Request) {
+	var after time.Time
+	if v := r.URL.Query().Get("filter.createdAfter"); v != "" {
+		after, _ = time.ParseInLocation("2006-01-02 15:04:05", v, time.UTC)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := []compute.Request{}
+	for n := len(s.requests) - 1; n >= 0; n-- {
+		req := s.requests[n]
+		if req.created.Before(after) {
+			continue
+		}
+		created := compute.IonosTime{Time: req.created}
+		status := s.status(req)
+		items = append(items, compute.Request{
+			Id:   strPtr(req.id),
+			Href: strPtr(s.baseURL + "/requests/" + req.id),
+			Metadata: &compute.RequestMetadata{
+				CreatedDate:   &created,
+				RequestStatus: &status,
+			},
+			Properties: &compute.RequestProperties{
+				Method: strPtr(req.method),
+				Url:    strPtr(req.url),
+				Body:   strPtr(req.body),
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, compute.Requests{Items: &items})
+}
+
+// status returns the status of a request. It must be called with mu held.
+func (s *Server) status(req request) compute.RequestStatus {
+	status, message := "DONE", "Request has been successfully executed"
+	if s.FailRequests {
+		status, message = "FAILED", "Request failed"
+	}
+	var targets []compute.RequestTarget
+	if req.target != "" {
+		targets = append(targets, compute.RequestTarget{
+			Target: &compute.ResourceReference{Id: strPtr(req.target)},
+			Status: strPtr(status),
+		})
+	}
+	return compute.RequestStatus{
+		Id:       strPtr(req.id),
+		Href:     strPtr(s.baseURL + "/requests/" + req.id + "/status"),
+		Metadata: &compute.RequestStatusMetadata{Status: &status, Message: &message, Targets: &targets},
+	}
+}
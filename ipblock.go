@@ -0,0 +1,85 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// nextReservedIP picks the next IP of the reserved IP block that is neither
+// assigned to a NIC nor handed out for a server still being created, going
+// round-robin over the block so consecutive instances get different
+// addresses.
+func (i *InstanceGroup) nextReservedIP(ctx context.Context) (string, error) {
+	block, _, err := withRetry(ctx, i, "IpblocksFindById", func(ctx context.Context) (compute.IpBlock, *shared.APIResponse, error) {
+		return i.api.GetIPBlock(ctx, i.ServerSpec.IPBlockID)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get IP block %v: %w", i.ServerSpec.IPBlockID, err)
+	}
+	if block.Properties == nil || block.Properties.Ips == nil || len(*block.Properties.Ips) == 0 {
+		return "", fmt.Errorf("IP block %v has no IPs", i.ServerSpec.IPBlockID)
+	}
+
+	used := make(map[string]bool)
+	if block.Properties.IpConsumers != nil {
+		for _, consumer := range *block.Properties.IpConsumers {
+			if consumer.Ip != nil {
+				used[*consumer.Ip] = true
+			}
+		}
+	}
+
+	ips := *block.Properties.Ips
+	p := &i.reservedIPs
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = make(map[string]time.Time)
+	}
+	for ip, assigned := range p.pending {
+		if used[ip] || time.Since(assigned) > staticIPPendingTTL {
+			delete(p.pending, ip)
+		}
+	}
+	for n := range ips {
+		ip := ips[(p.cursor+n)%len(ips)]
+		if used[ip] {
+			continue
+		}
+		if _, ok := p.pending[ip]; ok {
+			continue
+		}
+		p.cursor = (p.cursor + n + 1) % len(ips)
+		p.pending[ip] = time.Now()
+		return ip, nil
+	}
+	return "", fmt.Errorf("all %d IPs of IP block %v are in use", len(ips), i.ServerSpec.IPBlockID)
+}
+
+// addPublicNIC adds a NIC in the public LAN using the given reserved IP,
+// followed by the configured secondary IPs. It gets the same firewall as the
+// private NIC, so the public IP does not expose more than the LAN does.
+func (i *InstanceGroup) addPublicNIC(serverData *compute.Server, ip string) {
+	lanID := i.ServerSpec.PublicLanID
+	ips := append([]string{ip}, i.ServerSpec.SecondaryIPs...)
+	rules := i.nicFirewallRules()
+	var entities *compute.NicEntities
+	if len(rules) > 0 {
+		entities = &compute.NicEntities{Firewallrules: firewallRules(rules)}
+	}
+	nics := serverData.Entities.Nics.Items
+	*nics = append(*nics, compute.Nic{
+		Properties: &compute.NicProperties{
+			Name:           StrPtr("publicNIC"),
+			Lan:            &lanID,
+			Ips:            &ips,
+			Dhcp:           BoolPtr(true),
+			FirewallActive: BoolPtr(len(rules) > 0),
+		},
+		Entities: entities,
+	})
+}
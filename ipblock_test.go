@@ -0,0 +1,98 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// testIPBlock returns an IP block of which the used IPs are assigned to
+// NICs.
+func testIPBlock(id string, ips, used []string) compute.IpBlock {
+	var consumers []compute.IpConsumer
+	for _, ip := range used {
+		consumers = append(consumers, compute.IpConsumer{Ip: StrPtr(ip)})
+	}
+	return compute.IpBlock{Id: &id, Properties: &compute.IpBlockProperties{Ips: &ips, IpConsumers: &consumers}}
+}
+
+func TestNextReservedIP(t *testing.T) {
+	ips := []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}
+	for _, tc := range []struct {
+		name    string
+		used    []string
+		release string
+		want    []string
+	}{
+		{"round-robin", nil, "", []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}},
+		// IPs handed out for servers still being created are not handed
+		// out again, even though the block does not list them as used yet.
+		{"skips used and pending", []string{"192.0.2.2"}, "", []string{"192.0.2.1", "192.0.2.3", "192.0.2.4"}},
+		// A released IP, e.g. of a failed create, is available again.
+		{"reuses released", []string{"192.0.2.2"}, "192.0.2.3", []string{"192.0.2.1", "192.0.2.3", "192.0.2.4", "192.0.2.3"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(&mockCompute{ipBlocks: []compute.IpBlock{testIPBlock("block", ips, tc.used)}})
+			i.ServerSpec.IPBlockID = "block"
+
+			var got []string
+			for ip, err := i.nextReservedIP(context.Background()); err == nil; ip, err = i.nextReservedIP(context.Background()) {
+				got = append(got, ip)
+			}
+			if tc.release != "" {
+				i.reservedIPs.release(tc.release)
+				if ip, err := i.nextReservedIP(context.Background()); err == nil {
+					got = append(got, ip)
+				}
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("nextReservedIP handed out %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddPublicNIC(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		rules     []FirewallRule
+		secondary []string
+		wantIPs   []string
+		wantRules int
+	}{
+		{"without firewall", nil, nil, []string{"192.0.2.1"}, 0},
+		{"with firewall", []FirewallRule{{Protocol: "TCP", PortStart: 22, SourceIP: "198.51.100.0/24"}}, nil, []string{"192.0.2.1"}, 1},
+		{"with secondary IPs", nil, []string{"192.0.2.9"}, []string{"192.0.2.1", "192.0.2.9"}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.ServerSpec.PublicLanID = 2
+			i.ServerSpec.FirewallRules = tc.rules
+			i.ServerSpec.SecondaryIPs = tc.secondary
+
+			serverData := compute.Server{Entities: &compute.ServerEntities{Nics: &compute.Nics{Items: &[]compute.Nic{}}}}
+			i.addPublicNIC(&serverData, "192.0.2.1")
+
+			nics := *serverData.Entities.Nics.Items
+			if len(nics) != 1 {
+				t.Fatalf("addPublicNIC added %d NICs", len(nics))
+			}
+			nic := nics[0]
+			if ips := *nic.Properties.Ips; !slices.Equal(ips, tc.wantIPs) {
+				t.Errorf("public NIC has the IPs %v, want %v", ips, tc.wantIPs)
+			}
+			if active := *nic.Properties.FirewallActive; active != (tc.wantRules > 0) {
+				t.Errorf("public NIC has the firewall active %t", active)
+			}
+			rules := 0
+			if nic.Entities != nil && nic.Entities.Firewallrules != nil {
+				rules = len(*nic.Entities.Firewallrules.Items)
+			}
+			if rules != tc.wantRules {
+				t.Errorf("public NIC has %d firewall rules, want %d", rules, tc.wantRules)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// The bundled SDK does not model the IPv6 properties of LANs and NICs yet, so
+// they are read and written through rawRequest.
+
+type ipv6LanProperties struct {
+	Ipv6CidrBlock *string `json:"ipv6CidrBlock"`
+}
+
+type ipv6NicProperties struct {
+	Ipv6Ips *[]string `json:"ipv6Ips,omitempty"`
+}
+
+// ensureLanIPv6 enables IPv6 on the configured LAN of each datacenter if it
+// is not yet.
+func (i *InstanceGroup) ensureLanIPv6(ctx context.Context) error {
+	for _, dc := range i.datacenters() {
+		if err := i.ensureDatacenterLanIPv6(ctx, dc.ID, i.lanID(dc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureDatacenterLanIPv6 enables IPv6 on a LAN, using ipv6_cidr_block or
+// letting IONOS assign one. NICs created in an IPv6 enabled LAN get an IPv6
+// block and address assigned automatically.
+func (i *InstanceGroup) ensureDatacenterLanIPv6(ctx context.Context, datacenterID string, lanID int32) error {
+	path := fmt.Sprintf("/datacenters/%s/lans/%d", datacenterID, lanID)
+
+	var lan struct {
+		Properties ipv6LanProperties `json:"properties"`
+	}
+	if _, err := i.rawRequest(ctx, http.MethodGet, path, nil, &lan); err != nil {
+		return fmt.Errorf("failed to get LAN %d: %w", lanID, err)
+	}
+	if lan.Properties.Ipv6CidrBlock != nil {
+		return nil
+	}
+
+	cidr := "AUTO"
+	if i.ServerSpec.IPv6CidrBlock != "" {
+		cidr = i.ServerSpec.IPv6CidrBlock
+	}
+	if i.dryRun("would enable IPv6 on LAN", "datacenter", datacenterID, "lan", lanID, "cidr", cidr) {
+		return nil
+	}
+	apiResponse, err := i.rawRequest(ctx, http.MethodPatch, path, ipv6LanProperties{Ipv6CidrBlock: &cidr}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enable IPv6 on LAN %d: %w", lanID, err)
+	}
+	if location := apiResponse.Header.Get("Location"); location != "" {
+		if _, err := i.api.WaitForRequest(ctx, location); err != nil {
+			return fmt.Errorf("failed to enable IPv6 on LAN %d: %w", lanID, err)
+		}
+	}
+	i.log.Info("Enabled IPv6 on LAN", "datacenter", datacenterID, "lan", lanID, "cidr", cidr)
+	return nil
+}
+
+// nicIPv6 returns the first IPv6 address of a NIC of a server.
+func (i *InstanceGroup) nicIPv6(ctx context.Context, datacenterID, serverID string, nic compute.Nic) (string, error) {
+	path := fmt.Sprintf("/datacenters/%s/servers/%s/nics/%s", datacenterID, serverID, *nic.Id)
+
+	var result struct {
+		Properties ipv6NicProperties `json:"properties"`
+	}
+	if _, err := i.rawRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return "", fmt.Errorf("failed to get NIC %s: %w", *nic.Id, err)
+	}
+	if result.Properties.Ipv6Ips == nil || len(*result.Properties.Ipv6Ips) == 0 {
+		return "", fmt.Errorf("server NIC has no IPv6 address")
+	}
+	return (*result.Properties.Ipv6Ips)[0], nil
+}
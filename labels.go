@@ -0,0 +1,160 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// ErrNotGroupInstance is returned when asked to delete a server that does
+// not belong to the instance group.
+var ErrNotGroupInstance = errors.New("server does not belong to the instance group")
+
+const (
+	labelGroup   = "fleeting-group"
+	labelVersion = "fleeting-plugin-version"
+	labelSource  = "fleeting-source"
+	labelStandby = "fleeting-standby"
+	labelStopped = "fleeting-stopped"
+)
+
+// groupLabel is the value of the group label, the group name if configured
+// or the server name prefix otherwise.
+func (i *InstanceGroup) groupLabel() string {
+	if i.Name != "" {
+		return labelValue(i.Name)
+	}
+	return labelValue(i.ServerSpec.Name)
+}
+
+// labelValue replaces characters that are not allowed in label values.
+func labelValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, value)
+}
+
+// labelServer stamps a server with the group identity, the plugin version and
+// what created it, and its volumes with the group identity, so the volume
+// sweep can tell them apart from those of groups sharing the name prefix.
+func (i *InstanceGroup) labelServer(ctx context.Context, datacenterID string, server compute.Server, source string) error {
+	id := *server.Id
+	labels := map[string]string{
+		labelGroup:   i.groupLabel(),
+		labelVersion: labelValue(Version.String()),
+		labelSource:  labelValue(source),
+	}
+
+	var err error
+	for key, value := range labels {
+		_, _, err2 := withRetry(ctx, i, "ServersLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+			return i.api.AddServerLabel(ctx, datacenterID, id, key, value)
+		})
+		err = errors.Join(err, err2)
+	}
+
+	if server.Entities == nil || server.Entities.Volumes == nil || server.Entities.Volumes.Items == nil {
+		return err
+	}
+	for _, volume := range *server.Entities.Volumes.Items {
+		if volume.Id == nil {
+			continue
+		}
+		_, _, err2 := withRetry(ctx, i, "VolumesLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+			return i.api.AddVolumeLabel(ctx, datacenterID, *volume.Id, labelGroup, i.groupLabel())
+		})
+		err = errors.Join(err, err2)
+	}
+	return err
+}
+
+// serverGroups returns the group label of all labeled servers, keyed by
+// server ID.
+func (i *InstanceGroup) serverGroups(ctx context.Context) (map[string]string, error) {
+	return i.serverLabel(ctx, labelGroup)
+}
+
+// serverLabel returns the value of a label on all servers that have it, keyed
+// by server ID.
+func (i *InstanceGroup) serverLabel(ctx context.Context, key string) (map[string]string, error) {
+	return i.resourceLabel(ctx, "server", key)
+}
+
+// resourceLabel returns the value of a label on all resources of the given
+// type that have it, keyed by resource ID.
+func (i *InstanceGroup) resourceLabel(ctx context.Context, resourceType, key string) (map[string]string, error) {
+	labels, _, err := withRetry(ctx, i, "LabelsGet", func(ctx context.Context) (compute.Labels, *shared.APIResponse, error) {
+		return i.api.ListLabels(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]string)
+	if labels.Items == nil {
+		return groups, nil
+	}
+	for _, label := range *labels.Items {
+		props := label.Properties
+		if props == nil || props.Key == nil || *props.Key != key || props.ResourceId == nil {
+			continue
+		}
+		if props.ResourceType != nil && *props.ResourceType != resourceType {
+			continue
+		}
+		groups[*props.ResourceId] = *props.Value
+	}
+	return groups, nil
+}
+
+// isGroupServer reports whether a server belongs to the group. Labeled
+// servers are matched by their group label, so groups whose names share a
+// prefix are kept apart. Servers without a group label, e.g. created by an
+// older version of the plugin, fall back to the name prefix.
+func (i *InstanceGroup) isGroupServer(server compute.Server, groups map[string]string) bool {
+	if group, ok := groups[*server.Id]; ok {
+		return group == i.groupLabel()
+	}
+	return i.isGroupMember(*server.Properties.Name)
+}
+
+// isGroupVolume reports whether a volume belongs to the group, by its group
+// label like isGroupServer or, for volumes without one, by the name prefix.
+func (i *InstanceGroup) isGroupVolume(volume compute.Volume, groups map[string]string) bool {
+	if volume.Id == nil {
+		return false
+	}
+	if group, ok := groups[*volume.Id]; ok {
+		return group == i.groupLabel()
+	}
+	return volume.Properties != nil && volume.Properties.Name != nil && i.isGroupMember(*volume.Properties.Name)
+}
+
+// checkOwnership verifies that a server belongs to the group and is not
+// protected before it is deleted or stopped, so a wrong UUID cannot take down
+// an unrelated server. It returns the server.
+func (i *InstanceGroup) checkOwnership(ctx context.Context, datacenterID string, id string, groups map[string]string) (compute.Server, error) {
+	server, _, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+		return i.api.GetServer(ctx, datacenterID, id, 0)
+	})
+	if err != nil {
+		return server, fmt.Errorf("checking ownership of %s: %w", id, err)
+	}
+	if i.isProtected(server) {
+		return server, fmt.Errorf("refusing to delete %s: %w", id, ErrProtectedInstance)
+	}
+	if server.Properties == nil || server.Properties.Name == nil || !i.isGroupServer(server, groups) {
+		return server, fmt.Errorf("refusing to delete %s: %w %q", id, ErrNotGroupInstance, i.groupLabel())
+	}
+	return server, nil
+}
@@ -0,0 +1,78 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// ensureLans creates the private LAN in the datacenters that have no lan_id
+// configured and remembers its ID for lanID.
+func (i *InstanceGroup) ensureLans(ctx context.Context) error {
+	for _, dc := range i.datacenters() {
+		if dc.ID == "" || i.lanID(dc) != 0 {
+			continue
+		}
+		lanID, err := i.EnsureLan(ctx, dc.ID)
+		if err != nil {
+			return err
+		}
+		if i.autoLans == nil {
+			i.autoLans = make(map[string]int32)
+		}
+		i.autoLans[dc.ID] = lanID
+	}
+	return nil
+}
+
+// EnsureLan returns the ID of the private LAN named after the group,
+// creating it if it does not exist yet.
+func (i *InstanceGroup) EnsureLan(ctx context.Context, datacenterID string) (int32, error) {
+	ctx = withOperation(ctx, "EnsureLan")
+	name := i.groupLabel()
+	lans, _, err := withRetry(ctx, i, "LansGet", func(ctx context.Context) (compute.Lans, *shared.APIResponse, error) {
+		return i.api.ListLans(ctx, datacenterID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("listing LANs: %w", err)
+	}
+	if lans.Items != nil {
+		for _, lan := range *lans.Items {
+			if lan.Properties == nil || lan.Properties.Name == nil || *lan.Properties.Name != name || lan.Id == nil {
+				continue
+			}
+			if lan.Properties.Public != nil && *lan.Properties.Public {
+				return 0, fmt.Errorf("LAN %s named %q is public, configure lan_id", *lan.Id, name)
+			}
+			return parseLanID(*lan.Id)
+		}
+	}
+
+	lan := compute.LanPost{Properties: &compute.LanPropertiesPost{Name: &name, Public: BoolPtr(false)}}
+	if i.dryRun("would create private LAN", "datacenter", datacenterID, "name", name) {
+		return 0, nil
+	}
+	created, apiResponse, err := withRetry(ctx, i, "LansPost", func(ctx context.Context) (compute.LanPost, *shared.APIResponse, error) {
+		return i.api.CreateLan(ctx, datacenterID, lan)
+	})
+	i.audit(ctx, auditEvent{Action: "create", ResourceType: "lan", ResourceID: shared.ToValueDefault(created.Id), Name: name, Datacenter: datacenterID}, apiResponse, err)
+	if err != nil {
+		return 0, fmt.Errorf("creating LAN: %w", err)
+	}
+	if err := i.waitForLocation(ctx, apiResponse); err != nil {
+		return 0, fmt.Errorf("creating LAN: %w", err)
+	}
+	i.log.Info("Created private LAN", "datacenter", datacenterID, "id", *created.Id, "name", name)
+	return parseLanID(*created.Id)
+}
+
+func parseLanID(id string) (int32, error) {
+	n, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LAN id %q: %w", id, err)
+	}
+	return int32(n), nil
+}
@@ -0,0 +1,84 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// stateStandby is the state warm pool instances are listed with.
+const stateStandby = "STANDBY"
+
+// InstanceInfo describes a group instance, e.g. for the list command.
+type InstanceInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// State is the state of the server, AVAILABLE, BUSY or INACTIVE, or
+	// STOPPED for instances Decrease powered off and STANDBY for warm pool
+	// instances.
+	State      string    `json:"state"`
+	VMState    string    `json:"vm_state,omitempty"`
+	InternalIP string    `json:"internal_ip,omitempty"`
+	ExternalIP string    `json:"external_ip,omitempty"`
+	Datacenter string    `json:"datacenter"`
+	Zone       string    `json:"zone,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+// ListInstances returns the group instances with their addresses, sorted by
+// name.
+func (i *InstanceGroup) ListInstances(ctx context.Context) ([]InstanceInfo, error) {
+	servers, err := i.listGroupServersAt(ctx, i.Depth.listWithNICs())
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]InstanceInfo, 0, len(servers))
+	for _, server := range servers {
+		info := InstanceInfo{
+			ID:    *server.Id,
+			State: *server.Metadata.State,
+			Zone:  serverZone(server),
+		}
+		if server.Properties != nil {
+			info.Name = stringValue(server.Properties.Name)
+			info.VMState = stringValue(server.Properties.VmState)
+		}
+		if server.Metadata.CreatedDate != nil {
+			info.Created = server.Metadata.CreatedDate.Time
+		}
+		if rec, ok := i.registry.get(info.ID); ok {
+			info.Datacenter = rec.DatacenterID
+			if rec.Stopped {
+				info.State = stateStopped
+			} else if rec.Standby {
+				info.State = stateStandby
+			}
+		}
+		info.InternalIP, info.ExternalIP = i.instanceIPs(server, info.Datacenter)
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b InstanceInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos, nil
+}
+
+// instanceIPs returns the first IP of the NIC in the private LAN and, if
+// the server has one, of the NIC in public_lan_id.
+func (i *InstanceGroup) instanceIPs(server compute.Server, datacenterID string) (internal, external string) {
+	if server.Entities == nil || server.Entities.Nics == nil || server.Entities.Nics.Items == nil {
+		return "", ""
+	}
+	nics := *server.Entities.Nics.Items
+	if nic, ok := i.findPrivateNIC(nics, datacenterID); ok {
+		internal, _ = nicIP(nic)
+	}
+	for _, nic := range nics {
+		if i.ServerSpec.PublicLanID != 0 && nic.Properties != nil && nic.Properties.Lan != nil && *nic.Properties.Lan == i.ServerSpec.PublicLanID {
+			external, _ = nicIP(nic)
+		}
+	}
+	return internal, external
+}
@@ -0,0 +1,137 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "fleeting_ionos"
+
+// metrics holds the Prometheus collectors of an instance group. They are
+// registered on a registry of their own, so several groups in one process
+// do not collide.
+type metrics struct {
+	registry *prometheus.Registry
+
+	instances         *prometheus.GaugeVec
+	estimatedHourly   prometheus.Gauge
+	estimatedMonthly  prometheus.Gauge
+	instanceHourlyFee prometheus.Gauge
+	circuitOpen       prometheus.Gauge
+	requestDuration   *prometheus.HistogramVec
+	apiErrors         *prometheus.CounterVec
+	instancesReplaced prometheus.Counter
+}
+
+func newMetrics(group string) *metrics {
+	labels := prometheus.Labels{"group": group}
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		instances: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "instances",
+			Help:        "Number of group instances by state, as seen by the last Update.",
+			ConstLabels: labels,
+		}, []string{"state"}),
+		estimatedHourly: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "estimated_hourly_cost",
+			Help:        "Estimated hourly cost of the group instances, based on the configured pricing.",
+			ConstLabels: labels,
+		}),
+		estimatedMonthly: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "estimated_monthly_cost",
+			Help:        "Estimated monthly cost of the group instances if they kept running.",
+			ConstLabels: labels,
+		}),
+		instanceHourlyFee: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "instance_hourly_cost",
+			Help:        "Estimated hourly cost of a single instance.",
+			ConstLabels: labels,
+		}),
+		circuitOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "api_circuit_open",
+			Help:        "Whether the IONOS API circuit breaker is open (1) or closed (0).",
+			ConstLabels: labels,
+		}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Name:        "server_request_duration_seconds",
+			Help:        "Time from creating or deleting a server until the IONOS request is done, by action and result.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(5, 2, 10),
+		}, []string{"action", "result"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "api_errors_total",
+			Help:        "Failed IONOS API calls by HTTP status code, 0 if there was no response, including retried attempts.",
+			ConstLabels: labels,
+		}, []string{"status"}),
+		instancesReplaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "instances_replaced_total",
+			Help:        "Instances deleted after failing replace_after consecutive heartbeats.",
+			ConstLabels: labels,
+		}),
+	}
+	m.registry.MustRegister(m.instances, m.estimatedHourly, m.estimatedMonthly, m.instanceHourlyFee, m.circuitOpen, m.requestDuration, m.apiErrors, m.instancesReplaced)
+	return m
+}
+
+// recordAPIError counts a failed API call by its status code.
+func (i *InstanceGroup) recordAPIError(apiResponse *shared.APIResponse) {
+	if i.metrics == nil {
+		return
+	}
+	status := 0
+	if apiResponse != nil && apiResponse.Response != nil {
+		status = apiResponse.StatusCode
+	}
+	i.metrics.apiErrors.WithLabelValues(strconv.Itoa(status)).Inc()
+}
+
+// startMetricsServer serves the metrics and the health endpoint on
+// metrics_address, if configured.
+// A failing listener is logged and does not fail Init, e.g. when the CLI
+// runs next to the plugin with the same config.
+func (i *InstanceGroup) startMetricsServer() {
+	if i.MetricsAddress == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", i.MetricsAddress)
+	if err != nil {
+		i.log.Error("Failed to start metrics server", "address", i.MetricsAddress, "err", err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(i.metrics.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", i.healthHandler)
+	i.metricsServer = &http.Server{Handler: mux}
+
+	go func() {
+		if err := i.metricsServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			i.log.Error("Metrics server failed", "err", err)
+		}
+	}()
+	i.log.Info("Serving metrics", "address", listener.Addr().String())
+}
+
+func (i *InstanceGroup) stopMetricsServer(ctx context.Context) error {
+	if i.metricsServer == nil {
+		return nil
+	}
+	return i.metricsServer.Shutdown(ctx)
+}
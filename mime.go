@@ -0,0 +1,63 @@
+package ionos
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// cloudConfigMergeType makes cloud-init append to lists and merge maps of
+// earlier cloud-config parts instead of replacing them, so parts can e.g.
+// each add write_files.
+const cloudConfigMergeType = "list(append)+dict(no_replace,recurse_list)+str()"
+
+// userDataContentTypes maps the first line of a user data part to its MIME
+// type as understood by cloud-init.
+var userDataContentTypes = []struct {
+	prefix      string
+	contentType string
+}{
+	{"#cloud-config", "text/cloud-config"},
+	{"#cloud-boothook", "text/cloud-boothook"},
+	{"#include", "text/x-include-url"},
+	{"#!", "text/x-shellscript"},
+}
+
+func userDataContentType(part string) string {
+	for _, t := range userDataContentTypes {
+		if strings.HasPrefix(part, t.prefix) {
+			return t.contentType
+		}
+	}
+	return "text/plain"
+}
+
+// multipartUserData combines user data parts into a cloud-init multi-part
+// MIME archive. cloud-init processes the parts in order.
+func multipartUserData(parts []string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for n, part := range parts {
+		header := textproto.MIMEHeader{}
+		contentType := userDataContentType(part)
+		header.Set("Content-Type", contentType+`; charset="utf-8"`)
+		header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="part-%03d"`, n))
+		if contentType == "text/cloud-config" {
+			header.Set("Merge-Type", cloudConfigMergeType)
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := pw.Write([]byte(part)); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\nMIME-Version: 1.0\n\n", w.Boundary()) + body.String(), nil
+}
@@ -0,0 +1,63 @@
+package ionos
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestMultipartUserData(t *testing.T) {
+	parts := []struct {
+		content     string
+		contentType string
+	}{
+		{"#cloud-config\npackages: [git]\n", "text/cloud-config"},
+		{"#cloud-boothook\necho early\n", "text/cloud-boothook"},
+		{"#include\nhttps://example.com/user-data\n", "text/x-include-url"},
+		{"#!/bin/sh\necho hello\n", "text/x-shellscript"},
+		{"plain text", "text/plain"},
+	}
+	var contents []string
+	for _, part := range parts {
+		contents = append(contents, part.content)
+	}
+	archive, err := multipartUserData(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("archive content type %q, %v", mediaType, err)
+	}
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for n, want := range parts {
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", n, err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if contentType != want.contentType {
+			t.Errorf("part %d has content type %q, want %q", n, contentType, want.contentType)
+		}
+		mergeType := part.Header.Get("Merge-Type")
+		if (contentType == "text/cloud-config") != (mergeType == cloudConfigMergeType) {
+			t.Errorf("part %d has merge type %q", n, mergeType)
+		}
+		body, _ := io.ReadAll(part)
+		if string(body) != want.content {
+			t.Errorf("part %d is %q, want %q", n, body, want.content)
+		}
+	}
+	if _, err := r.NextPart(); err != io.EOF {
+		t.Errorf("archive has more than %d parts", len(parts))
+	}
+}
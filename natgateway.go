@@ -0,0 +1,164 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// NATGatewayConfig provisions a NAT gateway for the private LAN, so
+// instances without a public NIC can reach the internet.
+type NATGatewayConfig struct {
+	// Name of the gateway, defaults to the group label with a -nat suffix.
+	// An existing gateway of that name is reused.
+	Name string `json:"name"`
+	// PublicIPs are reserved IPs of the datacenter location, the first one
+	// is used for the SNAT rule. The gateway is only provisioned if set.
+	PublicIPs []string `json:"public_ips"`
+	// GatewayIPs are the addresses of the gateway in the LAN in CIDR
+	// notation, IONOS assigns one if empty.
+	GatewayIPs []string `json:"gateway_ips"`
+	// SourceSubnet is the subnet of the LAN in CIDR notation whose traffic
+	// is translated.
+	SourceSubnet string `json:"source_subnet"`
+}
+
+func (c NATGatewayConfig) enabled() bool {
+	return len(c.PublicIPs) > 0
+}
+
+func (i *InstanceGroup) natGatewayName() string {
+	if i.NATGateway.Name != "" {
+		return i.NATGateway.Name
+	}
+	return i.groupLabel() + "-nat"
+}
+
+func (i *InstanceGroup) validateNATGateway() error {
+	if !i.NATGateway.enabled() {
+		return nil
+	}
+	if i.NATGateway.SourceSubnet == "" {
+		return fmt.Errorf("nat_gateway requires source_subnet")
+	}
+	return nil
+}
+
+// ensureNATGateways provisions the NAT gateway in each datacenter if
+// nat_gateway is configured.
+func (i *InstanceGroup) ensureNATGateways(ctx context.Context) error {
+	if !i.NATGateway.enabled() {
+		return nil
+	}
+	if err := i.validateNATGateway(); err != nil {
+		return err
+	}
+	for _, dc := range i.datacenters() {
+		if _, err := i.EnsureNATGateway(ctx, dc.ID, i.lanID(dc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureNATGateway creates a NAT gateway connected to the LAN with an SNAT
+// rule for source_subnet, or reuses the existing gateway of the same name.
+// It returns the ID of the gateway.
+func (i *InstanceGroup) EnsureNATGateway(ctx context.Context, datacenterID string, lanID int32) (string, error) {
+	ctx = withOperation(ctx, "EnsureNATGateway")
+	name := i.natGatewayName()
+	gateways, _, err := withRetry(ctx, i, "NatgatewaysGet", func(ctx context.Context) (compute.NatGateways, *shared.APIResponse, error) {
+		return i.api.ListNATGateways(ctx, datacenterID)
+	})
+	if err != nil {
+		return "", fmt.Errorf("listing NAT gateways: %w", err)
+	}
+	if gateways.Items != nil {
+		for _, gateway := range *gateways.Items {
+			if gateway.Properties == nil || gateway.Properties.Name == nil || *gateway.Properties.Name != name {
+				continue
+			}
+			if !natGatewayHasLan(gateway, lanID) {
+				i.log.Warn("Existing NAT gateway is not connected to the LAN", "gateway", *gateway.Id, "datacenter", datacenterID, "lan", lanID)
+			}
+			return *gateway.Id, nil
+		}
+	}
+
+	lan := compute.NatGatewayLanProperties{Id: &lanID}
+	if len(i.NATGateway.GatewayIPs) > 0 {
+		lan.GatewayIps = &i.NATGateway.GatewayIPs
+	}
+	gateway := compute.NatGateway{
+		Properties: &compute.NatGatewayProperties{
+			Name:      &name,
+			PublicIps: &i.NATGateway.PublicIPs,
+			Lans:      &[]compute.NatGatewayLanProperties{lan},
+		},
+	}
+	if i.dryRun("would create NAT gateway", "datacenter", datacenterID, "payload", payload(gateway)) {
+		return "", nil
+	}
+	created, apiResponse, err := withRetry(ctx, i, "NatgatewaysPost", func(ctx context.Context) (compute.NatGateway, *shared.APIResponse, error) {
+		return i.api.CreateNATGateway(ctx, datacenterID, gateway)
+	})
+	i.audit(ctx, auditEvent{Action: "create", ResourceType: "nat_gateway", ResourceID: shared.ToValueDefault(created.Id), Name: name, Datacenter: datacenterID}, apiResponse, err)
+	if err != nil {
+		return "", fmt.Errorf("creating NAT gateway: %w", err)
+	}
+	if err := i.waitForLocation(ctx, apiResponse); err != nil {
+		return "", fmt.Errorf("creating NAT gateway: %w", err)
+	}
+
+	snat := compute.SNAT
+	all := compute.ALL
+	rule := compute.NatGatewayRule{
+		Properties: &compute.NatGatewayRuleProperties{
+			Name:         StrPtr(name + "-snat"),
+			Type:         &snat,
+			Protocol:     &all,
+			SourceSubnet: &i.NATGateway.SourceSubnet,
+			PublicIp:     &i.NATGateway.PublicIPs[0],
+		},
+	}
+	_, apiResponse, err = withRetry(ctx, i, "NatgatewaysRulesPost", func(ctx context.Context) (compute.NatGatewayRule, *shared.APIResponse, error) {
+		return i.api.CreateNATGatewayRule(ctx, datacenterID, *created.Id, rule)
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating NAT gateway rule: %w", err)
+	}
+	if err := i.waitForLocation(ctx, apiResponse); err != nil {
+		return "", fmt.Errorf("creating NAT gateway rule: %w", err)
+	}
+
+	i.log.Info("Created NAT gateway", "id", *created.Id, "datacenter", datacenterID, "lan", lanID)
+	return *created.Id, nil
+}
+
+func natGatewayHasLan(gateway compute.NatGateway, lanID int32) bool {
+	if gateway.Properties.Lans == nil {
+		return false
+	}
+	for _, lan := range *gateway.Properties.Lans {
+		if lan.Id != nil && *lan.Id == lanID {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForLocation waits for the asynchronous request of an API response to
+// finish.
+func (i *InstanceGroup) waitForLocation(ctx context.Context, apiResponse *shared.APIResponse) error {
+	if apiResponse == nil || apiResponse.Response == nil {
+		return nil
+	}
+	location := apiResponse.Header.Get("Location")
+	if location == "" {
+		return nil
+	}
+	_, err := i.api.WaitForRequest(ctx, location)
+	return err
+}
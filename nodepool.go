@@ -0,0 +1,200 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// KubernetesConfig scales an IONOS Managed Kubernetes node pool instead of
+// standalone servers. Increase and Decrease change the node count of the
+// pool, the nodes are exposed as instances of the group.
+type KubernetesConfig struct {
+	ClusterID  string `json:"cluster_id"`
+	NodePoolID string `json:"node_pool_id"`
+}
+
+func (c KubernetesConfig) enabled() bool {
+	return c.ClusterID != "" || c.NodePoolID != ""
+}
+
+// nodePoolPutProperties are the node pool properties a PUT accepts, the
+// others are read-only.
+var nodePoolPutProperties = []string{
+	"name", "nodeCount", "k8sVersion", "maintenanceWindow", "autoScaling",
+	"lans", "labels", "annotations", "publicIps",
+}
+
+type nodePool struct {
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+}
+
+type nodePoolNodes struct {
+	Items []struct {
+		ID         string `json:"id"`
+		Properties struct {
+			Name string `json:"name"`
+		} `json:"properties"`
+	} `json:"items"`
+}
+
+type nodePoolBackend struct {
+	i *InstanceGroup
+}
+
+func (b nodePoolBackend) path(suffix string) string {
+	return "/k8s/" + b.i.Kubernetes.ClusterID + "/nodepools/" + b.i.Kubernetes.NodePoolID + suffix
+}
+
+func (b nodePoolBackend) pool(ctx context.Context) (nodePool, error) {
+	pool, _, err := withRetry(ctx, b.i, "K8sNodepoolsFindById", func(ctx context.Context) (nodePool, *shared.APIResponse, error) {
+		var pool nodePool
+		apiResponse, err := b.i.rawRequest(ctx, http.MethodGet, b.path(""), nil, &pool)
+		return pool, apiResponse, err
+	})
+	if err != nil {
+		return nodePool{}, fmt.Errorf("getting node pool %s: %w", b.i.Kubernetes.NodePoolID, err)
+	}
+	return pool, nil
+}
+
+// init checks that both IDs are set and takes the datacenter from the node
+// pool if datacenter_id is not configured.
+func (b nodePoolBackend) init(ctx context.Context) error {
+	if b.i.Kubernetes.ClusterID == "" || b.i.Kubernetes.NodePoolID == "" {
+		return fmt.Errorf("kubernetes requires cluster_id and node_pool_id")
+	}
+	pool, err := b.pool(ctx)
+	if err != nil {
+		return err
+	}
+	if autoScaling, ok := pool.Properties["autoScaling"].(map[string]any); ok && intProperty(autoScaling, "maxNodeCount") > 0 {
+		b.i.log.Warn("Node pool autoscaling is enabled and competes with the runner autoscaler", "node_pool", b.i.Kubernetes.NodePoolID)
+	}
+	if b.i.DatacenterId != "" || len(b.i.Datacenters) > 0 {
+		return nil
+	}
+	id, _ := pool.Properties["datacenterId"].(string)
+	if id == "" {
+		return fmt.Errorf("node pool %s has no datacenter", b.i.Kubernetes.NodePoolID)
+	}
+	b.i.DatacenterId = id
+	return nil
+}
+
+// members matches the nodes of the pool to the datacenter servers backing
+// them, by ID or by name.
+func (b nodePoolBackend) members(ctx context.Context) (map[string]string, error) {
+	nodes, _, err := withRetry(ctx, b.i, "K8sNodepoolsNodesGet", func(ctx context.Context) (nodePoolNodes, *shared.APIResponse, error) {
+		var nodes nodePoolNodes
+		apiResponse, err := b.i.rawRequest(ctx, http.MethodGet, b.path("/nodes?depth=1"), nil, &nodes)
+		return nodes, apiResponse, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing node pool nodes: %w", err)
+	}
+
+	byName := make(map[string]string, len(nodes.Items))
+	byID := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		byID[node.ID] = true
+		if node.Properties.Name != "" {
+			byName[node.Properties.Name] = node.ID
+		}
+	}
+
+	members := make(map[string]string, len(nodes.Items))
+	for _, dc := range b.i.datacenters() {
+		err := b.i.forEachDatacenterServer(ctx, dc.ID, b.i.Depth.list(), func(server compute.Server) {
+			if byID[*server.Id] {
+				members[*server.Id] = *server.Id
+			} else if server.Properties != nil && server.Properties.Name != nil {
+				if nodeID, ok := byName[*server.Properties.Name]; ok {
+					members[*server.Id] = nodeID
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+func (b nodePoolBackend) resize(ctx context.Context, delta int) (int, int, error) {
+	pool, err := b.pool(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	current := intProperty(pool.Properties, "nodeCount")
+	target := max(current+delta, 1)
+	if autoScaling, ok := pool.Properties["autoScaling"].(map[string]any); ok {
+		if maxNodes := intProperty(autoScaling, "maxNodeCount"); maxNodes > 0 && target > maxNodes {
+			target = maxNodes
+		}
+	}
+	if target == current {
+		return current, target, nil
+	}
+
+	props := make(map[string]any, len(nodePoolPutProperties))
+	for _, key := range nodePoolPutProperties {
+		if value, ok := pool.Properties[key]; ok {
+			props[key] = value
+		}
+	}
+	props["nodeCount"] = target
+	if b.i.dryRun("would set node pool node count", "node_pool", b.i.Kubernetes.NodePoolID, "current", current, "target", target) {
+		return current, target, nil
+	}
+	_, err = withRetryNoResult(ctx, b.i, "K8sNodepoolsPut", func(ctx context.Context) (*shared.APIResponse, error) {
+		return b.i.rawRequest(ctx, http.MethodPut, b.path(""), map[string]any{"properties": props}, nil)
+	})
+	if err != nil {
+		return current, current, fmt.Errorf("updating node pool %s: %w", b.i.Kubernetes.NodePoolID, err)
+	}
+	b.i.log.Info("Changed node pool node count", "node_pool", b.i.Kubernetes.NodePoolID, "from", current, "to", target)
+	return current, target, nil
+}
+
+// remove lowers the node count first, as deleting a node of a pool that is
+// at its node count makes the pool replace it. The node count is raised again
+// for nodes that could not be deleted.
+func (b nodePoolBackend) remove(ctx context.Context, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if _, _, err := b.resize(ctx, -len(ids)); err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	var err error
+	for _, id := range ids {
+		if b.i.dryRun("would delete node", "id", id, "node_pool", b.i.Kubernetes.NodePoolID) {
+			removed = append(removed, id)
+			continue
+		}
+		_, err2 := withRetryNoResult(ctx, b.i, "K8sNodepoolsNodesDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return b.i.rawRequest(ctx, http.MethodDelete, b.path("/nodes/"+id), nil, nil)
+		})
+		if err2 != nil {
+			b.i.log.Error("Failed to delete node", "err", err2, "id", id)
+			err = errors.Join(err, err2)
+			continue
+		}
+		removed = append(removed, id)
+	}
+
+	if failed := len(ids) - len(removed); failed > 0 {
+		if _, _, err2 := b.resize(ctx, failed); err2 != nil {
+			err = errors.Join(err, err2)
+		}
+	}
+	return removed, err
+}
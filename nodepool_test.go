@@ -0,0 +1,83 @@
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeNodePool serves the node pool endpoints nodePoolBackend uses. Deleting
+// a node listed in failing returns 422.
+type fakeNodePool struct {
+	mu        sync.Mutex
+	nodeCount int
+	failing   []string
+	deleted   []string
+}
+
+func (f *fakeNodePool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(nodePool{ID: "pool", Properties: map[string]any{"nodeCount": f.nodeCount}})
+	case r.Method == http.MethodPut:
+		var body struct {
+			Properties struct {
+				NodeCount int `json:"nodeCount"`
+			} `json:"properties"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.nodeCount = body.Properties.NodeCount
+	case r.Method == http.MethodDelete:
+		id := r.PathValue("node")
+		if slices.Contains(f.failing, id) {
+			http.Error(w, "node is busy", http.StatusUnprocessableEntity)
+			return
+		}
+		f.deleted = append(f.deleted, id)
+	}
+}
+
+func TestNodePoolRemove(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       []string
+		failing   []string
+		removed   []string
+		nodeCount int
+	}{
+		{name: "all deleted", ids: []string{"a", "b"}, removed: []string{"a", "b"}, nodeCount: 3},
+		{name: "one failed", ids: []string{"a", "b"}, failing: []string{"b"}, removed: []string{"a"}, nodeCount: 4},
+		{name: "all failed", ids: []string{"a", "b"}, failing: []string{"a", "b"}, nodeCount: 5},
+		{name: "nothing to remove", nodeCount: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakeNodePool{nodeCount: 5, failing: tt.failing}
+			mux := http.NewServeMux()
+			mux.Handle("/k8s/cluster/nodepools/pool", pool)
+			mux.Handle("/k8s/cluster/nodepools/pool/nodes/{node}", pool)
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			i := newTestGroup(&mockCompute{config: testConfig(server.URL)})
+			i.Kubernetes = KubernetesConfig{ClusterID: "cluster", NodePoolID: "pool"}
+
+			removed, err := nodePoolBackend{i}.remove(context.Background(), tt.ids)
+			if (err != nil) != (len(tt.failing) > 0) {
+				t.Errorf("remove error %v, want an error for %v", err, tt.failing)
+			}
+			if !slices.Equal(removed, tt.removed) {
+				t.Errorf("remove removed %v, want %v", removed, tt.removed)
+			}
+			if pool.nodeCount != tt.nodeCount {
+				t.Errorf("node count %d, want %d", pool.nodeCount, tt.nodeCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package ionos
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+const (
+	imagePasswordLength   = 32
+	imagePasswordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// newImagePassword returns a random password made of letters and digits,
+// which the API accepts for every image.
+func newImagePassword() (string, error) {
+	password := make([]byte, imagePasswordLength)
+	max := big.NewInt(int64(len(imagePasswordAlphabet)))
+	for n := range password {
+		c, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generating image password: %w", err)
+		}
+		password[n] = imagePasswordAlphabet[c.Int64()]
+	}
+	return string(password), nil
+}
+
+// loadImagePasswordKey reads the PEM encoded RSA public key of
+// image_password_key.
+func loadImagePasswordKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading image_password_key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("image_password_key %s: no PEM data found", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("image_password_key %s: %w", path, err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("image_password_key %s: not an RSA public key", path)
+	}
+	return rsaKey, nil
+}
+
+// instanceImagePassword generates the image password of a single instance
+// for random_image_password. With image_password_key the password is logged
+// encrypted with RSA-OAEP (SHA-256) and base64 encoded, so it can be
+// recovered for console access; otherwise it is discarded.
+func (i *InstanceGroup) instanceImagePassword(serverName string) (string, error) {
+	password, err := newImagePassword()
+	if err != nil {
+		return "", err
+	}
+	secrets.add(password)
+	if i.ServerSpec.ImagePasswordKey == "" {
+		return password, nil
+	}
+
+	key, err := loadImagePasswordKey(i.ServerSpec.ImagePasswordKey)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, []byte(password), []byte(serverName))
+	if err != nil {
+		return "", fmt.Errorf("encrypting image password: %w", err)
+	}
+	i.log.Info("Generated image password", "name", serverName, "encrypted_password", base64.StdEncoding.EncodeToString(encrypted))
+	return password, nil
+}
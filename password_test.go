@@ -0,0 +1,97 @@
+package ionos
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestRandomImagePassword(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		keyFile string
+		logged  int
+	}{
+		{"discarded", "", 0},
+		{"encrypted", keyFile, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{}
+			i := newTestGroup(api)
+			var out bytes.Buffer
+			i.log = hclog.New(&hclog.LoggerOptions{Output: &out, JSONFormat: true})
+			i.ServerSpec.Type = "ENTERPRISE"
+			i.ServerSpec.RandomImagePassword = true
+			i.ServerSpec.ImagePasswordKey = tc.keyFile
+
+			names := []string{"runner-1-aaaa", "runner-2-bbbb"}
+			for n, name := range names {
+				if _, err := i.createServerVariant(context.Background(), resolvedSpec{Image: "image"}, DatacenterConfig{ID: "dc1"}, "", name, n+1, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			passwords := make(map[string]string)
+			for _, server := range api.posted {
+				password := *(*server.Entities.Volumes.Items)[0].Properties.ImagePassword
+				if len(password) != imagePasswordLength || strings.Trim(password, imagePasswordAlphabet) != "" {
+					t.Errorf("image password %q is not %d letters and digits", password, imagePasswordLength)
+				}
+				passwords[*server.Properties.Name] = password
+			}
+			if passwords[names[0]] == passwords[names[1]] {
+				t.Error("instances got the same image password")
+			}
+
+			// With a key, the password of each instance can be recovered
+			// from the log.
+			logged := 0
+			for line := range strings.Lines(out.String()) {
+				var entry struct {
+					Name      string `json:"name"`
+					Encrypted string `json:"encrypted_password"`
+				}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Encrypted == "" {
+					continue
+				}
+				logged++
+				ciphertext, _ := base64.StdEncoding.DecodeString(entry.Encrypted)
+				password, err := rsa.DecryptOAEP(sha256.New(), nil, key, ciphertext, []byte(entry.Name))
+				if err != nil || string(password) != passwords[entry.Name] {
+					t.Errorf("logged password of %s decrypts to %q, %v", entry.Name, password, err)
+				}
+			}
+			if logged != tc.logged {
+				t.Errorf("logged %d encrypted passwords, want %d", logged, tc.logged)
+			}
+			if strings.Contains(out.String(), passwords[names[0]]) {
+				t.Error("log contains the plain image password")
+			}
+		})
+	}
+}
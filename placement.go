@@ -0,0 +1,66 @@
+package ionos
+
+import (
+	"context"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// placement is a datacenter and availability zone to create a server in.
+type placement struct {
+	dc   DatacenterConfig
+	zone string
+}
+
+// placements returns where to try to create a server: the picked datacenter
+// and zone first, followed by the other availability_zones of that
+// datacenter and then the other datacenters.
+func (i *InstanceGroup) placements(dc DatacenterConfig, zone string) []placement {
+	zones := []string{zone}
+	for _, z := range i.ServerSpec.AvailabilityZones {
+		if z != zone {
+			zones = append(zones, z)
+		}
+	}
+
+	var placements []placement
+	for _, z := range zones {
+		placements = append(placements, placement{dc: dc, zone: z})
+	}
+	for _, other := range i.datacenters() {
+		if other.ID == dc.ID {
+			continue
+		}
+		for _, z := range zones {
+			placements = append(placements, placement{dc: other, zone: z})
+		}
+	}
+	return placements
+}
+
+// createServerPlaced creates a server in dc, in the next availability zone
+// if availability_zones is configured. When the API reports no capacity
+// there, the same server is created in the next placement before giving up.
+// It returns the datacenter the server was created in, or the last one
+// tried.
+func (i *InstanceGroup) createServerPlaced(ctx context.Context, spec resolvedSpec, dc DatacenterConfig, serverName string, index int) (compute.Server, DatacenterConfig, error) {
+	placements := i.placements(dc, i.nextZone())
+	var server compute.Server
+	var err error
+	for n, p := range placements {
+		server, err = i.createServer(ctx, spec, p.dc, p.zone, serverName, index)
+		if err == nil {
+			if n > 0 {
+				i.log.Info("Instance placed after capacity errors", "name", serverName, "datacenter", p.dc.ID, "zone", p.zone, "attempts", n+1)
+			}
+			return server, p.dc, nil
+		}
+		if !isCapacityError(err) || n == len(placements)-1 || ctx.Err() != nil {
+			return server, p.dc, err
+		}
+		next := placements[n+1]
+		i.log.Warn("No capacity, trying next placement", "name", serverName, "datacenter", p.dc.ID, "zone", p.zone,
+			"next_datacenter", next.dc.ID, "next_zone", next.zone, "err", err)
+	}
+	return server, dc, err
+}
@@ -0,0 +1,90 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlacements(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		datacenters []DatacenterConfig
+		zones       []string
+		dc          string
+		zone        string
+		want        []string
+	}{
+		{"single datacenter without zones", nil, nil, "dc1", "", []string{"dc1/"}},
+		{"zones", nil, []string{"ZONE_1", "ZONE_2"}, "dc1", "ZONE_2", []string{"dc1/ZONE_2", "dc1/ZONE_1"}},
+		{
+			"datacenters and zones",
+			[]DatacenterConfig{{ID: "dc1"}, {ID: "dc2"}, {ID: "dc3"}},
+			[]string{"ZONE_1", "ZONE_2"},
+			"dc2", "ZONE_2",
+			[]string{"dc2/ZONE_2", "dc2/ZONE_1", "dc1/ZONE_2", "dc1/ZONE_1", "dc3/ZONE_2", "dc3/ZONE_1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			if tc.datacenters != nil {
+				i.DatacenterId = ""
+				i.Datacenters = tc.datacenters
+			}
+			i.ServerSpec.AvailabilityZones = tc.zones
+
+			var got []string
+			for _, p := range i.placements(DatacenterConfig{ID: tc.dc}, tc.zone) {
+				got = append(got, fmt.Sprintf("%s/%s", p.dc.ID, p.zone))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("placements = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateServerPlaced(t *testing.T) {
+	full := apiError(http.StatusUnprocessableEntity, "not enough capacity in the availability zone")
+	for _, tc := range []struct {
+		name string
+		errs []error
+		want []string
+		err  bool
+	}{
+		{"first placement", nil, []string{"dc1/ZONE_1"}, false},
+		{"next zone", []error{full}, []string{"dc1/ZONE_1", "dc1/ZONE_2"}, false},
+		{"next datacenter", []error{full, full}, []string{"dc1/ZONE_1", "dc1/ZONE_2", "dc2/ZONE_1"}, false},
+		{"no capacity anywhere", []error{full, full, full, full}, []string{"dc1/ZONE_1", "dc1/ZONE_2", "dc2/ZONE_1", "dc2/ZONE_2"}, true},
+		// Only capacity errors move on to the next placement.
+		{"invalid request", []error{apiError(http.StatusUnprocessableEntity, "invalid image")}, []string{"dc1/ZONE_1"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{createErrs: tc.errs}
+			i := newTestGroup(api)
+			i.Retry.MaxAttempts = 1
+			i.DatacenterId = ""
+			i.Datacenters = []DatacenterConfig{{ID: "dc1"}, {ID: "dc2"}}
+			i.ServerSpec.Type = "ENTERPRISE"
+			i.ServerSpec.AvailabilityZones = []string{"ZONE_1", "ZONE_2"}
+
+			_, dc, err := i.createServerPlaced(context.Background(), resolvedSpec{Image: "image"}, DatacenterConfig{ID: "dc1"}, "runner-1-aaaa", 1)
+			if (err != nil) != tc.err {
+				t.Errorf("createServerPlaced error %v, want error %t", err, tc.err)
+			}
+			var got []string
+			for n, server := range api.posted {
+				got = append(got, fmt.Sprintf("%s/%s", api.postedTo[n], serverZone(server)))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("createServerPlaced tried %v, want %v", got, tc.want)
+			}
+			if last := tc.want[len(tc.want)-1]; !strings.HasPrefix(last, dc.ID+"/") {
+				t.Errorf("createServerPlaced returned datacenter %s, want the one of %s", dc.ID, last)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const defaultWarmPoolInterval = Duration(30 * time.Second)
+
+// startWarmPool keeps warm_pool_size standby instances around. Standby
+// instances are hidden from Update until Increase hands them out, so the
+// runner gets an AVAILABLE instance without waiting for it to boot.
+func (i *InstanceGroup) startWarmPool() {
+	if i.WarmPoolSize <= 0 {
+		return
+	}
+	if i.backend() != nil {
+		i.log.Warn("warm_pool_size is ignored with autoscaling or kubernetes")
+		return
+	}
+	interval := i.WarmPoolInterval
+	if interval <= 0 {
+		interval = defaultWarmPoolInterval
+	}
+	i.runPeriodic("warm-pool", time.Duration(interval), i.refillWarmPool)
+}
+
+// refillWarmPool creates standby instances until the pool is full, without
+// exceeding max_size.
+func (i *InstanceGroup) refillWarmPool(ctx context.Context) error {
+	if i.DryRun {
+		return nil
+	}
+	if err := i.validateConfig(); err != nil {
+		return fmt.Errorf("validating required config: %w", err)
+	}
+	spec, err := i.resolveSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	missing := i.WarmPoolSize - len(i.registry.standby())
+	if missing <= 0 {
+		return nil
+	}
+	current, err := i.countInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("counting instances: %w", err)
+	}
+	missing = min(missing, i.MaxSize-current)
+
+	for range missing {
+		dc := i.nextDatacenter()
+		index := int(i.instanceCounter.Add(1))
+		server, dc, err := i.createServerPlaced(ctx, spec, dc, i.newServerName(index), index)
+		if err != nil {
+			return fmt.Errorf("creating standby instance: %w", err)
+		}
+		id := *server.Id
+		i.registry.setDatacenter(id, dc.ID)
+		i.registry.setZone(id, serverZone(server))
+		i.registry.setStandby(id, true)
+		if err := i.labelServer(ctx, dc.ID, server, "warm-pool"); err != nil {
+			i.log.Warn("Failed to label instance", "id", id, "err", err)
+		}
+		if err := i.labelStandby(ctx, dc.ID, id); err != nil {
+			i.log.Warn("Failed to label standby instance", "id", id, "err", err)
+		}
+		i.log.Info("Created standby instance", "id", id, "datacenter", dc.ID)
+	}
+	return nil
+}
+
+// takeStandby hands out up to n AVAILABLE standby instances and returns
+// their IDs. Instances that are still booting stay in the pool. Each instance
+// is claimed before it is checked, so concurrent calls never hand out the
+// same one.
+func (i *InstanceGroup) takeStandby(ctx context.Context, n int) []string {
+	var taken []string
+	checked := make(map[string]bool)
+	for len(taken) < n {
+		rec, ok := i.registry.claimStandby(checked)
+		if !ok {
+			break
+		}
+		checked[rec.ID] = true
+
+		dc := i.datacenterOf(ctx, rec.ID)
+		server, apiResponse, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, rec.ID, 0)
+		})
+		if err != nil {
+			if apiResponse.HttpNotFound() {
+				i.registry.remove(rec.ID)
+			} else {
+				i.log.Warn("Failed to get standby instance", "id", rec.ID, "err", err)
+				i.registry.releaseStandby(rec.ID, false)
+			}
+			continue
+		}
+		if server.Metadata == nil || server.Metadata.State == nil || *server.Metadata.State != "AVAILABLE" {
+			i.registry.releaseStandby(rec.ID, false)
+			continue
+		}
+
+		i.registry.releaseStandby(rec.ID, true)
+		_, err = withRetryNoResult(ctx, i, "ServersLabelsDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteServerLabel(ctx, dc, rec.ID, labelStandby)
+		})
+		if err != nil {
+			i.log.Warn("Failed to remove standby label", "id", rec.ID, "err", err)
+		}
+		i.log.Info("Handing out standby instance", "id", rec.ID)
+		taken = append(taken, rec.ID)
+	}
+	return taken
+}
+
+// labelStandby marks a server as standby, so the pool survives a restart.
+func (i *InstanceGroup) labelStandby(ctx context.Context, datacenterID string, id string) error {
+	_, _, err := withRetry(ctx, i, "ServersLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+		return i.api.AddServerLabel(ctx, datacenterID, id, labelStandby, "true")
+	})
+	return err
+}
@@ -0,0 +1,51 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestTakeStandbyConcurrently(t *testing.T) {
+	api := &mockCompute{
+		servers: []compute.Server{
+			testServer("standby-1", "runner-1-aaaa", "AVAILABLE"),
+			testServer("standby-2", "runner-2-bbbb", "AVAILABLE"),
+			testServer("standby-3", "runner-3-cccc", "AVAILABLE"),
+			testServer("booting", "runner-4-dddd", "BUSY"),
+		},
+		delay: 10 * time.Millisecond,
+	}
+	i := newTestGroup(api)
+	for _, server := range api.servers {
+		i.registry.setStandby(*server.Id, true)
+	}
+
+	var mu sync.Mutex
+	var taken []string
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := i.takeStandby(context.Background(), 1)
+			mu.Lock()
+			taken = append(taken, ids...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(taken)
+	if want := []string{"standby-1", "standby-2", "standby-3"}; !slices.Equal(taken, want) {
+		t.Errorf("takeStandby handed out %v, want each of %v once", taken, want)
+	}
+	standby := i.registry.standby()
+	if len(standby) != 1 || standby[0].ID != "booting" || standby[0].Claimed {
+		t.Errorf("warm pool is %+v, want the unclaimed booting instance", standby)
+	}
+}
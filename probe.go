@@ -0,0 +1,81 @@
+package ionos
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+const probeDialTimeout = 5 * time.Second
+
+// probeConnector waits until the connector port of an instance accepts
+// connections, as sshd usually comes up some time after the server is
+// AVAILABLE. For SSH the probe also waits for the server banner. It does
+// nothing unless probe_timeout is set.
+func (i *InstanceGroup) probeConnector(ctx context.Context, addr string, cfg provider.ConnectorConfig) error {
+	if i.ProbeTimeout <= 0 {
+		return nil
+	}
+
+	protocol, port := connectorPort(cfg)
+	target := net.JoinHostPort(addr, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(i.ProbeTimeout))
+	defer cancel()
+	retry := i.Retry.withDefaults()
+	for attempt := 1; ; attempt++ {
+		err := probe(ctx, target, protocol == provider.ProtocolSSH)
+		if err == nil {
+			return nil
+		}
+		i.log.Debug("Connector not ready yet", "address", target, "attempt", attempt, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not reachable within probe_timeout: %w", target, err)
+		case <-time.After(backoff(retry, attempt)):
+		}
+	}
+}
+
+// connectorPort returns the protocol and port the runner connects to.
+func connectorPort(cfg provider.ConnectorConfig) (provider.Protocol, int) {
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = provider.ProtocolSSH
+	}
+	port := cfg.ProtocolPort
+	if port == 0 {
+		port = provider.DefaultProtocolPorts[protocol]
+	}
+	return protocol, port
+}
+
+// probe dials target and, if ssh is set, reads the SSH protocol banner.
+func probe(ctx context.Context, target string, ssh bool) error {
+	dialer := net.Dialer{Timeout: probeDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if !ssh {
+		return nil
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(probeDialTimeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("reading SSH banner: %w", err)
+	}
+	if !strings.HasPrefix(line, "SSH-") {
+		return fmt.Errorf("unexpected SSH banner %q", strings.TrimSpace(line))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package ionos
+
+import (
+	"errors"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// ErrProtectedInstance is returned when asked to delete a server that is
+// listed in protected.
+var ErrProtectedInstance = errors.New("server is protected")
+
+// isProtected reports whether a server is listed in protected by ID or name.
+// Protected servers are never deleted or stopped by Decrease or the orphan
+// reaper, even if they carry the group label, e.g. a bastion or cache server
+// whose name shares the server name prefix. Shutdown deletes no servers, so it
+// needs no check.
+func (i *InstanceGroup) isProtected(server compute.Server) bool {
+	var name string
+	if server.Properties != nil && server.Properties.Name != nil {
+		name = *server.Properties.Name
+	}
+	for _, protected := range i.Protected {
+		if server.Id != nil && protected == *server.Id || name != "" && protected == name {
+			return true
+		}
+	}
+	return false
+}
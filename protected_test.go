@@ -0,0 +1,37 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestDecreaseRefusesProtectedInstance(t *testing.T) {
+	for _, protected := range []string{"cache", "runner-cache"} {
+		t.Run(protected, func(t *testing.T) {
+			api := &mockCompute{
+				servers: []compute.Server{testServer("cache", "runner-cache", "AVAILABLE")},
+				labels:  []compute.Label{testLabel("cache", labelGroup, "runner")},
+			}
+			i := newTestGroup(api)
+			i.Protected = []string{protected}
+
+			if _, err := i.checkOwnership(context.Background(), "dc1", "cache", map[string]string{"cache": "runner"}); !errors.Is(err, ErrProtectedInstance) {
+				t.Errorf("checkOwnership error %v, want %v", err, ErrProtectedInstance)
+			}
+
+			succeeded, err := i.Decrease(context.Background(), []string{"cache"})
+			if len(succeeded) != 0 {
+				t.Errorf("Decrease succeeded for %v, want none", succeeded)
+			}
+			if !errors.Is(err, ErrProtectedInstance) {
+				t.Errorf("Decrease error %v, want %v", err, ErrProtectedInstance)
+			}
+			if len(api.deleted) != 0 {
+				t.Errorf("Decrease deleted %v, want none", api.deleted)
+			}
+		})
+	}
+}
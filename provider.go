@@ -2,72 +2,314 @@ package ionos
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
 	"github.com/ionos-cloud/sdk-go-bundle/shared"
 	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http"
 	"path"
 	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type ServerSpec struct {
 	// The user data currently needs to add the ssh key to the user cause the api does not allow to add a ssh key to a private image...
 	// cherry on top: would be nice if you could pass the name of the image instead of the id -- this is not possible, the name of the image is not unique
-	Cores         int32   `json:"cores"`
-	Image         string  `json:"image,omitempty"`
-	ImagePassword string  `json:"image_password"`
-	Name          string  `json:"name"`
-	LanID         int32   `json:"lan_id"`
-	Ram           int32   `json:"ram"`
-	StorageSize   float32 `json:"storage_size"`
-	TemplateID    string  `json:"template_id"`
-	TemplateName  string  `json:"template_name"`
-	Type          string  `json:"type"`
-	UserData      string  `json:"user_data,omitempty"`
-	VolumeType    string  `json:"volume_type"`
+	AvailabilityZone       string              `json:"availability_zone,omitempty"`
+	AvailabilityZones      []string            `json:"availability_zones,omitempty"`
+	Cores                  int32               `json:"cores"`
+	BackupUnitID           string              `json:"backup_unit_id,omitempty"`
+	BootCdrom              string              `json:"boot_cdrom,omitempty"`
+	Bus                    string              `json:"bus,omitempty"`
+	CpuFamily              string              `json:"cpu_family,omitempty"`
+	CpuFamilyFallback      []string            `json:"cpu_family_fallback,omitempty"`
+	EnterpriseFallback     *EnterpriseFallback `json:"enterprise_fallback,omitempty"`
+	FirewallRules          []FirewallRule      `json:"firewall_rules,omitempty"`
+	Image                  string              `json:"image,omitempty"`
+	ImagePattern           string              `json:"image_pattern,omitempty"`
+	ImagePassword          string              `json:"image_password"`
+	ImagePasswordKey       string              `json:"image_password_key,omitempty"`
+	IPBlockID              string              `json:"ip_block_id,omitempty"`
+	IPv6                   bool                `json:"ipv6,omitempty"`
+	IPv6CidrBlock          string              `json:"ipv6_cidr_block,omitempty"`
+	Name                   string              `json:"name"`
+	OS                     string              `json:"os,omitempty"`
+	PublicLanID            int32               `json:"public_lan_id,omitempty"`
+	RandomImagePassword    bool                `json:"random_image_password,omitempty"`
+	LanID                  int32               `json:"lan_id"`
+	LicenceType            string              `json:"licence_type,omitempty"`
+	ManagerCIDRs           []string            `json:"manager_cidrs,omitempty"`
+	MinCores               int32               `json:"min_cores,omitempty"`
+	MinRam                 int32               `json:"min_ram,omitempty"`
+	MinStorage             float32             `json:"min_storage,omitempty"`
+	Ram                    int32               `json:"ram"`
+	SecondaryIPs           []string            `json:"secondary_ips,omitempty"`
+	StaticIPs              *StaticIPConfig     `json:"static_ips,omitempty"`
+	StorageSize            float32             `json:"storage_size"`
+	TemplateID             string              `json:"template_id"`
+	TemplateName           string              `json:"template_name"`
+	Type                   string              `json:"type"`
+	UserData               string              `json:"user_data,omitempty"`
+	UserDataTemplate       bool                `json:"user_data_template,omitempty"`
+	UserDataMetadata       bool                `json:"user_data_metadata,omitempty"`
+	UserDataFragments      []UserDataFragment  `json:"user_data_fragments,omitempty"`
+	UserDataFile           string              `json:"user_data_file,omitempty"`
+	UserDataGzip           bool                `json:"user_data_gzip,omitempty"`
+	VolumeAvailabilityZone string              `json:"volume_availability_zone,omitempty"`
+	VolumeType             string              `json:"volume_type"`
+	VolumeTier             string              `json:"volume_tier,omitempty"`
 }
 
 var _ provider.InstanceGroup = (*InstanceGroup)(nil)
 
+const (
+	defaultMaxSize           = 1000
+	defaultPageSize          = 100
+	defaultDeleteConcurrency = 8
+)
+
 type InstanceGroup struct {
-	Profile         string     `json:"profile"`
-	ConfigFile      string     `json:"config_file"`
-	CredentialsFile string     `json:"credentials_file"`
-	Name            string     `json:"name"`
-	DatacenterId    string     `json:"datacenter_id"`
-	Token           string     `json:"ionos_token"`
-	ServerSpec      ServerSpec `json:"server_spec"`
+	Profile             string               `json:"profile"`
+	ConfigFile          string               `json:"config_file"`
+	CredentialsFile     string               `json:"credentials_file"`
+	Name                string               `json:"name"`
+	DatacenterId        string               `json:"datacenter_id"`
+	Datacenters         []DatacenterConfig   `json:"datacenters"`
+	Token               string               `json:"ionos_token"`
+	TokenFile           string               `json:"ionos_token_file"`
+	APIURL              string               `json:"api_url"`
+	ServerSpec          ServerSpec           `json:"server_spec"`
+	ServerSpecs         []SpecVariant        `json:"server_specs"`
+	SpecPolicy          string               `json:"spec_policy"`
+	Retry               RetryConfig          `json:"retry"`
+	Timeouts            TimeoutsConfig       `json:"timeouts"`
+	CircuitBreaker      CircuitBreakerConfig `json:"circuit_breaker"`
+	HTTP                HTTPConfig           `json:"http"`
+	Tracing             TracingConfig        `json:"tracing"`
+	VolumeSweepInterval Duration             `json:"volume_sweep_interval"`
+	MaxSize             int                  `json:"max_size"`
+	SkipQuotaCheck      bool                 `json:"skip_quota_check"`
+	PageSize            int32                `json:"page_size"`
+	BootGracePeriod     Duration             `json:"boot_grace_period"`
+	ReplaceAfter        int                  `json:"replace_after"`
+	OrphanTTL           Duration             `json:"orphan_ttl"`
+	ReaperInterval      Duration             `json:"reaper_interval"`
+	ConnectWait         Duration             `json:"connect_wait"`
+	ProbeTimeout        Duration             `json:"probe_timeout"`
+	ManageSSHKey        bool                 `json:"manage_ssh_key"`
+	SSHKeyFile          string               `json:"ssh_key_file"`
+	Bastion             BastionConfig        `json:"bastion"`
+	NATGateway          NATGatewayConfig     `json:"nat_gateway"`
+	Autoscaling         AutoscalingConfig    `json:"autoscaling"`
+	Kubernetes          KubernetesConfig     `json:"kubernetes"`
+	UseIPv6             bool                 `json:"use_ipv6"`
+	MetricsAddress      string               `json:"metrics_address"`
+	WarmPoolSize        int                  `json:"warm_pool_size"`
+	WarmPoolInterval    Duration             `json:"warm_pool_interval"`
+	DryRun              bool                 `json:"dry_run"`
+	CleanupOnCancel     bool                 `json:"cleanup_on_cancel"`
+	ServerCacheTTL      Duration             `json:"server_cache_ttl"`
+	UpdateSnapshotTTL   Duration             `json:"update_snapshot_ttl"`
+	Depth               DepthConfig          `json:"depth"`
+	DeleteConcurrency   int                  `json:"delete_concurrency"`
+	ShutdownTimeout     Duration             `json:"shutdown_timeout"`
+	WaitForDelete       bool                 `json:"wait_for_delete"`
+	VerifyDelete        bool                 `json:"verify_delete"`
+	DeleteWaitTimeout   Duration             `json:"delete_wait_timeout"`
+	DecreaseAction      string               `json:"decrease_action"`
+	Protected           []string             `json:"protected"`
+	Pricing             Pricing              `json:"pricing"`
+	KeepImages          int                  `json:"keep_images"`
+	AuditLog            string               `json:"audit_log"`
+	Vault               VaultConfig          `json:"vault"`
 
 	log             hclog.Logger
-	computeClient   compute.APIClient
+	api             computeAPI
 	instanceCounter atomic.Int32
+	staticIPs       staticIPPool
+	reservedIPs     staticIPPool
+	zoneCursor      atomic.Int32
+	dcMu            sync.Mutex
+	dcCurrent       []int
+	specMu          sync.Mutex
+	specCurrent     []int
+	tracer          trace.Tracer
+	tracerProvider  *sdktrace.TracerProvider
+	bgCtx           context.Context
+	bgCancel        context.CancelFunc
+	bgWG            sync.WaitGroup
+	registry        *registry
+	startedAt       time.Time
+	metrics         *metrics
+	metricsServer   *http.Server
+	health          healthState
+	auditLog        auditLog
+	pending         pendingRequests
+	requestLog      requestLog
+	costMu          sync.Mutex
+	costPerHour     *float64
+	templates       templateCache
+	serverCache     serverCache
+	breaker         circuitBreaker
+	sshKey          *sshKeyPair
+	image           *imageInfo
+	imageLocation   string
+	imageResolvedAt time.Time
+	resolveMu       sync.Mutex
+	resolved        atomic.Pointer[resolvedSpec]
+	bastion         *bastion
+	autoLans        map[string]int32
+	vault           *vaultClient
+	apiToken        *tokenTransport
+	reloadMu        sync.Mutex
+	reloadedAt      time.Time
 
 	settings provider.Settings
 }
 
 // Init implements provider.InstanceGroup.
-func (i *InstanceGroup) Init(ctx context.Context, logger hclog.Logger, settings provider.Settings) (provider.ProviderInfo, error) {
-	cfg := shared.NewConfiguration("", "", i.Token, "")
+func (i *InstanceGroup) Init(ctx context.Context, logger hclog.Logger, settings provider.Settings) (info provider.ProviderInfo, err error) {
+	if err := i.initTracing(ctx); err != nil {
+		return provider.ProviderInfo{}, err
+	}
+	ctx, span := i.startSpan(ctx, "Init", attribute.String("fleeting.group", i.Name))
+	defer func() { endSpan(span, err) }()
 
-	computeClient := compute.NewAPIClient(cfg)
+	if err := i.initClient(ctx, logger, settings, true); err != nil {
+		return provider.ProviderInfo{}, err
+	}
+	if err := i.provision(ctx); err != nil {
+		return provider.ProviderInfo{}, err
+	}
+	if err := i.adoptInstances(ctx); err != nil {
+		i.log.Error("Failed to adopt existing instances", "err", err)
+	}
 
-	i.computeClient = *computeClient
-	i.settings = settings
-	i.log = logger
+	i.startBackground()
+	if i.VolumeSweepInterval > 0 {
+		i.runPeriodic("volume-sweep", time.Duration(i.VolumeSweepInterval), func(ctx context.Context) error {
+			_, err := i.CollectFailedCreations(ctx, time.Now().Add(-failedCreateLookback))
+			_, err2 := i.SweepVolumes(ctx)
+			return errors.Join(err, err2)
+		})
+	}
+	i.startReaper()
+	i.startVaultRefresh()
+	i.startReloadSignal()
+	i.startMetricsServer()
+	i.startWarmPool()
 
 	return provider.ProviderInfo{
 		ID:        path.Join("ionos", i.Name),
-		MaxSize:   1000,
+		MaxSize:   i.MaxSize,
 		Version:   Version.String(),
 		BuildInfo: Version.BuildInfo(),
 	}, nil
 }
 
+// InitCLI initializes the instance group for a single command of the CLI.
+// It loads the credentials, creates the API client and looks up the group
+// instances, but starts none of the periodic tasks, the metrics server or
+// the reload signal handler, and does not write to the datacenters. With
+// provision, it also resolves the image and makes sure the LANs and NAT
+// gateways exist like Init does, which creating instances needs.
+func (i *InstanceGroup) InitCLI(ctx context.Context, logger hclog.Logger, provision bool) error {
+	if err := i.initClient(ctx, logger, provider.Settings{}, false); err != nil {
+		return err
+	}
+	if provision {
+		if err := i.provision(ctx); err != nil {
+			return err
+		}
+	}
+	if err := i.adoptInstances(ctx); err != nil {
+		i.log.Error("Failed to adopt existing instances", "err", err)
+	}
+	// Tasks started by the command itself, like deleting the servers of a
+	// failed creation, still run until Shutdown.
+	i.startBackground()
+	return nil
+}
+
+// initClient creates the API client, verifies the credentials and the
+// datacenters and initializes the backend, if any. With checkWrite, it also checks that the token may modify
+// the datacenters, which writes a label to them.
+func (i *InstanceGroup) initClient(ctx context.Context, logger hclog.Logger, settings provider.Settings, checkWrite bool) error {
+	if err := i.initAPI(ctx); err != nil {
+		return err
+	}
+
+	i.settings = settings
+	i.log = newRedactingLogger(logger)
+	secrets.add(i.Token, i.ServerSpec.ImagePassword, settings.Password)
+	i.registry = newRegistry()
+	i.startedAt = time.Now()
+	i.metrics = newMetrics(i.groupLabel())
+	if i.MaxSize <= 0 {
+		i.MaxSize = defaultMaxSize
+	}
+
+	owner, err := i.validateCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	if err := i.verifyDatacenters(ctx, checkWrite && !owner); err != nil {
+		return err
+	}
+	if err := i.loadSSHKey(); err != nil {
+		return err
+	}
+	if err := i.initBastion(); err != nil {
+		return err
+	}
+	if i.backend() != nil {
+		return i.initBackend(ctx)
+	}
+	return nil
+}
+
+// provision resolves the image and provisions the network of the group.
+func (i *InstanceGroup) provision(ctx context.Context) error {
+	if _, err := i.resolveSpec(ctx); err != nil {
+		return err
+	}
+	i.resolveImage(ctx)
+	if err := i.validateImage(); err != nil {
+		return err
+	}
+
+	if i.backend() != nil {
+		// The backend's own configuration defines the network, so there
+		// is nothing to provision here.
+		return nil
+	}
+	// User data is checked once here rather than on every Increase, as
+	// resolving its secrets can call Vault.
+	if i.hasUserData() {
+		if err := i.validateUserData(ctx); err != nil {
+			return err
+		}
+	}
+	if err := i.ensureLans(ctx); err != nil {
+		return err
+	}
+	if i.ServerSpec.IPv6 {
+		if err := i.ensureLanIPv6(ctx); err != nil {
+			return err
+		}
+	}
+	return i.ensureNATGateways(ctx)
+}
+
 func StrPtr(str string) *string {
 	return &str
 }
@@ -77,145 +319,531 @@ func FloatPtr(float float32) *float32 {
 func BoolPtr(boolean bool) *bool { return &boolean }
 
 // Increase implements provider.InstanceGroup.
-func (i *InstanceGroup) Increase(ctx context.Context, delta int) (int, error) {
-	var err error
+func (i *InstanceGroup) Increase(ctx context.Context, delta int) (succeeded int, err error) {
+	ctx, span := i.startSpan(ctx, "Increase", attribute.Int("fleeting.delta", delta))
+	defer func() {
+		span.SetAttributes(attribute.Int("fleeting.succeeded", succeeded))
+		endSpan(span, err)
+	}()
+
+	if b := i.backend(); b != nil {
+		succeeded, err = i.backendIncrease(ctx, b, delta)
+		i.log.Info("Increase", "delta", delta, "succeeded", succeeded)
+		return succeeded, err
+	}
+
 	err = i.validateConfig()
 	if err != nil {
 		return 0, fmt.Errorf("validating required config: %w", err)
 	}
 
-	// Get template ID based on the provided template name.
-	if i.ServerSpec.Type == "CUBE" {
-		if i.ServerSpec.TemplateName != "" {
-			i.ServerSpec.TemplateID, err = i.getTemplateID(i.ServerSpec.TemplateName)
-			if err != nil {
-				return 0, fmt.Errorf("getting template id from template name: %w", err)
-			}
+	spec, err := i.resolveSpec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if i.WarmPoolSize > 0 {
+		taken := i.takeStandby(ctx, delta)
+		succeeded += len(taken)
+		delta -= len(taken)
+		if delta == 0 {
+			i.log.Info("Increase", "delta", len(taken), "succeeded", succeeded)
+			return succeeded, nil
+		}
+	}
+
+	current, err := i.countInstances(ctx)
+	if err != nil {
+		return succeeded, fmt.Errorf("counting instances: %w", err)
+	}
+	if current+delta > i.MaxSize {
+		if current >= i.MaxSize {
+			return succeeded, fmt.Errorf("max_size of %d instances reached", i.MaxSize)
+		}
+		i.log.Warn("Increase would exceed max_size, limiting delta", "delta", delta, "current", current, "max_size", i.MaxSize)
+		delta = i.MaxSize - current
+	}
+
+	if i.keepsStopped() {
+		started := i.startStopped(ctx, delta)
+		succeeded += len(started)
+		delta -= len(started)
+		current += len(started)
+		if delta == 0 {
+			i.log.Info("Increase", "delta", len(started), "succeeded", succeeded)
+			return succeeded, nil
 		}
 	}
 
-	succeeded := 0
+	if !i.SkipQuotaCheck {
+		if err := i.checkQuota(ctx, current, delta); err != nil {
+			return succeeded, err
+		}
+	}
+
+	results := make([]CreateResult, 0, delta)
+	var created []string
 	for range delta {
+		// A cancelled Increase stops creating servers, the runner no
+		// longer waits for them.
+		if ctx.Err() != nil {
+			break
+		}
 		index := int(i.instanceCounter.Add(1))
-		serverData := i.getPostServerData(index)
-		server, _, err2 := i.computeClient.ServersApi.DatacentersServersPost(ctx, i.DatacenterId).Server(serverData).Execute()
+		dc := i.nextDatacenter()
+		serverName := i.newServerName(index)
+		server, dc, err2 := i.createServerPlaced(ctx, spec, dc, serverName, index)
 		if err2 != nil {
-			i.log.Error("Failed to create instance", "err", err2)
-			err = errors.Join(err, err2)
+			result := newCreateResult(dc, index, serverName, "", err2)
+			results = append(results, result)
+			i.log.Error("Failed to create instance", "index", index, "name", serverName, "datacenter", dc.ID,
+				"request_id", result.RequestID, "class", result.Class, "err", err2)
+			if invalidatesTemplate(err2) {
+				i.templates.clear()
+			}
+			continue
+		}
+		results = append(results, newCreateResult(dc, index, serverName, *server.Id, nil))
+		if i.DryRun {
+			succeeded++
 		} else {
-			i.log.Info("Instance creation request successful", "id", *server.Id)
+			i.log.Info("Instance creation request successful", "id", *server.Id, "datacenter", dc.ID, "zone", serverZone(server))
+			i.registry.touch(*server.Id)
+			i.registry.setDatacenter(*server.Id, dc.ID)
+			i.registry.setZone(*server.Id, serverZone(server))
+			if err := i.labelServer(ctx, dc.ID, server, "increase"); err != nil {
+				i.log.Warn("Failed to label instance", "id", *server.Id, "err", err)
+			}
+			created = append(created, *server.Id)
 			succeeded++
 		}
 	}
 
+	i.serverCache.invalidate()
+	if err := ctx.Err(); err != nil {
+		i.log.Warn("Increase cancelled", "delta", delta, "created", len(created), "err", err)
+		if i.CleanupOnCancel && len(created) > 0 {
+			succeeded -= i.cleanupCancelledIncrease(ctx, created)
+		}
+		return succeeded, fmt.Errorf("increase cancelled after %d of %d instances: %w", len(created), delta, err)
+	}
 	i.log.Info("Increase", "delta", delta, "succeeded", succeeded)
-	return succeeded, err
+	if increaseErr := (&IncreaseError{Results: results}); len(increaseErr.Failed()) > 0 {
+		return succeeded, increaseErr
+	}
+	return succeeded, nil
 }
 
 // ConnectInfo implements provider.InstanceGroup.
-func (i *InstanceGroup) ConnectInfo(ctx context.Context, instance string) (provider.ConnectInfo, error) {
-	server, _, err := i.computeClient.ServersApi.DatacentersServersFindById(ctx, i.DatacenterId, instance).Pretty(true).Depth(2).Execute()
+func (i *InstanceGroup) ConnectInfo(ctx context.Context, instance string) (_ provider.ConnectInfo, err error) {
+	ctx, span := i.startSpan(ctx, "ConnectInfo", attribute.String("fleeting.instance", instance))
+	defer func() { endSpan(span, err) }()
+	defer func() { err = i.withRequestContext(instance, err) }()
+
+	server, err := i.waitForAvailable(ctx, instance)
 	if err != nil {
-		return provider.ConnectInfo{}, fmt.Errorf("failed to get server with ID: %v, error: %w", instance, err)
+		return provider.ConnectInfo{}, err
 	}
 
+	dc := i.datacenterOf(ctx, instance)
+	nic, err := i.privateNIC(ctx, dc, instance)
+	if err != nil {
+		return provider.ConnectInfo{}, err
+	}
 	var internalIP string
-
-	nic := (*server.Entities.Nics.Items)[0]
-	internalIP = (*nic.Properties.Ips)[0]
-
-	state := *server.Metadata.State
-	if state != "AVAILABLE" {
-		return provider.ConnectInfo{}, fmt.Errorf("server is not in the AVAILABLE State")
+	if i.UseIPv6 {
+		internalIP, err = i.nicIPv6(ctx, dc, instance, nic)
+	} else {
+		internalIP, err = nicIP(nic)
+	}
+	if err != nil {
+		return provider.ConnectInfo{}, err
 	}
 
+	i.registry.touch(instance)
+
 	connectInfo := provider.ConnectInfo{
-		ConnectorConfig: i.settings.ConnectorConfig,
+		ConnectorConfig: i.connectorConfig(),
 		ID:              *server.Id,
 		InternalAddr:    internalIP,
 	}
+	if i.bastion != nil {
+		_, port := connectorPort(connectInfo.ConnectorConfig)
+		localPort, err := i.tunnelTo(instance, internalIP, port)
+		if err != nil {
+			return provider.ConnectInfo{}, err
+		}
+		connectInfo.InternalAddr = "127.0.0.1"
+		connectInfo.ExternalAddr = "127.0.0.1"
+		connectInfo.ProtocolPort = localPort
+	}
+	if err := i.probeConnector(ctx, connectInfo.InternalAddr, connectInfo.ConnectorConfig); err != nil {
+		return provider.ConnectInfo{}, err
+	}
 
 	return connectInfo, nil
 
 }
 
 // Update implements provider.InstanceGroup.
-func (i *InstanceGroup) Update(ctx context.Context, fn func(instance string, state provider.State)) error {
-	instances, _, err := i.computeClient.ServersApi.DatacentersServersGet(ctx, i.DatacenterId).Depth(2).Execute()
+func (i *InstanceGroup) Update(ctx context.Context, fn func(instance string, state provider.State)) (err error) {
+	ctx, span := i.startSpan(ctx, "Update")
+	defer func() { endSpan(span, err) }()
+
+	// The runner may call Update several times in a row, e.g. while it
+	// scales, so with update_snapshot_ttl the states of the last call are
+	// reused for a moment.
+	ttl := time.Duration(i.UpdateSnapshotTTL)
+	if states, ok := i.serverCache.getSnapshot(ttl); ok {
+		for _, s := range states {
+			i.registry.touch(s.id)
+			fn(s.id, s.state)
+		}
+		i.health.recordUpdate()
+		return nil
+	}
+
+	servers, err := i.cachedGroupServers(ctx)
 	if err != nil {
 		return err
 	}
-	for _, instance := range *instances.Items {
+
+	var snapshot []instanceState
+	report := func(instance string, state provider.State) {
+		snapshot = append(snapshot, instanceState{instance, state})
+		// The runner knows about every instance Update reports, so the
+		// reaper must not treat it as an orphan.
+		i.registry.touch(instance)
+		fn(instance, state)
+	}
+
+	counts := make(map[string]int)
+	for _, instance := range servers {
 		state := *instance.Metadata.State
+		rec, known := i.registry.get(*instance.Id)
+		// Stopped instances are hidden until they are started again.
+		if known && rec.Stopped {
+			counts[stateStopped]++
+			continue
+		}
+		counts[state]++
 
-		if !strings.HasPrefix(*instance.Properties.Name, i.ServerSpec.Name) {
+		// Warm pool instances are hidden until they are handed out.
+		if known && rec.Standby {
 			continue
 		}
 
+		// Started instances are creating until their VM runs again.
+		if known && rec.Starting {
+			if state == "AVAILABLE" && isRunning(instance) {
+				i.registry.setStarting(*instance.Id, false)
+			} else {
+				report(*instance.Id, provider.StateCreating)
+				continue
+			}
+		}
+
 		switch state {
 		case "AVAILABLE":
-			fn(*instance.Id, provider.StateRunning)
+			report(*instance.Id, provider.StateRunning)
 			// "BUSY" can also correspond to provider.StateDeleting but there is no way to figure
 			// it out.
 		case "BUSY":
-			fn(*instance.Id, provider.StateCreating)
+			report(*instance.Id, provider.StateCreating)
 		case "INACTIVE":
-			fn(*instance.Id, provider.StateDeleted)
+			report(*instance.Id, provider.StateDeleted)
 		}
+	}
 
+	i.metrics.instances.Reset()
+	for state, count := range counts {
+		i.metrics.instances.WithLabelValues(state).Set(float64(count))
+	}
+	if i.Pricing.configured() {
+		var total int
+		for state, count := range counts {
+			if state != "INACTIVE" && state != stateStopped {
+				total += count
+			}
+		}
+		i.recordCost(ctx, total)
+	}
+	if ttl > 0 {
+		i.serverCache.setSnapshot(snapshot)
 	}
+	i.health.recordUpdate()
 	return nil
 }
 
 // Decrease implements provider.InstanceGroup.
-func (i *InstanceGroup) Decrease(ctx context.Context, instances []string) ([]string, error) {
+func (i *InstanceGroup) Decrease(ctx context.Context, instances []string) (succeeded []string, err error) {
 	if len(instances) == 0 {
 		return nil, nil
 	}
 
-	succeeded := make([]string, 0, len(instances))
-	var err error
-	for _, id := range instances {
-		_, err2 := i.computeClient.ServersApi.DatacentersServersDelete(ctx, i.DatacenterId, id).Execute()
-		if err2 != nil {
-			i.log.Error("Failed to delete instance", "err", err2, "id", id)
-			err = errors.Join(err, err2)
+	ctx, span := i.startSpan(ctx, "Decrease", attribute.StringSlice("fleeting.instances", instances))
+	defer func() {
+		span.SetAttributes(attribute.StringSlice("fleeting.succeeded", succeeded))
+		endSpan(span, err)
+	}()
+
+	if b := i.backend(); b != nil {
+		succeeded, err = i.backendDecrease(ctx, b, instances)
+		i.log.Info("Decrease", "instances", instances)
+		return succeeded, err
+	}
+
+	groups, err := i.serverGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing server labels: %w", err)
+	}
+
+	// Deletions (or stops) run concurrently, bounded by delete_concurrency, and are
+	// reported in the order of instances.
+	errs := make([]error, len(instances))
+	sem := make(chan struct{}, i.deleteConcurrency())
+	var wg sync.WaitGroup
+	for n, id := range instances {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if i.keepsStopped() {
+				errs[n] = i.stopInstance(ctx, id, groups)
+			} else {
+				errs[n] = i.deleteInstance(ctx, id, groups)
+			}
+		}()
+	}
+	wg.Wait()
+
+	succeeded = make([]string, 0, len(instances))
+	for n, id := range instances {
+		if errs[n] != nil {
+			err = errors.Join(err, errs[n])
 		} else {
-			i.log.Info("Instance deletion request successful", "id", id)
 			succeeded = append(succeeded, id)
 		}
 	}
 
+	i.serverCache.invalidate()
 	i.log.Info("Decrease", "instances", instances)
 
 	return succeeded, err
 }
 
-// Heartbeat implements provider.InstanceGroup.
-func (i *InstanceGroup) Heartbeat(ctx context.Context, instance string) error {
-	_, apiResponse, err := i.computeClient.ServersApi.DatacentersServersFindById(ctx, i.DatacenterId, instance).Execute()
+// deleteInstance deletes a single instance after checking that it belongs
+// to the group.
+func (i *InstanceGroup) deleteInstance(ctx context.Context, id string, groups map[string]string) error {
+	dc := i.datacenterOf(ctx, id)
+	if _, err := i.checkOwnership(ctx, dc, id, groups); err != nil {
+		i.log.Error("Not deleting instance", "id", id, "err", err)
+		return err
+	}
+	if i.dryRun("would delete server", "id", id, "datacenter", dc) {
+		return nil
+	}
+	started := time.Now()
+	apiResponse, err := withRetryNoResult(ctx, i, "Decrease", func(ctx context.Context) (*shared.APIResponse, error) {
+		return i.api.DeleteServer(ctx, dc, id)
+	})
+	i.audit(ctx, auditEvent{Action: "delete", ResourceType: "server", ResourceID: id, Datacenter: dc}, apiResponse, err)
 	if err != nil {
-		if apiResponse.HttpNotFound() {
-			return fmt.Errorf("instance %v does not exist", instance)
-		} else {
-			return fmt.Errorf("error retrieving instance %v: %w", instance, err)
+		i.log.Error("Failed to delete instance", "err", err, "id", id)
+		return err
+	}
+	done := i.trackRequest("delete", id, apiResponse, started)
+	i.log.Info("Instance deletion request successful", "id", id)
+	if i.WaitForDelete {
+		if err := i.awaitDelete(ctx, id, done); err != nil {
+			i.log.Error("Instance deletion not confirmed", "err", err, "id", id)
+			return err
 		}
+		i.log.Info("Instance deleted", "id", id)
 	}
+	if i.VerifyDelete {
+		if err := i.verifyDeleted(ctx, dc, id); err != nil {
+			i.log.Error("Instance still exists after deletion", "err", err, "id", id)
+			return err
+		}
+	}
+	i.registry.remove(id)
+	i.closeTunnel(id)
 	return nil
 }
 
+func (i *InstanceGroup) deleteConcurrency() int {
+	if i.DeleteConcurrency <= 0 {
+		return defaultDeleteConcurrency
+	}
+	return i.DeleteConcurrency
+}
+
+// Heartbeat implements provider.InstanceGroup.
+func (i *InstanceGroup) Heartbeat(ctx context.Context, instance string) (err error) {
+	ctx, span := i.startSpan(ctx, "Heartbeat", attribute.String("fleeting.instance", instance))
+	defer func() { endSpan(span, err) }()
+	defer func() { err = i.withRequestContext(instance, err) }()
+	defer func() { i.recordHeartbeat(instance, err) }()
+
+	ttl := time.Duration(i.ServerCacheTTL)
+	dc := i.datacenterOf(ctx, instance)
+	server, cached := i.serverCache.getServer(instance, ttl)
+	if !cached {
+		var apiResponse *shared.APIResponse
+		server, apiResponse, err = withRetry(ctx, i, "Heartbeat", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, instance, i.Depth.server())
+		})
+		if err != nil {
+			if apiResponse.HttpNotFound() {
+				return fmt.Errorf("instance %v does not exist", instance)
+			} else {
+				return fmt.Errorf("error retrieving instance %v: %w", instance, err)
+			}
+		}
+		if ttl > 0 {
+			i.serverCache.setServer(server)
+		}
+	}
+	i.registry.touch(instance)
+	return i.checkServerHealth(server, dc)
+}
+
 // Shutdown implements provider.InstanceGroup.
 func (i *InstanceGroup) Shutdown(ctx context.Context) error {
+	i.drainRequests(ctx)
+	i.stopBackground()
+	i.log.Info("Shutdown", "instances", i.registry.len())
+	return errors.Join(i.closeBastion(), i.stopMetricsServer(ctx), i.shutdownTracing(ctx), i.auditLog.close())
+}
+
+// forEachGroupServer pages through the servers of the datacenters and calls
+// fn for each one that belongs to the group. The API filters by name on the
+// server side, membership is then decided by the group label.
+func (i *InstanceGroup) forEachGroupServer(ctx context.Context, depth int32, fn func(server compute.Server)) error {
+	if b := i.backend(); b != nil {
+		return i.forEachBackendServer(ctx, b, depth, fn)
+	}
+
+	groups, err := i.serverGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("listing server labels: %w", err)
+	}
+
+	for _, dc := range i.datacenters() {
+		err := i.forEachDatacenterServer(ctx, dc.ID, depth, func(server compute.Server) {
+			if i.isGroupServer(server, groups) {
+				i.registry.setDatacenter(*server.Id, dc.ID)
+				i.registry.setZone(*server.Id, serverZone(server))
+				fn(server)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// forEachDatacenterServer pages through the servers of a datacenter whose
+// name contains the server name prefix.
+func (i *InstanceGroup) forEachDatacenterServer(ctx context.Context, datacenterID string, depth int32, fn func(server compute.Server)) error {
+	limit := i.PageSize
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	// Backends name the servers themselves.
+	name := i.ServerSpec.Name
+	if i.backend() != nil {
+		name = ""
+	}
+
+	for offset := int32(0); ; offset += limit {
+		servers, _, err := withRetry(ctx, i, "ServersGet", func(ctx context.Context) (compute.Servers, *shared.APIResponse, error) {
+			return i.api.ListServers(ctx, datacenterID, name, depth, offset, limit)
+		})
+		if err != nil {
+			return err
+		}
+		if servers.Items == nil {
+			return nil
+		}
+
+		for _, server := range *servers.Items {
+			fn(server)
+		}
+
+		// Pagination is applied before filtering, so a short page does not
+		// mean there are no more servers when the API tells us otherwise.
+		if servers.Links != nil {
+			if servers.Links.Next == nil {
+				return nil
+			}
+		} else if int32(len(*servers.Items)) < limit {
+			return nil
+		}
+	}
+}
+
+// listGroupServers returns all servers in the datacenters that belong to the
+// group, without their NICs and volumes unless depth.list asks for them.
+func (i *InstanceGroup) listGroupServers(ctx context.Context) ([]compute.Server, error) {
+	return i.listGroupServersAt(ctx, i.Depth.list())
+}
+
+func (i *InstanceGroup) listGroupServersAt(ctx context.Context, depth int32) ([]compute.Server, error) {
+	var members []compute.Server
+	err := i.forEachGroupServer(ctx, depth, func(server compute.Server) {
+		members = append(members, server)
+	})
+	return members, err
+}
+
+// countInstances returns the number of group servers that count towards
+// max_size. Stopped instances do not.
+func (i *InstanceGroup) countInstances(ctx context.Context) (int, error) {
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, server := range servers {
+		rec, ok := i.registry.get(*server.Id)
+		if ok && rec.Stopped {
+			continue
+		}
+		if *server.Metadata.State != "INACTIVE" || ok && rec.Starting {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// isGroupMember reports whether a server or volume name belongs to this group.
+func (i *InstanceGroup) isGroupMember(name string) bool {
+	return i.ServerSpec.Name != "" && strings.HasPrefix(name, i.ServerSpec.Name)
+}
+
 func (i *InstanceGroup) validateConfig() error {
+	if err := i.validateDatacenters(); err != nil {
+		return err
+	}
+
 	// Validate required attributes
 	if i.ServerSpec.Type == "" || i.ServerSpec.Name == "" {
 		return fmt.Errorf("type, name are required")
 	}
-	if i.ServerSpec.LanID == 0 || i.ServerSpec.UserData == "" || i.ServerSpec.VolumeType == "" {
-		return fmt.Errorf("lan_id, user_data, volume_type are required")
+	if i.ServerSpec.VolumeType == "" && i.ServerSpec.Type != "CUBE" {
+		return fmt.Errorf("volume_type is required")
+	}
+	if !i.isWindows() && !i.bootsBlankVolume() && !i.hasUserData() {
+		return fmt.Errorf("one of user_data/user_data_file/user_data_fragments is required")
+	}
+	if i.ServerSpec.UserData != "" && i.ServerSpec.UserDataFile != "" {
+		return fmt.Errorf("only one of user_data/user_data_file can be specified")
 	}
 
 	// Validate type
@@ -224,10 +852,23 @@ func (i *InstanceGroup) validateConfig() error {
 		return fmt.Errorf("type can be 'ENTERPRISE' or 'CUBE'")
 	}
 
+	// Validate availability zones
+	serverZones := []string{"AUTO", "ZONE_1", "ZONE_2"}
+	if i.ServerSpec.AvailabilityZone != "" && !slices.Contains(serverZones, i.ServerSpec.AvailabilityZone) {
+		return fmt.Errorf("availability_zone can be 'AUTO', 'ZONE_1' or 'ZONE_2'")
+	}
+	if err := i.validateAvailabilityZones(); err != nil {
+		return err
+	}
+	volumeZones := []string{"AUTO", "ZONE_1", "ZONE_2", "ZONE_3"}
+	if i.ServerSpec.VolumeAvailabilityZone != "" && !slices.Contains(volumeZones, i.ServerSpec.VolumeAvailabilityZone) {
+		return fmt.Errorf("volume_availability_zone can be 'AUTO', 'ZONE_1', 'ZONE_2' or 'ZONE_3'")
+	}
+
 	// Validate 'CUBE' type
 	if i.ServerSpec.Type == "CUBE" {
-		if i.ServerSpec.TemplateID == "" && i.ServerSpec.TemplateName == "" {
-			return fmt.Errorf("one of template_id/template_name is required for 'CUBE' type, if both are specified, template_id will have priority")
+		if i.ServerSpec.TemplateID == "" && i.ServerSpec.TemplateName == "" && !i.ServerSpec.selectsTemplate() {
+			return fmt.Errorf("one of template_id/template_name/min_cores/min_ram/min_storage is required for 'CUBE' type, if both are specified, template_id will have priority")
 		}
 	}
 
@@ -237,24 +878,316 @@ func (i *InstanceGroup) validateConfig() error {
 			return fmt.Errorf("cores, ram and storage_size are required for 'ENTERPRISE' type")
 		}
 	}
+
+	if i.ServerSpec.EnterpriseFallback != nil && i.ServerSpec.Type != "CUBE" {
+		return fmt.Errorf("enterprise_fallback can only be used with 'CUBE' type")
+	}
+
+	// Validate volume bus
+	if i.ServerSpec.Bus != "" && !slices.Contains([]string{"VIRTIO", "IDE"}, i.ServerSpec.Bus) {
+		return fmt.Errorf("bus can be 'VIRTIO' or 'IDE'")
+	}
+
+	if len(i.ServerSpec.CpuFamilyFallback) > 0 && i.ServerSpec.CpuFamily == "" {
+		return fmt.Errorf("cpu_family_fallback requires cpu_family to be set")
+	}
+
+	if _, err := path.Match(i.ServerSpec.ImagePattern, ""); err != nil {
+		return fmt.Errorf("image_pattern: %w", err)
+	}
+	if err := i.validateVolumeTypes(); err != nil {
+		return err
+	}
+	if err := i.validateSpecVariants(); err != nil {
+		return err
+	}
+	if err := validateFirewallRules(i.ServerSpec.FirewallRules); err != nil {
+		return err
+	}
+	for _, cidr := range i.ServerSpec.ManagerCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil && net.ParseIP(cidr) == nil {
+			return fmt.Errorf("manager_cidrs: %q must be an IP or CIDR", cidr)
+		}
+	}
+
+	if i.bootsBlankVolume() && i.hasUserData() {
+		return fmt.Errorf("user_data requires an image, it cannot be used with boot_cdrom only")
+	}
+
+	if i.ServerSpec.IPBlockID != "" && i.ServerSpec.PublicLanID == 0 {
+		return fmt.Errorf("ip_block_id requires public_lan_id")
+	}
+	if len(i.ServerSpec.SecondaryIPs) > 0 && i.ServerSpec.IPBlockID == "" {
+		return fmt.Errorf("secondary_ips requires ip_block_id")
+	}
+	for _, ip := range i.ServerSpec.SecondaryIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("secondary_ips: invalid IP %q", ip)
+		}
+	}
+
+	if err := i.validateStaticIPs(); err != nil {
+		return err
+	}
+
+	if i.UseIPv6 && !i.ServerSpec.IPv6 {
+		return fmt.Errorf("use_ipv6 requires ipv6 in server_spec")
+	}
+
+	if !slices.Contains([]string{"", decreaseDelete, decreaseStop, decreaseSuspend}, i.DecreaseAction) {
+		return fmt.Errorf("decrease_action can be 'delete', 'stop' or 'suspend'")
+	}
+	if i.DecreaseAction == decreaseSuspend && i.ServerSpec.Type != "CUBE" {
+		return fmt.Errorf("decrease_action 'suspend' can only be used with 'CUBE' type")
+	}
+
+	// Validate OS
+	if i.ServerSpec.OS != "" && !strings.EqualFold(i.ServerSpec.OS, osLinux) && !i.isWindows() {
+		return fmt.Errorf("os can be 'linux' or 'windows'")
+	}
+	licenceTypes := []string{"LINUX", "WINDOWS", "WINDOWS2016", "WINDOWS2022", "RHEL", "OTHER", "UNKNOWN"}
+	if licence := strings.ToUpper(i.ServerSpec.LicenceType); licence != "" {
+		if !slices.Contains(licenceTypes, licence) {
+			return fmt.Errorf("licence_type can be one of %s", strings.Join(licenceTypes, ", "))
+		}
+		if strings.EqualFold(i.ServerSpec.OS, osLinux) && strings.HasPrefix(licence, "WINDOWS") {
+			return fmt.Errorf("os is linux, but licence_type is %s", licence)
+		}
+	}
+	if i.isWindows() && i.ServerSpec.ImagePassword == "" && i.settings.Password == "" {
+		return fmt.Errorf("image_password is required for 'windows' to log in as Administrator")
+	}
+	if i.ServerSpec.RandomImagePassword {
+		if i.ServerSpec.ImagePassword != "" {
+			return fmt.Errorf("only one of image_password/random_image_password can be specified")
+		}
+		if i.isWindows() {
+			return fmt.Errorf("random_image_password cannot be used with 'windows', the runner logs in with the image password")
+		}
+	}
+	if i.ServerSpec.ImagePasswordKey != "" {
+		if !i.ServerSpec.RandomImagePassword {
+			return fmt.Errorf("image_password_key requires random_image_password")
+		}
+		if _, err := loadImagePasswordKey(i.ServerSpec.ImagePasswordKey); err != nil {
+			return err
+		}
+	}
+
+	if err := i.Depth.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// createServer posts a new server, trying the configured CPU families in order
+// until the datacenter accepts one. The server name carries an idempotency
+// token, so a server created by a request that failed on our side is found
+// before the request is repeated.
+func (i *InstanceGroup) createServer(ctx context.Context, spec resolvedSpec, dc DatacenterConfig, zone string, serverName string, index int) (compute.Server, error) {
+	var server compute.Server
+	var err error
+	order := i.specOrder()
+	for n, variant := range order {
+		server, err = i.createServerVariant(ctx, spec, dc, zone, serverName, index, variant)
+		if err == nil || !isCapacityError(err) || n == len(order)-1 {
+			break
+		}
+		i.log.Warn("No capacity for server spec, trying next", "spec", variant.Name, "next", order[n+1].Name, "err", err)
+	}
+	return server, err
+}
+
+// createServerVariant creates a server with server_spec overridden by the
+// variant, if any, in the availability zone zone, if set.
+func (i *InstanceGroup) createServerVariant(ctx context.Context, spec resolvedSpec, dc DatacenterConfig, zone string, serverName string, index int, variant *SpecVariant) (compute.Server, error) {
+	typ := i.specType(variant)
+	families := []string{""}
+	if typ == "ENTERPRISE" {
+		if variant != nil && variant.CpuFamily != "" {
+			families = []string{variant.CpuFamily}
+		} else if i.ServerSpec.CpuFamily != "" {
+			families = append([]string{i.ServerSpec.CpuFamily}, i.ServerSpec.CpuFamilyFallback...)
+		}
+	}
+
+	var server compute.Server
+	var err error
+	var publicIP string
+	if i.ServerSpec.IPBlockID != "" {
+		if publicIP, err = i.nextReservedIP(ctx); err != nil {
+			return compute.Server{}, err
+		}
+		defer func() {
+			if server.Id == nil {
+				i.reservedIPs.release(publicIP)
+			}
+		}()
+	}
+	var staticIP string
+	if i.ServerSpec.StaticIPs != nil {
+		if staticIP, err = i.nextStaticIP(ctx); err != nil {
+			return compute.Server{}, err
+		}
+		defer func() {
+			if server.Id == nil {
+				i.staticIPs.release(staticIP)
+			}
+		}()
+	}
+	for n, family := range families {
+		serverData, err2 := i.getPostServerData(ctx, spec, dc, serverName, index, family)
+		if err2 != nil {
+			return compute.Server{}, err2
+		}
+		i.applySpecVariant(spec, variant, &serverData)
+		if err2 := i.setInstanceUserData(ctx, &serverData, dc.ID, serverName, index, variant, staticIP); err2 != nil {
+			return compute.Server{}, err2
+		}
+		if staticIP != "" {
+			setStaticIP(&serverData, staticIP)
+		}
+		if zone != "" {
+			setZone(&serverData, zone)
+		}
+		if publicIP != "" {
+			i.addPublicNIC(&serverData, publicIP)
+		}
+		server, err = i.postServer(ctx, dc, serverName, serverData)
+		if err == nil || !isCpuFamilyUnavailable(err) || n == len(families)-1 {
+			break
+		}
+		i.log.Warn("CPU family not available, trying next", "cpu_family", family, "next", families[n+1], "err", err)
+	}
+
+	if err != nil && typ == "CUBE" && i.ServerSpec.EnterpriseFallback != nil && isCapacityError(err) {
+		i.log.Warn("No capacity for CUBE server, falling back to ENTERPRISE", "name", serverName, "err", err)
+		serverData, err2 := i.getPostServerData(ctx, spec, dc, serverName, index, "")
+		if err2 != nil {
+			return compute.Server{}, err2
+		}
+		i.applySpecVariant(spec, variant, &serverData)
+		if err2 := i.setInstanceUserData(ctx, &serverData, dc.ID, serverName, index, variant, staticIP); err2 != nil {
+			return compute.Server{}, err2
+		}
+		if staticIP != "" {
+			setStaticIP(&serverData, staticIP)
+		}
+		if zone != "" {
+			setZone(&serverData, zone)
+		}
+		if publicIP != "" {
+			i.addPublicNIC(&serverData, publicIP)
+		}
+		if err2 := i.toEnterprise(ctx, &serverData); err2 != nil {
+			return compute.Server{}, errors.Join(err, err2)
+		}
+		server, err = i.postServer(ctx, dc, serverName, serverData)
+	}
+	return server, err
+}
+
+// newServerName returns the name of the server with the given index,
+// including an idempotency token.
+func (i *InstanceGroup) newServerName(index int) string {
+	return fmt.Sprintf("%s-%d-%s", i.ServerSpec.Name, index, newIdempotencyToken())
+}
+
+// postServer creates a server, looking for a server created by an earlier
+// attempt before repeating the request.
+func (i *InstanceGroup) postServer(ctx context.Context, dc DatacenterConfig, serverName string, serverData compute.Server) (compute.Server, error) {
+	if i.dryRun("would create server", "name", serverName, "datacenter", dc.ID, "payload", payload(serverData)) {
+		return compute.Server{Id: StrPtr(serverName), Properties: serverData.Properties}, nil
+	}
+
+	attempt := 0
+	started := time.Now()
+	server, apiResponse, err := withRetry(ctx, i, "Increase", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+		attempt++
+		if attempt > 1 {
+			if existing, found := i.reconcileCreate(ctx, dc.ID, serverName); found {
+				return existing, nil, nil
+			}
+		}
+		return i.api.CreateServer(ctx, dc.ID, serverData)
+	})
+	if err != nil && mayHaveCreated(apiResponse) {
+		if existing, found := i.reconcileCreate(ctx, dc.ID, serverName); found {
+			server, err = existing, nil
+		}
+	}
+	i.audit(ctx, auditEvent{Action: "create", ResourceType: "server", ResourceID: shared.ToValueDefault(server.Id), Name: serverName, Datacenter: dc.ID}, apiResponse, err)
+	if err == nil {
+		done := i.trackRequest("create", shared.ToValueDefault(server.Id), apiResponse, started)
+		i.collectOnFailure(done, dc.ID, shared.ToValueDefault(server.Id), serverName)
+	}
+	return server, err
+}
+
+// resolveTemplate looks up the ID of template_name for 'CUBE' servers, or
+// selects the template by minimum resources.
+func (i *InstanceGroup) resolveTemplate(ctx context.Context, spec *resolvedSpec) error {
+	for n := range i.ServerSpecs {
+		v := &i.ServerSpecs[n]
+		if i.specType(v) != "CUBE" || v.TemplateName == "" {
+			continue
+		}
+		templateID, err := i.getTemplateID(ctx, v.TemplateName)
+		if err != nil {
+			return fmt.Errorf("server_specs[%d]: getting template id from template name: %w", n, err)
+		}
+		spec.VariantTemplateIDs[v] = templateID
+	}
+
+	if i.ServerSpec.Type == "CUBE" && i.ServerSpec.selectsTemplate() {
+		// The selection is kept, like a configured template.
+		if spec.TemplateID != "" {
+			return nil
+		}
+		template, err := i.selectTemplate(ctx)
+		if err != nil {
+			return err
+		}
+		spec.TemplateID, spec.TemplateName = *template.Id, *template.Properties.Name
+		return nil
+	}
+	if i.ServerSpec.Type != "CUBE" || i.ServerSpec.TemplateName == "" {
+		return nil
+	}
+	templateID, err := i.getTemplateID(ctx, i.ServerSpec.TemplateName)
+	if err != nil {
+		return fmt.Errorf("getting template id from template name: %w", err)
+	}
+	spec.TemplateID = templateID
 	return nil
 }
 
-func (i *InstanceGroup) getPostServerData(index int) compute.Server {
+// isCpuFamilyUnavailable reports whether the API rejected a server because the
+// requested CPU family does not exist in the datacenter.
+func isCpuFamilyUnavailable(err error) bool {
+	var apiErr shared.GenericOpenAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusUnprocessableEntity {
+		return false
+	}
+	body := strings.ToLower(string(apiErr.Body()))
+	return strings.Contains(body, "cpu") && strings.Contains(body, "family")
+}
+
+func (i *InstanceGroup) getPostServerData(ctx context.Context, spec resolvedSpec, dc DatacenterConfig, serverName string, index int, cpuFamily string) (compute.Server, error) {
 	var serverData compute.Server
 	var cores, ram *int32
 	var imagePassword *string
+	var serverZone, volumeZone *string
+	var family *string
 	var storageSize *float32
 	var templateID *string
 
-	name := i.ServerSpec.Name
 	serverType := i.ServerSpec.Type
-	lanID := i.ServerSpec.LanID
-	userdata := base64.StdEncoding.EncodeToString([]byte(i.ServerSpec.UserData))
-	volumeType := i.ServerSpec.VolumeType
+	lanID := i.lanID(dc)
+	volumeType := i.specVolumeType(nil)
 
 	if serverType == "CUBE" {
-		templateID = &i.ServerSpec.TemplateID
+		templateID = &spec.TemplateID
 	}
 
 	if serverType == "ENTERPRISE" {
@@ -267,19 +1200,91 @@ func (i *InstanceGroup) getPostServerData(index int) compute.Server {
 	// can be removed in the future if only private images will be used.
 	if i.ServerSpec.ImagePassword != "" {
 		imagePassword = &i.ServerSpec.ImagePassword
+	} else if i.ServerSpec.RandomImagePassword {
+		password, err := i.instanceImagePassword(serverName)
+		if err != nil {
+			return compute.Server{}, err
+		}
+		imagePassword = &password
+	}
+
+	if cpuFamily != "" {
+		family = &cpuFamily
+	}
+
+	if i.ServerSpec.AvailabilityZone != "" {
+		serverZone = &i.ServerSpec.AvailabilityZone
+	}
+	if i.ServerSpec.VolumeAvailabilityZone != "" {
+		volumeZone = &i.ServerSpec.VolumeAvailabilityZone
+	}
+
+	rules := i.nicFirewallRules()
+	firewallActive := len(rules) > 0
+	var nicEntities *compute.NicEntities
+	if firewallActive {
+		nicEntities = &compute.NicEntities{Firewallrules: firewallRules(rules)}
+	}
+
+	var image, licenceType *string
+	if spec.Image != "" {
+		image = &spec.Image
+	} else if i.ServerSpec.BootCdrom != "" {
+		// A blank volume for the installer on the CD-ROM to write to
+		licenceType = StrPtr("OTHER")
+	}
+	if i.ServerSpec.LicenceType != "" {
+		licenceType = StrPtr(strings.ToUpper(i.ServerSpec.LicenceType))
+	}
+
+	var bus *string
+	if i.ServerSpec.Bus != "" {
+		bus = &i.ServerSpec.Bus
+	}
+
+	var backupUnitID *string
+	if i.ServerSpec.BackupUnitID != "" {
+		backupUnitID = &i.ServerSpec.BackupUnitID
+	}
+
+	var userdata *string
+	if i.hasUserData() {
+		rendered, err := i.renderUserData(ctx, dc.ID, serverName, index, nil, "")
+		if err != nil {
+			return compute.Server{}, err
+		}
+		encoded, err := i.encodeUserData(rendered)
+		if err != nil {
+			return compute.Server{}, err
+		}
+		userdata = &encoded
+	}
+
+	var bootCdrom *compute.ResourceReference
+	var cdroms *compute.Cdroms
+	if i.ServerSpec.BootCdrom != "" {
+		bootCdrom = &compute.ResourceReference{Id: &i.ServerSpec.BootCdrom}
+		cdroms = &compute.Cdroms{Items: &[]compute.Image{{Id: &i.ServerSpec.BootCdrom}}}
 	}
 
 	serverData = compute.Server{
 		Entities: &compute.ServerEntities{
+			Cdroms: cdroms,
 			Volumes: &compute.AttachedVolumes{
 				Items: &[]compute.Volume{
 					{
 						Properties: &compute.VolumeProperties{
-							Image:         &i.ServerSpec.Image,
-							Type:          &volumeType,
-							UserData:      &userdata,
-							Size:          storageSize,
-							ImagePassword: imagePassword,
+							Name:             &serverName,
+							Image:            image,
+							LicenceType:      licenceType,
+							Type:             &volumeType,
+							UserData:         userdata,
+							Size:             storageSize,
+							ImagePassword:    imagePassword,
+							AvailabilityZone: volumeZone,
+							BackupunitId:     backupUnitID,
+							Bus:              bus,
+							SshKeys:          i.sshKeys(),
 						},
 					},
 				},
@@ -288,34 +1293,51 @@ func (i *InstanceGroup) getPostServerData(index int) compute.Server {
 				Items: &[]compute.Nic{
 					{
 						Properties: &compute.NicProperties{
-							Name:           StrPtr("privateNIC"),
+							Name:           StrPtr(privateNICName),
 							Lan:            &lanID,
-							FirewallActive: BoolPtr(false),
+							FirewallActive: BoolPtr(firewallActive),
 						},
+						Entities: nicEntities,
 					},
 				},
 			},
 		},
 		Properties: &compute.ServerProperties{
-			Cores:        cores,
-			Name:         StrPtr(fmt.Sprintf("%s-%d", name, index)),
-			Ram:          ram,
-			TemplateUuid: templateID,
-			Type:         &serverType,
+			AvailabilityZone: serverZone,
+			BootCdrom:        bootCdrom,
+			Cores:            cores,
+			CpuFamily:        family,
+			Name:             &serverName,
+			Ram:              ram,
+			TemplateUuid:     templateID,
+			Type:             &serverType,
 		},
 	}
-	return serverData
+	return serverData, nil
 }
 
-func (i *InstanceGroup) getTemplateID(templateName string) (string, error) {
-	templates, _, err := i.computeClient.TemplatesApi.TemplatesGet(context.Background()).Depth(1).Execute()
+func (i *InstanceGroup) getTemplateID(ctx context.Context, templateName string) (string, error) {
+	if id, ok := i.templates.get(templateName); ok {
+		return id, nil
+	}
+
+	templates, _, err := withRetry(ctx, i, "TemplatesGet", func(ctx context.Context) (compute.Templates, *shared.APIResponse, error) {
+		return i.api.ListTemplates(ctx)
+	})
 	if err != nil {
 		return "", err
 	}
 	for _, template := range *templates.Items {
 		if *template.Properties.Name == templateName {
+			i.templates.set(templateName, *template.Id)
 			return *template.Id, nil
 		}
 	}
 	return "", fmt.Errorf("template %s not found", templateName)
 }
+
+// bootsBlankVolume reports whether instances boot from a CD-ROM onto a volume
+// without image, where no cloud-init user data can be applied.
+func (i *InstanceGroup) bootsBlankVolume() bool {
+	return i.ServerSpec.BootCdrom != "" && i.ServerSpec.Image == ""
+}
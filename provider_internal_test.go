@@ -0,0 +1,174 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+func TestDecreaseChecksOwnership(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ids     []string
+		deleted []string
+		errs    []error
+	}{
+		{"own", []string{"own"}, []string{"own"}, nil},
+		{"other group", []string{"other-group"}, nil, []error{ErrNotGroupInstance}},
+		{"unlabelled", []string{"unrelated"}, nil, []error{ErrNotGroupInstance}},
+		{"protected", []string{"protected"}, nil, []error{ErrProtectedInstance}},
+		{"missing", []string{"missing"}, nil, []error{ErrClassNotFound}},
+		{
+			"mixed",
+			[]string{"own", "other-group", "protected", "missing"},
+			[]string{"own"},
+			[]error{ErrNotGroupInstance, ErrProtectedInstance, ErrClassNotFound},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{
+				servers: []compute.Server{
+					testServer("own", "runner-1-aaaa", "AVAILABLE"),
+					testServer("other-group", "runner-2-bbbb", "AVAILABLE"),
+					testServer("unrelated", "database", "AVAILABLE"),
+					testServer("protected", "runner-cache", "AVAILABLE"),
+				},
+				labels: []compute.Label{
+					testLabel("own", labelGroup, "runner"),
+					testLabel("other-group", labelGroup, "other"),
+				},
+			}
+			i := newTestGroup(api)
+			i.Protected = []string{"runner-cache"}
+
+			succeeded, err := i.Decrease(context.Background(), tc.ids)
+			if !slices.Equal(succeeded, tc.deleted) || !slices.Equal(api.deleted, tc.deleted) {
+				t.Errorf("Decrease succeeded for %v and deleted %v, want %v", succeeded, api.deleted, tc.deleted)
+			}
+			if len(tc.errs) == 0 && err != nil {
+				t.Errorf("Decrease: %v", err)
+			}
+			for _, want := range tc.errs {
+				if !errors.Is(err, want) {
+					t.Errorf("Decrease error %v does not report %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateMapsStates(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		state string
+		mark  func(r *registry, id string)
+		// want is empty for instances Update hides from the runner.
+		want provider.State
+	}{
+		{"available", "AVAILABLE", nil, provider.StateRunning},
+		{"busy", "BUSY", nil, provider.StateCreating},
+		{"inactive", "INACTIVE", nil, provider.StateDeleted},
+		{"standby", "AVAILABLE", func(r *registry, id string) { r.setStandby(id, true) }, ""},
+		{"stopped", "AVAILABLE", func(r *registry, id string) { r.setStopped(id, true) }, ""},
+		{"starting", "BUSY", func(r *registry, id string) { r.setStarting(id, true) }, provider.StateCreating},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(&mockCompute{servers: []compute.Server{testServer("server", "runner-1-aaaa", tc.state)}})
+			if tc.mark != nil {
+				tc.mark(i.registry, "server")
+			}
+
+			states := make(map[string]provider.State)
+			err := i.Update(context.Background(), func(instance string, state provider.State) {
+				states[instance] = state
+			})
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if state, ok := states["server"]; state != tc.want || ok != (tc.want != "") || len(states) > 1 {
+				t.Errorf("Update reported %v, want %q", states, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateServerCpuFamilyFallback(t *testing.T) {
+	unavailable := apiError(http.StatusUnprocessableEntity, "cpu family INTEL_ICELAKE is not available")
+	for _, tc := range []struct {
+		name    string
+		variant *SpecVariant
+		errs    []error
+		want    []string
+		err     bool
+	}{
+		{"first family", nil, nil, []string{"INTEL_ICELAKE"}, false},
+		{"fallback", nil, []error{unavailable}, []string{"INTEL_ICELAKE", "AMD_EPYC"}, false},
+		{"all unavailable", nil, []error{unavailable, unavailable, unavailable}, []string{"INTEL_ICELAKE", "AMD_EPYC", "INTEL_SKYLAKE"}, true},
+		// Other errors are not about the CPU family and fail at once.
+		{"other error", nil, []error{apiError(http.StatusBadRequest, "invalid")}, []string{"INTEL_ICELAKE"}, true},
+		{"variant family", &SpecVariant{CpuFamily: "AMD_EPYC"}, []error{unavailable}, []string{"AMD_EPYC"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{createErrs: tc.errs}
+			i := newTestGroup(api)
+			i.Retry.MaxAttempts = 1
+			i.ServerSpec.Type = "ENTERPRISE"
+			i.ServerSpec.CpuFamily = "INTEL_ICELAKE"
+			i.ServerSpec.CpuFamilyFallback = []string{"AMD_EPYC", "INTEL_SKYLAKE"}
+
+			_, err := i.createServerVariant(context.Background(), resolvedSpec{Image: "image"}, DatacenterConfig{ID: "dc1"}, "", "runner-1-aaaa", 1, tc.variant)
+			if (err != nil) != tc.err {
+				t.Errorf("createServerVariant error %v, want error %t", err, tc.err)
+			}
+			var families []string
+			for _, server := range api.posted {
+				families = append(families, *server.Properties.CpuFamily)
+			}
+			if !slices.Equal(families, tc.want) {
+				t.Errorf("createServerVariant tried the CPU families %v, want %v", families, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdatePaginates(t *testing.T) {
+	runners := []string{"runner-1-aaaa", "runner-2-bbbb", "runner-3-cccc", "runner-4-dddd", "runner-5-eeee"}
+	for _, tc := range []struct {
+		name      string
+		servers   []string
+		pageLinks bool
+		calls     int
+	}{
+		{"without links", runners, false, 3},
+		{"with links", runners, true, 3},
+		// The filter drops the database server from the first page, which
+		// is short but links the next one.
+		{"short page", slices.Insert(slices.Clone(runners), 1, "database"), true, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{pageLinks: tc.pageLinks}
+			for n, name := range tc.servers {
+				api.servers = append(api.servers, testServer(fmt.Sprintf("server-%d", n), name, "AVAILABLE"))
+			}
+			i := newTestGroup(api)
+			i.PageSize = 2
+
+			reported := 0
+			if err := i.Update(context.Background(), func(string, provider.State) { reported++ }); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if reported != len(runners) {
+				t.Errorf("Update reported %d instances, want %d", reported, len(runners))
+			}
+			if api.listCalls != tc.calls {
+				t.Errorf("Update listed %d pages, want %d", api.listCalls, tc.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package ionos_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/codecentric/fleeting-plugin-ionos"
+	"github.com/codecentric/fleeting-plugin-ionos/internal/fakeapi"
+	"github.com/hashicorp/go-hclog"
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+const testConfig = `{
+	"datacenter_id": "dc1",
+	"ionos_token": "token",
+	"server_spec": {
+		"type": "ENTERPRISE",
+		"name": "runner",
+		"lan_id": 1,
+		"volume_type": "HDD",
+		"cores": 1,
+		"ram": 1024,
+		"storage_size": 10,
+		"image": "image",
+		"user_data": "#cloud-config"
+	}
+}`
+
+// newTestGroup starts a fake API and returns an instance group configured
+// against it.
+func newTestGroup(t *testing.T) *ionos.InstanceGroup {
+	t.Helper()
+	fake := fakeapi.New()
+	fake.BootDelay = 0
+	ts := fake.Start()
+	t.Cleanup(ts.Close)
+	t.Setenv("IONOS_API_URL", ts.URL)
+
+	group := &ionos.InstanceGroup{}
+	if err := json.Unmarshal([]byte(testConfig), group); err != nil {
+		t.Fatal(err)
+	}
+	return group
+}
+
+func update(t *testing.T, ctx context.Context, group *ionos.InstanceGroup) map[string]provider.State {
+	t.Helper()
+	states := make(map[string]provider.State)
+	err := group.Update(ctx, func(instance string, state provider.State) {
+		states[instance] = state
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	return states
+}
+
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	group := newTestGroup(t)
+
+	info, err := group.Init(ctx, hclog.NewNullLogger(), provider.Settings{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if info.ID != "ionos" || info.MaxSize <= 0 {
+		t.Errorf("Init returned %+v", info)
+	}
+
+	succeeded, err := group.Increase(ctx, 2)
+	if err != nil || succeeded != 2 {
+		t.Fatalf("Increase(2) = %d, %v", succeeded, err)
+	}
+
+	states := update(t, ctx, group)
+	if len(states) != 2 {
+		t.Fatalf("Update reported %d instances, want 2: %v", len(states), states)
+	}
+	var ids []string
+	for id, state := range states {
+		if state != provider.StateRunning {
+			t.Errorf("instance %s is %s, want %s", id, state, provider.StateRunning)
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	connect, err := group.ConnectInfo(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("ConnectInfo: %v", err)
+	}
+	if connect.ID != ids[0] || connect.InternalAddr == "" {
+		t.Errorf("ConnectInfo returned %+v", connect)
+	}
+
+	deleted, err := group.Decrease(ctx, ids)
+	if err != nil {
+		t.Fatalf("Decrease: %v", err)
+	}
+	slices.Sort(deleted)
+	if !slices.Equal(deleted, ids) {
+		t.Errorf("Decrease deleted %v, want %v", deleted, ids)
+	}
+	if states := update(t, ctx, group); len(states) != 0 {
+		t.Errorf("Update after Decrease reported %v", states)
+	}
+
+	if err := group.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+}
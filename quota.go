@@ -0,0 +1,154 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// ErrQuotaExceeded is returned when creating instances would exceed the
+// resource limits of the IONOS contract.
+var ErrQuotaExceeded = errors.New("quota would be exceeded")
+
+// checkQuota verifies that delta more instances stay within max_size, given
+// current instances, and that the contract has enough cores and RAM left for
+// them. The contract limits have no server count, so max_size is the only
+// limit on the number of instances. With server_specs, the largest variant is
+// assumed, as any of them may be created.
+func (i *InstanceGroup) checkQuota(ctx context.Context, current, delta int) error {
+	if current+delta > i.MaxSize {
+		return fmt.Errorf("%w: %d instances needed, max_size of %d allows %d more", ErrQuotaExceeded, delta, i.MaxSize, max(0, i.MaxSize-current))
+	}
+
+	cores, ram, err := i.serverResources(ctx)
+	if err != nil {
+		return fmt.Errorf("resolving server resources: %w", err)
+	}
+
+	limits, err := i.resourceLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("getting contract resource limits: %w", err)
+	}
+
+	if limits.CoresPerServer != nil && cores > *limits.CoresPerServer {
+		return fmt.Errorf("%w: %d cores per server requested, the contract allows %d", ErrQuotaExceeded, cores, *limits.CoresPerServer)
+	}
+	if limits.RamPerServer != nil && ram > *limits.RamPerServer {
+		return fmt.Errorf("%w: %d MB RAM per server requested, the contract allows %d", ErrQuotaExceeded, ram, *limits.RamPerServer)
+	}
+	if limits.CoresPerContract != nil && limits.CoresProvisioned != nil {
+		available := *limits.CoresPerContract - *limits.CoresProvisioned
+		if needed := cores * int32(delta); needed > available {
+			return fmt.Errorf("%w: %d cores needed for %d instances, %d available", ErrQuotaExceeded, needed, delta, available)
+		}
+	}
+	if limits.RamPerContract != nil && limits.RamProvisioned != nil {
+		available := *limits.RamPerContract - *limits.RamProvisioned
+		if needed := ram * int32(delta); needed > available {
+			return fmt.Errorf("%w: %d MB RAM needed for %d instances, %d MB available", ErrQuotaExceeded, needed, delta, available)
+		}
+	}
+	return nil
+}
+
+func (i *InstanceGroup) resourceLimits(ctx context.Context) (compute.ResourceLimits, error) {
+	contracts, _, err := withRetry(ctx, i, "ContractsGet", func(ctx context.Context) (compute.Contracts, *shared.APIResponse, error) {
+		return i.api.ListContracts(ctx)
+	})
+	if err != nil {
+		return compute.ResourceLimits{}, err
+	}
+	if contracts.Items == nil || len(*contracts.Items) == 0 {
+		return compute.ResourceLimits{}, fmt.Errorf("no contract found")
+	}
+	contract := (*contracts.Items)[0]
+	if contract.Properties == nil || contract.Properties.ResourceLimits == nil {
+		return compute.ResourceLimits{}, fmt.Errorf("contract has no resource limits")
+	}
+	return *contract.Properties.ResourceLimits, nil
+}
+
+// serverResources returns the cores and RAM (in MB) of a single instance, the
+// largest of the server_specs variants if there are any. For 'CUBE' servers
+// they are defined by the template.
+func (i *InstanceGroup) serverResources(ctx context.Context) (int32, int32, error) {
+	spec := i.spec()
+	var cores, ram int32
+	for _, v := range i.specVariants() {
+		c, r, err := i.variantResources(ctx, spec, v)
+		if err != nil {
+			return 0, 0, err
+		}
+		cores, ram = max(cores, c), max(ram, r)
+	}
+	return cores, ram, nil
+}
+
+// variantResources returns the cores and RAM of an instance created with v,
+// or with server_spec alone if v is nil.
+func (i *InstanceGroup) variantResources(ctx context.Context, spec resolvedSpec, v *SpecVariant) (int32, int32, error) {
+	if i.specType(v) != "CUBE" {
+		cores, ram := i.ServerSpec.Cores, i.ServerSpec.Ram
+		if v != nil && v.Cores != 0 {
+			cores = v.Cores
+		}
+		if v != nil && v.Ram != 0 {
+			ram = v.Ram
+		}
+		return cores, ram, nil
+	}
+
+	templateID := spec.variantTemplateID(v)
+	template, _, err := withRetry(ctx, i, "TemplatesFindById", func(ctx context.Context) (compute.Template, *shared.APIResponse, error) {
+		return i.api.GetTemplate(ctx, templateID)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if template.Properties == nil || template.Properties.Cores == nil || template.Properties.Ram == nil {
+		return 0, 0, fmt.Errorf("template %s has no cores or RAM", templateID)
+	}
+	return int32(*template.Properties.Cores), int32(*template.Properties.Ram), nil
+}
+
+// QuotaHeadroom is how much of the contract resource limits is left.
+type QuotaHeadroom struct {
+	CoresAvailable int32 `json:"cores_available"`
+	// RAMAvailable is in MB.
+	RAMAvailable int32 `json:"ram_available"`
+	// Instances is how many more instances of the server spec fit, within
+	// max_size.
+	Instances int `json:"instances"`
+}
+
+// quotaHeadroom returns the cores and RAM left in the contract and how many
+// instances of the server spec they and max_size fit.
+func (i *InstanceGroup) quotaHeadroom(ctx context.Context) (QuotaHeadroom, error) {
+	current, err := i.countInstances(ctx)
+	if err != nil {
+		return QuotaHeadroom{}, fmt.Errorf("counting instances: %w", err)
+	}
+	cores, ram, err := i.serverResources(ctx)
+	if err != nil {
+		return QuotaHeadroom{}, fmt.Errorf("resolving server resources: %w", err)
+	}
+	limits, err := i.resourceLimits(ctx)
+	if err != nil {
+		return QuotaHeadroom{}, fmt.Errorf("getting contract resource limits: %w", err)
+	}
+	if limits.CoresPerContract == nil || limits.CoresProvisioned == nil || limits.RamPerContract == nil || limits.RamProvisioned == nil {
+		return QuotaHeadroom{}, fmt.Errorf("contract has no core or RAM limits")
+	}
+
+	headroom := QuotaHeadroom{
+		CoresAvailable: *limits.CoresPerContract - *limits.CoresProvisioned,
+		RAMAvailable:   *limits.RamPerContract - *limits.RamProvisioned,
+	}
+	if cores > 0 && ram > 0 {
+		headroom.Instances = max(0, min(int(headroom.CoresAvailable/cores), int(headroom.RAMAvailable/ram), i.MaxSize-current))
+	}
+	return headroom, nil
+}
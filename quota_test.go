@@ -0,0 +1,55 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestCheckQuota(t *testing.T) {
+	int32Ptr := func(n int32) *int32 { return &n }
+	limits := compute.ResourceLimits{
+		CoresPerServer:   int32Ptr(8),
+		RamPerServer:     int32Ptr(16384),
+		CoresPerContract: int32Ptr(40),
+		CoresProvisioned: int32Ptr(20),
+		RamPerContract:   int32Ptr(81920),
+		RamProvisioned:   int32Ptr(40960),
+	}
+	cube := compute.Template{
+		Id:         StrPtr("cube-xl"),
+		Properties: &compute.TemplateProperties{Cores: FloatPtr(4), Ram: FloatPtr(32768)},
+	}
+
+	tests := []struct {
+		name     string
+		variants []SpecVariant
+		current  int
+		delta    int
+		exceeded bool
+	}{
+		{name: "server spec fits", delta: 5},
+		{name: "cores of server spec exceed the contract", delta: 6, exceeded: true},
+		{name: "largest variant is checked", variants: []SpecVariant{{Cores: 2}, {Cores: 4}}, delta: 6, exceeded: true},
+		{name: "smaller variants fit", variants: []SpecVariant{{Cores: 2, Ram: 4096}, {Cores: 2, Ram: 4096}}, delta: 10},
+		{name: "variant above the per server limit", variants: []SpecVariant{{}, {Cores: 16}}, delta: 1, exceeded: true},
+		{name: "template of a CUBE variant", variants: []SpecVariant{{}, {Type: "CUBE", TemplateID: "cube-xl"}}, delta: 1, exceeded: true},
+		{name: "max_size is reached", current: 9, delta: 2, exceeded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestGroup(&mockCompute{limits: limits, templates: []compute.Template{cube}})
+			i.MaxSize = 10
+			i.ServerSpec.Type = "ENTERPRISE"
+			i.ServerSpec.Cores, i.ServerSpec.Ram = 4, 8192
+			i.ServerSpecs = tt.variants
+
+			err := i.checkQuota(context.Background(), tt.current, tt.delta)
+			if errors.Is(err, ErrQuotaExceeded) != tt.exceeded {
+				t.Errorf("checkQuota(%d, %d) = %v, want exceeded %v", tt.current, tt.delta, err, tt.exceeded)
+			}
+		})
+	}
+}
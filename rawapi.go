@@ -0,0 +1,80 @@
+package ionos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// rawRequest calls the Cloud API with the configuration of the compute client
+// for fields the bundled SDK does not model yet. Errors are returned as
+// shared.GenericOpenAPIError like the generated clients do.
+func (i *InstanceGroup) rawRequest(ctx context.Context, method, path string, body, out any) (*shared.APIResponse, error) {
+	cfg := i.api.Config()
+	if len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("no API server configured")
+	}
+	return i.rawRequestURL(ctx, method, strings.TrimSuffix(cfg.Servers[0].URL, "/")+path, body, out)
+}
+
+// rawRequestURL is rawRequest for APIs outside the Cloud API, e.g. VM Auto
+// Scaling, which use the same credentials.
+func (i *InstanceGroup) rawRequestURL(ctx context.Context, method, url string, body, out any) (*shared.APIResponse, error) {
+	cfg := i.api.Config()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", cfg.UserAgent)
+	}
+	for key, value := range cfg.DefaultHeader {
+		req.Header.Set(key, value)
+	}
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	} else if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	apiResponse := shared.NewAPIResponse(resp)
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apiResponse, err
+	}
+	if resp.StatusCode >= 300 {
+		return apiResponse, *shared.NewGenericOpenAPIError(fmt.Sprintf("%s %s: %s", method, req.URL.Path, resp.Status), data, nil, resp.StatusCode)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return apiResponse, err
+		}
+	}
+	return apiResponse, nil
+}
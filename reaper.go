@@ -0,0 +1,87 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const defaultReaperInterval = Duration(5 * time.Minute)
+
+// startReaper runs ReapOrphans periodically when orphan_ttl is configured. It
+// only starts reaping once the plugin has been running for the TTL, so the
+// runner has had the chance to ask about the instances it knows.
+func (i *InstanceGroup) startReaper() {
+	if i.OrphanTTL <= 0 {
+		return
+	}
+	interval := i.ReaperInterval
+	if interval <= 0 {
+		interval = defaultReaperInterval
+	}
+	ttl := time.Duration(i.OrphanTTL)
+	i.runPeriodic("orphan-reaper", time.Duration(interval), func(ctx context.Context) error {
+		if time.Since(i.startedAt) < ttl {
+			return nil
+		}
+		_, err := i.ReapOrphans(ctx, ttl)
+		return err
+	})
+}
+
+// ReapOrphans deletes group servers that Update has not reported and the
+// runner has not asked about for longer than ttl, e.g. because the runner
+// manager crashed between creating and registering them. It returns the IDs of the deleted servers.
+func (i *InstanceGroup) ReapOrphans(ctx context.Context, ttl time.Duration) ([]string, error) {
+	ctx = withOperation(ctx, "ReapOrphans")
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []string
+	for _, server := range servers {
+		id := *server.Id
+		if *server.Metadata.State == "BUSY" {
+			continue
+		}
+		if rec, ok := i.registry.get(id); ok && (rec.Standby || rec.Stopped) {
+			continue
+		}
+		if i.isProtected(server) {
+			continue
+		}
+
+		var lastSeen time.Time
+		if server.Metadata.CreatedDate != nil {
+			lastSeen = server.Metadata.CreatedDate.Time
+		}
+		if rec, ok := i.registry.get(id); ok && rec.LastSeen.After(lastSeen) {
+			lastSeen = rec.LastSeen
+		}
+		if time.Since(lastSeen) < ttl {
+			continue
+		}
+
+		dc := i.datacenterOf(ctx, id)
+		if i.dryRun("would delete orphaned instance", "id", id, "name", *server.Properties.Name, "last_seen", lastSeen) {
+			continue
+		}
+		i.log.Warn("Deleting orphaned instance", "id", id, "name", *server.Properties.Name, "last_seen", lastSeen)
+		apiResponse, err2 := withRetryNoResult(ctx, i, "ServersDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteServer(ctx, dc, id)
+		})
+		i.audit(ctx, auditEvent{Action: "delete", ResourceType: "server", ResourceID: id, Name: *server.Properties.Name, Datacenter: dc}, apiResponse, err2)
+		if err2 != nil {
+			i.log.Error("Failed to delete orphaned instance", "err", err2, "id", id)
+			err = errors.Join(err, err2)
+			continue
+		}
+		i.registry.remove(id)
+		i.closeTunnel(id)
+		reaped = append(reaped, id)
+	}
+	return reaped, err
+}
@@ -0,0 +1,185 @@
+package ionos
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	redacted = "<redacted>"
+	// minSecretLength keeps short values such as "1" from being masked
+	// everywhere.
+	minSecretLength = 4
+)
+
+// secrets holds the values that are masked in log output and error
+// strings: tokens, passwords and the values of user_data placeholders. It is
+// package wide, as errors are formatted without access to their group.
+var secrets secretSet
+
+type secretSet struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// add registers values to be masked.
+func (s *secretSet) add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+		if _, ok := s.values[value]; ok {
+			continue
+		}
+		if s.values == nil {
+			s.values = make(map[string]struct{})
+		}
+		s.values[value] = struct{}{}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	// Longer values first, so a secret containing another one is masked
+	// as a whole.
+	sorted := make([]string, 0, len(s.values))
+	for value := range s.values {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(a, b int) bool { return len(sorted[a]) > len(sorted[b]) })
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, value := range sorted {
+		pairs = append(pairs, value, redacted)
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+}
+
+// redact masks the registered secrets in text.
+func (s *secretSet) redact(text string) string {
+	s.mu.RLock()
+	replacer := s.replacer
+	s.mu.RUnlock()
+	if replacer == nil {
+		return text
+	}
+	return replacer.Replace(text)
+}
+
+// redactingLogger masks secrets in the message and arguments of every log
+// line, including those of loggers derived from it.
+type redactingLogger struct {
+	hclog.Logger
+}
+
+func newRedactingLogger(logger hclog.Logger) hclog.Logger {
+	if _, ok := logger.(redactingLogger); ok {
+		return logger
+	}
+	return redactingLogger{logger}
+}
+
+func (l redactingLogger) Log(level hclog.Level, msg string, args ...any) {
+	l.Logger.Log(level, secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Trace(msg string, args ...any) {
+	l.Logger.Trace(secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Debug(msg string, args ...any) {
+	l.Logger.Debug(secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Info(msg string, args ...any) {
+	l.Logger.Info(secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Warn(msg string, args ...any) {
+	l.Logger.Warn(secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Error(msg string, args ...any) {
+	l.Logger.Error(secrets.redact(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) With(args ...any) hclog.Logger {
+	return redactingLogger{l.Logger.With(redactArgs(args)...)}
+}
+
+func (l redactingLogger) Named(name string) hclog.Logger {
+	return redactingLogger{l.Logger.Named(name)}
+}
+
+func (l redactingLogger) ResetNamed(name string) hclog.Logger {
+	return redactingLogger{l.Logger.ResetNamed(name)}
+}
+
+// redactArgs masks secrets in string, error and Stringer arguments.
+func redactArgs(args []any) []any {
+	out := make([]any, len(args))
+	for n, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			out[n] = secrets.redact(v)
+		case error:
+			out[n] = secrets.redact(v.Error())
+		case fmt.Stringer:
+			out[n] = secrets.redact(v.String())
+		default:
+			out[n] = arg
+		}
+	}
+	return out
+}
+
+// redactedFields are request body fields that are masked in dry run logs as
+// a whole. User data is base64 encoded, so the secrets it contains would not
+// be found in it.
+var redactedFields = map[string]bool{
+	"userData":      true,
+	"imagePassword": true,
+}
+
+// redactPayload masks redactedFields anywhere in a JSON document.
+func redactPayload(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if redactedFields[key] {
+				v[key] = redacted
+			} else {
+				v[key] = redactPayload(value)
+			}
+		}
+	case []any:
+		for n, value := range v {
+			v[n] = redactPayload(value)
+		}
+	}
+	return v
+}
+
+// redactJSON masks redactedFields and secrets in a JSON document.
+func redactJSON(data []byte) string {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return secrets.redact(string(data))
+	}
+	var masked strings.Builder
+	enc := json.NewEncoder(&masked)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactPayload(doc)); err != nil {
+		return secrets.redact(string(data))
+	}
+	return secrets.redact(strings.TrimSuffix(masked.String(), "\n"))
+}
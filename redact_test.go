@@ -0,0 +1,61 @@
+package ionos
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestRedact(t *testing.T) {
+	secrets.add("s3cr3t-token", "s3cr3t-token-suffix", "abc")
+
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"token s3cr3t-token", "token " + redacted},
+		{"token s3cr3t-token-suffix", "token " + redacted},
+		{"short abc stays", "short abc stays"},
+	} {
+		if got := secrets.redact(tc.text); got != tc.want {
+			t.Errorf("redact(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	secrets.add("logger-secret")
+	var out bytes.Buffer
+	log := newRedactingLogger(hclog.New(&hclog.LoggerOptions{Output: &out}))
+
+	log.With("token", "logger-secret").Info("using logger-secret", "err", errors.New("bad logger-secret"))
+	if strings.Contains(out.String(), "logger-secret") {
+		t.Errorf("log output contains the secret: %s", out.String())
+	}
+	if strings.Count(out.String(), redacted) != 3 {
+		t.Errorf("log output does not mask every occurrence: %s", out.String())
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	secrets.add("json-secret")
+
+	for _, tc := range []struct {
+		data string
+		want string
+	}{
+		{
+			`{"properties":{"name":"json-secret","userData":"dXNlcg==","imagePassword":"pw"}}`,
+			`{"properties":{"imagePassword":"<redacted>","name":"<redacted>","userData":"<redacted>"}}`,
+		},
+		{`{"properties":{"name":"runner"}}`, `{"properties":{"name":"runner"}}`},
+		{`not json with json-secret`, `not json with <redacted>`},
+	} {
+		if got := redactJSON([]byte(tc.data)); got != tc.want {
+			t.Errorf("redactJSON(%s) = %s, want %s", tc.data, got, tc.want)
+		}
+	}
+}
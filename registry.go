@@ -0,0 +1,218 @@
+package ionos
+
+import (
+	"sync"
+	"time"
+)
+
+// instanceRecord is what the plugin knows about an instance beyond what the
+// API returns.
+type instanceRecord struct {
+	ID string
+	// LastSeen is the last time the instance was reported to the runner by
+	// Update or the runner asked about it through ConnectInfo or Heartbeat,
+	// or when it was created.
+	LastSeen time.Time
+	// Adopted is set for instances found at Init that were created by an
+	// earlier run of the plugin.
+	Adopted bool
+	// DatacenterID is the datacenter the instance runs in.
+	DatacenterID string
+	// Zone is the availability zone of the instance, if known.
+	Zone string
+	// Standby is set for warm pool instances that have not been handed out
+	// to the runner yet.
+	Standby bool
+	// Claimed is set while Increase checks a standby instance before
+	// handing it out, so a concurrent Increase does not take it too.
+	Claimed bool
+	// Stopped is set for instances Decrease powered off instead of deleting
+	// them.
+	Stopped bool
+	// Starting is set for stopped instances Increase started again until
+	// they are running, so Update does not report them as deleted while they
+	// are still INACTIVE.
+	Starting bool
+	// HeartbeatFailures counts the consecutive heartbeats that found the
+	// instance unhealthy.
+	HeartbeatFailures int
+	// Replacing is set while an instance that kept failing heartbeats is
+	// being deleted.
+	Replacing bool
+}
+
+// registry tracks the instances of the group within the running plugin.
+type registry struct {
+	mu        sync.Mutex
+	instances map[string]*instanceRecord
+}
+
+func newRegistry() *registry {
+	return &registry{instances: make(map[string]*instanceRecord)}
+}
+
+// record returns the record for id, creating it when needed. It must be
+// called with mu held.
+func (r *registry) record(id string) *instanceRecord {
+	rec, ok := r.instances[id]
+	if !ok {
+		rec = &instanceRecord{ID: id}
+		r.instances[id] = rec
+	}
+	return rec
+}
+
+// touch marks the instance as seen by the runner.
+func (r *registry) touch(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).LastSeen = time.Now()
+}
+
+// adopt registers an instance created by an earlier run of the plugin.
+func (r *registry) adopt(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).Adopted = true
+}
+
+// setDatacenter records the datacenter of an instance.
+func (r *registry) setDatacenter(id, datacenterID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).DatacenterID = datacenterID
+}
+
+// setZone records the availability zone of an instance.
+func (r *registry) setZone(id, zone string) {
+	if zone == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).Zone = zone
+}
+
+func (r *registry) setStandby(id string, standby bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).Standby = standby
+}
+
+// standby returns the warm pool instances that have not been handed out.
+func (r *registry) standby() []instanceRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []instanceRecord
+	for _, rec := range r.instances {
+		if rec.Standby {
+			records = append(records, *rec)
+		}
+	}
+	return records
+}
+
+// claimStandby claims a standby instance that is neither claimed by another
+// caller nor in skip. Claimed instances stay standby, so the warm pool does
+// not replace them, until they are handed out or released.
+func (r *registry) claimStandby(skip map[string]bool) (instanceRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range r.instances {
+		if rec.Standby && !rec.Claimed && !skip[rec.ID] {
+			rec.Claimed = true
+			return *rec, true
+		}
+	}
+	return instanceRecord{}, false
+}
+
+// releaseStandby returns a claimed instance to the warm pool, or hands it out
+// to the runner.
+func (r *registry) releaseStandby(id string, handOut bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec, ok := r.instances[id]; ok {
+		rec.Claimed = false
+		if handOut {
+			rec.Standby = false
+			rec.LastSeen = time.Now()
+		}
+	}
+}
+
+func (r *registry) setStopped(id string, stopped bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).Stopped = stopped
+}
+
+func (r *registry) setStarting(id string, starting bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(id).Starting = starting
+}
+
+// stopped returns the instances Decrease powered off.
+func (r *registry) stopped() []instanceRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []instanceRecord
+	for _, rec := range r.instances {
+		if rec.Stopped {
+			records = append(records, *rec)
+		}
+	}
+	return records
+}
+
+// heartbeatFailed counts an unhealthy heartbeat and returns the number of
+// consecutive ones.
+func (r *registry) heartbeatFailed(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.record(id)
+	rec.HeartbeatFailures++
+	return rec.HeartbeatFailures
+}
+
+func (r *registry) heartbeatPassed(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec, ok := r.instances[id]; ok {
+		rec.HeartbeatFailures = 0
+	}
+}
+
+// setReplacing marks an instance as being replaced and reports whether it
+// was not already.
+func (r *registry) setReplacing(id string, replacing bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.record(id)
+	changed := rec.Replacing != replacing
+	rec.Replacing = replacing
+	return changed
+}
+
+func (r *registry) get(id string) (instanceRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.instances[id]
+	if !ok {
+		return instanceRecord{}, false
+	}
+	return *rec, true
+}
+
+func (r *registry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.instances)
+}
+
+func (r *registry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.instances, id)
+}
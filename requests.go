@@ -0,0 +1,349 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	defaultShutdownTimeout = Duration(time.Minute)
+
+	// maxLoggedInstances bounds the request log, the instances whose last
+	// request is oldest are dropped first.
+	maxLoggedInstances = 500
+	// maxInstanceRequests is the number of requests kept per instance.
+	maxInstanceRequests = 10
+)
+
+// Request statuses as reported by the IONOS API. Requests the plugin made
+// are RUNNING until they finished.
+const (
+	requestQueued  = "QUEUED"
+	requestRunning = "RUNNING"
+	requestDone    = "DONE"
+	requestFailed  = "FAILED"
+)
+
+// RequestInfo is an asynchronous IONOS API request that creates, deletes or
+// powers a server, e.g. to quote its ID to IONOS support.
+type RequestInfo struct {
+	RequestID string    `json:"request_id"`
+	Action    string    `json:"action"`
+	ServerID  string    `json:"server_id,omitempty"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	Started   time.Time `json:"started"`
+}
+
+// pendingRequests tracks the requests the plugin waits for, so Shutdown can
+// drain them.
+type pendingRequests struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	requests map[string]RequestInfo
+}
+
+func (p *pendingRequests) add(location string, req RequestInfo) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.draining {
+		return false
+	}
+	if p.requests == nil {
+		p.requests = make(map[string]RequestInfo)
+	}
+	p.requests[location] = req
+	p.wg.Add(1)
+	return true
+}
+
+func (p *pendingRequests) done(location string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.requests, location)
+	p.wg.Done()
+}
+
+func (p *pendingRequests) list() []RequestInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	requests := make([]RequestInfo, 0, len(p.requests))
+	for _, req := range p.requests {
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// requestLog keeps the last requests of each instance.
+type requestLog struct {
+	mu         sync.Mutex
+	byInstance map[string][]RequestInfo
+	// order lists the instances by their last request, oldest first.
+	order []string
+}
+
+// record adds a request or updates it by its ID.
+func (l *requestLog) record(req RequestInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.byInstance == nil {
+		l.byInstance = make(map[string][]RequestInfo)
+	}
+
+	requests := l.byInstance[req.ServerID]
+	if n := slices.IndexFunc(requests, func(r RequestInfo) bool { return r.RequestID == req.RequestID }); n >= 0 {
+		requests[n] = req
+		return
+	}
+	requests = append(requests, req)
+	if len(requests) > maxInstanceRequests {
+		requests = requests[len(requests)-maxInstanceRequests:]
+	}
+	l.byInstance[req.ServerID] = requests
+
+	l.order = slices.DeleteFunc(l.order, func(id string) bool { return id == req.ServerID })
+	l.order = append(l.order, req.ServerID)
+	if len(l.order) > maxLoggedInstances {
+		delete(l.byInstance, l.order[0])
+		l.order = l.order[1:]
+	}
+}
+
+// get returns the requests of an instance, oldest first.
+func (l *requestLog) get(instance string) []RequestInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.byInstance[instance])
+}
+
+// InstanceRequests returns the server requests this plugin made for an
+// instance and their status, oldest first.
+func (i *InstanceGroup) InstanceRequests(instance string) []RequestInfo {
+	return i.requestLog.get(instance)
+}
+
+// withRequestContext adds the last failed request of an instance to err,
+// e.g. the create request of an instance that never became healthy.
+func (i *InstanceGroup) withRequestContext(instance string, err error) error {
+	if err == nil {
+		return nil
+	}
+	requests := i.requestLog.get(instance)
+	for n := len(requests) - 1; n >= 0; n-- {
+		if req := requests[n]; req.Status == requestFailed {
+			return fmt.Errorf("%w (%s request %s failed: %s)", err, req.Action, req.RequestID, req.Message)
+		}
+	}
+	return err
+}
+
+// trackRequest records the asynchronous request of a server create, delete
+// or power change started at started and waits for it in the background to
+// record its outcome and duration. The returned channel receives the outcome,
+// it is nil if the request is not tracked.
+func (i *InstanceGroup) trackRequest(action, serverID string, apiResponse *shared.APIResponse, started time.Time) <-chan error {
+	if i.bgCtx == nil || i.bgCtx.Err() != nil || apiResponse == nil || apiResponse.Response == nil {
+		return nil
+	}
+	location := apiResponse.Header.Get("Location")
+	if location == "" {
+		return nil
+	}
+	req := RequestInfo{RequestID: requestID(apiResponse), Action: action, ServerID: serverID, Status: requestRunning, Started: started}
+	if !i.pending.add(location, req) {
+		return nil
+	}
+	i.requestLog.record(req)
+
+	done := make(chan error, 1)
+	go func() {
+		defer i.pending.done(location)
+		_, err := i.api.WaitForRequest(i.bgCtx, location)
+		if i.bgCtx.Err() != nil {
+			done <- i.bgCtx.Err()
+			return
+		}
+		done <- err
+		req.Status = requestDone
+		if err != nil {
+			req.Status, req.Message = requestFailed, err.Error()
+			i.log.Warn("Server request failed", "action", action, "id", serverID, "request_id", req.RequestID, "err", err)
+		}
+		i.requestLog.record(req)
+		if i.metrics != nil {
+			result := "done"
+			if err != nil {
+				result = "failed"
+			}
+			i.metrics.requestDuration.WithLabelValues(action, result).Observe(time.Since(started).Seconds())
+		}
+	}()
+	return done
+}
+
+// drainRequests waits until the pending requests have reached a terminal
+// state, at most shutdown_timeout or until ctx is done. Requests still
+// pending are logged; their servers are adopted on the next start.
+func (i *InstanceGroup) drainRequests(ctx context.Context) {
+	i.pending.mu.Lock()
+	i.pending.draining = true
+	i.pending.mu.Unlock()
+
+	if len(i.pending.list()) == 0 {
+		return
+	}
+	timeout := i.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	i.log.Info("Waiting for pending requests", "count", len(i.pending.list()), "timeout", timeout)
+
+	drained := make(chan struct{})
+	go func() {
+		i.pending.wg.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(time.Duration(timeout))
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	for _, req := range i.pending.list() {
+		i.log.Warn("Shutting down with pending request", "action", req.Action, "id", req.ServerID,
+			"request_id", req.RequestID, "age", time.Since(req.Started).Round(time.Second))
+	}
+}
+
+// ListRequests returns the server requests of the group created after since,
+// newest first, as reported by the API, so requests of earlier plugin runs
+// are included. A status of "pending" matches queued and running requests,
+// any other non-empty status is matched exactly.
+func (i *InstanceGroup) ListRequests(ctx context.Context, since time.Time, status string) ([]RequestInfo, error) {
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool)
+	for _, server := range servers {
+		members[*server.Id] = true
+	}
+	i.requestLog.mu.Lock()
+	for id := range i.requestLog.byInstance {
+		members[id] = true
+	}
+	i.requestLog.mu.Unlock()
+
+	requests, _, err := withRetry(ctx, i, "RequestsGet", func(ctx context.Context) (compute.Requests, *shared.APIResponse, error) {
+		return i.api.ListRequests(ctx, since)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing requests: %w", err)
+	}
+	if requests.Items == nil {
+		return nil, nil
+	}
+
+	// Oldest first, so create requests add their servers to members before
+	// the later requests of those servers are matched.
+	items := *requests.Items
+	slices.SortFunc(items, func(a, b compute.Request) int {
+		return requestCreated(a).Compare(requestCreated(b))
+	})
+
+	status = strings.ToUpper(status)
+	var infos []RequestInfo
+	for _, req := range items {
+		info, ok := i.groupRequest(req, members)
+		if !ok {
+			continue
+		}
+		switch status {
+		case "":
+		case "PENDING":
+			if info.Status != requestQueued && info.Status != requestRunning {
+				continue
+			}
+		default:
+			if info.Status != status {
+				continue
+			}
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b RequestInfo) int { return b.Started.Compare(a.Started) })
+	return infos, nil
+}
+
+// groupRequest converts a request to a server of the group. Create requests
+// are matched by the server name, as their server may be gone by now; the
+// server is then added to members so its later requests match as well.
+func (i *InstanceGroup) groupRequest(req compute.Request, members map[string]bool) (RequestInfo, bool) {
+	if req.Properties == nil || req.Properties.Method == nil || req.Properties.Url == nil {
+		return RequestInfo{}, false
+	}
+	method, url := *req.Properties.Method, strings.TrimSuffix(*req.Properties.Url, "/")
+	info := RequestInfo{RequestID: stringValue(req.Id), Action: strings.ToLower(method), Started: requestCreated(req)}
+	if m := requestServerID.FindStringSubmatch(url); m != nil {
+		info.ServerID = m[1]
+	}
+	if req.Metadata != nil {
+		if s := req.Metadata.RequestStatus; s != nil && s.Metadata != nil {
+			info.Status, info.Message = stringValue(s.Metadata.Status), stringValue(s.Metadata.Message)
+			if info.ServerID == "" && s.Metadata.Targets != nil {
+				for _, target := range *s.Metadata.Targets {
+					if target.Target != nil && target.Target.Id != nil {
+						info.ServerID = *target.Target.Id
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case method == http.MethodPost && strings.HasSuffix(url, "/servers"):
+		if !members[info.ServerID] && !strings.Contains(stringValue(req.Properties.Body), `"name":"`+i.ServerSpec.Name+`-`) {
+			return RequestInfo{}, false
+		}
+		if info.ServerID != "" {
+			members[info.ServerID] = true
+		}
+		info.Action = "create"
+	case !members[info.ServerID]:
+		return RequestInfo{}, false
+	case method == http.MethodDelete:
+		info.Action = "delete"
+	case method == http.MethodPost:
+		info.Action = url[strings.LastIndex(url, "/")+1:]
+	}
+	return info, true
+}
+
+func requestCreated(req compute.Request) time.Time {
+	if req.Metadata == nil || req.Metadata.CreatedDate == nil {
+		return time.Time{}
+	}
+	return req.Metadata.CreatedDate.Time
+}
+
+var requestServerID = regexp.MustCompile(`/servers/([^/]+)`)
+
+// stringValue returns the string s points to, or "" if s is nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
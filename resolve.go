@@ -0,0 +1,51 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ResolveInstances maps server names to instance IDs, so operators can
+// refer to instances by the name they see in the DCD. Arguments that are
+// UUIDs are returned unchanged. A name must match exactly one group server.
+func (i *InstanceGroup) ResolveInstances(ctx context.Context, namesOrIDs []string) ([]string, error) {
+	var names []string
+	for _, arg := range namesOrIDs {
+		if uuid.Validate(arg) != nil {
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		return namesOrIDs, nil
+	}
+
+	servers, err := i.listGroupServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string)
+	for _, server := range servers {
+		name := *server.Properties.Name
+		byName[name] = append(byName[name], *server.Id)
+	}
+
+	ids := make([]string, 0, len(namesOrIDs))
+	for _, arg := range namesOrIDs {
+		if uuid.Validate(arg) == nil {
+			ids = append(ids, arg)
+			continue
+		}
+		switch matches := byName[arg]; len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no instance named %q in the group", arg)
+		case 1:
+			ids = append(ids, matches[0])
+		default:
+			return nil, fmt.Errorf("instance name %q is ambiguous, use one of %s", arg, strings.Join(matches, ", "))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,67 @@
+package ionos
+
+import (
+	"context"
+	"maps"
+)
+
+// resolvedSpec is what the plugin resolves from server_spec and server_specs
+// at runtime: the template of a template name or of minimum resources and
+// the image of image_pattern or the active golden image. Increase and the
+// warm pool resolve it concurrently, so it is replaced as a whole instead of
+// being written to the config, and a server is created from one value.
+type resolvedSpec struct {
+	TemplateID   string
+	TemplateName string
+	Image        string
+	// VariantTemplateIDs are the template IDs of the server_specs entries.
+	VariantTemplateIDs map[*SpecVariant]string
+}
+
+// spec returns a copy of the last resolved spec, or the configured one if
+// nothing has been resolved yet.
+func (i *InstanceGroup) spec() resolvedSpec {
+	if spec := i.resolved.Load(); spec != nil {
+		s := *spec
+		s.VariantTemplateIDs = maps.Clone(s.VariantTemplateIDs)
+		return s
+	}
+	spec := resolvedSpec{
+		TemplateID:         i.ServerSpec.TemplateID,
+		TemplateName:       i.ServerSpec.TemplateName,
+		Image:              i.ServerSpec.Image,
+		VariantTemplateIDs: make(map[*SpecVariant]string),
+	}
+	for n := range i.ServerSpecs {
+		if id := i.ServerSpecs[n].TemplateID; id != "" {
+			spec.VariantTemplateIDs[&i.ServerSpecs[n]] = id
+		}
+	}
+	return spec
+}
+
+// resolveSpec resolves the templates and the image and returns the spec to
+// create servers from.
+func (i *InstanceGroup) resolveSpec(ctx context.Context) (resolvedSpec, error) {
+	i.resolveMu.Lock()
+	defer i.resolveMu.Unlock()
+
+	spec := i.spec()
+	if err := i.resolveTemplate(ctx, &spec); err != nil {
+		return resolvedSpec{}, err
+	}
+	if err := i.refreshImage(ctx, &spec); err != nil {
+		return resolvedSpec{}, err
+	}
+	i.resolved.Store(&spec)
+	return spec, nil
+}
+
+// variantTemplateID returns the template ID of a server_specs entry, or the
+// one of server_spec if the entry has none.
+func (s resolvedSpec) variantTemplateID(v *SpecVariant) string {
+	if id := s.VariantTemplateIDs[v]; id != "" {
+		return id
+	}
+	return s.TemplateID
+}
@@ -0,0 +1,82 @@
+package ionos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CreateResult is the outcome of a single instance creation in Increase.
+type CreateResult struct {
+	Index        int        `json:"index"`
+	Name         string     `json:"name"`
+	DatacenterID string     `json:"datacenter_id"`
+	ID           string     `json:"id,omitempty"`
+	RequestID    string     `json:"request_id,omitempty"`
+	Class        ErrorClass `json:"error_class,omitempty"`
+	Err          error      `json:"-"`
+}
+
+func newCreateResult(dc DatacenterConfig, index int, name, id string, err error) CreateResult {
+	result := CreateResult{Index: index, Name: name, DatacenterID: dc.ID, ID: id, Err: err}
+	if err != nil {
+		result.Class = ClassifyError(err)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			result.RequestID = apiErr.RequestID
+		}
+	}
+	return result
+}
+
+// IncreaseError is returned by Increase when some of the requested instances
+// could not be created. Results holds an entry per creation attempt.
+type IncreaseError struct {
+	Results []CreateResult
+}
+
+// Failed returns the results of the failed creations.
+func (e *IncreaseError) Failed() []CreateResult {
+	var failed []CreateResult
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Error summarizes the failures by class and includes the first error, the
+// individual errors are logged by Increase.
+func (e *IncreaseError) Error() string {
+	failed := e.Failed()
+	if len(failed) == 0 {
+		return "no instance creation failed"
+	}
+
+	counts := make(map[ErrorClass]int)
+	for _, result := range failed {
+		class := result.Class
+		if class == "" {
+			class = "other"
+		}
+		counts[class]++
+	}
+	classes := make([]string, 0, len(counts))
+	for class, n := range counts {
+		classes = append(classes, fmt.Sprintf("%s: %d", class, n))
+	}
+	sort.Strings(classes)
+
+	return fmt.Sprintf("%d of %d instance creations failed (%s), first error for %s: %v",
+		len(failed), len(e.Results), strings.Join(classes, ", "), failed[0].Name, failed[0].Err)
+}
+
+func (e *IncreaseError) Unwrap() []error {
+	var errs []error
+	for _, result := range e.Failed() {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
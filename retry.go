@@ -0,0 +1,154 @@
+package ionos
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultRetryMaxAttempts    = 4
+	defaultRetryInitialBackoff = Duration(time.Second)
+	defaultRetryMaxBackoff     = Duration(30 * time.Second)
+)
+
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts per API call, 1 disables retries.
+	MaxAttempts    int      `json:"max_attempts"`
+	InitialBackoff Duration `json:"initial_backoff"`
+	MaxBackoff     Duration `json:"max_backoff"`
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultRetryMaxBackoff
+	}
+	return c
+}
+
+// withRetry runs an IONOS API call and repeats it on 429 and 5xx responses
+// with exponential backoff and jitter, honoring the Retry-After header. A
+// 401 response reloads the credentials and repeats the call once. Each
+// attempt gets a context bounded by the timeout for op.
+func withRetry[T any](ctx context.Context, i *InstanceGroup, op string, call func(ctx context.Context) (T, *shared.APIResponse, error)) (T, *shared.APIResponse, error) {
+	cfg := i.Retry.withDefaults()
+
+	ctx, span := i.startSpan(ctx, "ionos."+op)
+	reloaded := false
+	for attempt := 1; ; attempt++ {
+		if err := i.allowAPICall(); err != nil {
+			var zero T
+			endSpan(span, err)
+			return zero, nil, &APIError{Op: op, Class: ErrClassTransient, Err: err}
+		}
+		callCtx, cancel := i.withOpTimeout(ctx, op)
+		result, apiResponse, err := call(callCtx)
+		cancel()
+		i.recordAPICall(ctx, apiResponse, err)
+		if ctx.Err() == nil {
+			i.health.recordAPICall(apiResponse, err)
+			if err != nil {
+				i.recordAPIError(apiResponse)
+			}
+		}
+		if err != nil && !reloaded && apiResponse != nil && apiResponse.Response != nil && apiResponse.StatusCode == http.StatusUnauthorized {
+			// The token may have been rotated, repeat the call once with
+			// the reloaded credentials.
+			reloaded = true
+			if i.reloadUnauthorized(ctx) {
+				attempt--
+				continue
+			}
+		}
+		if err == nil || !isRetryable(apiResponse) || attempt >= cfg.MaxAttempts {
+			span.SetAttributes(attribute.Int("ionos.attempts", attempt))
+			if apiResponse != nil && apiResponse.Response != nil {
+				span.SetAttributes(attribute.Int("http.response.status_code", apiResponse.StatusCode))
+			}
+			if id := requestID(apiResponse); id != "" {
+				span.SetAttributes(attribute.String("ionos.request_id", id))
+			}
+			err = classifyAPIError(op, apiResponse, err)
+			if class := ClassifyError(err); class != "" {
+				span.SetAttributes(attribute.String("ionos.error_class", string(class)))
+			}
+			endSpan(span, err)
+			return result, apiResponse, err
+		}
+
+		wait := retryAfter(apiResponse)
+		if wait <= 0 {
+			wait = backoff(cfg, attempt)
+		}
+		i.log.Warn("Retrying IONOS API call", "op", op, "attempt", attempt, "status", apiResponse.StatusCode, "wait", wait, "err", err)
+
+		select {
+		case <-ctx.Done():
+			err = classifyAPIError(op, apiResponse, err)
+			endSpan(span, err)
+			return result, apiResponse, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// withRetryNoResult is withRetry for calls that only return a response.
+func withRetryNoResult(ctx context.Context, i *InstanceGroup, op string, call func(ctx context.Context) (*shared.APIResponse, error)) (*shared.APIResponse, error) {
+	_, apiResponse, err := withRetry(ctx, i, op, func(ctx context.Context) (struct{}, *shared.APIResponse, error) {
+		apiResponse, err := call(ctx)
+		return struct{}{}, apiResponse, err
+	})
+	return apiResponse, err
+}
+
+func isRetryable(apiResponse *shared.APIResponse) bool {
+	if apiResponse == nil || apiResponse.Response == nil {
+		return false
+	}
+	switch apiResponse.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(apiResponse *shared.APIResponse) time.Duration {
+	value := apiResponse.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// backoff returns the exponential delay for the given attempt with jitter
+// applied to the upper half of the interval.
+func backoff(cfg RetryConfig, attempt int) time.Duration {
+	wait := time.Duration(cfg.InitialBackoff) << (attempt - 1)
+	if wait <= 0 || wait > time.Duration(cfg.MaxBackoff) {
+		wait = time.Duration(cfg.MaxBackoff)
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
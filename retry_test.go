@@ -0,0 +1,78 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+func TestWithRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		calls    int
+		class    ErrorClass
+	}{
+		{"retryable responses", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3, ""},
+		{"client error", []int{http.StatusBadRequest}, 1, ErrClassInvalid},
+		{"not found", []int{http.StatusNotFound}, 1, ErrClassNotFound},
+		{"server error", []int{http.StatusInternalServerError}, 3, ErrClassTransient},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.Retry.MaxAttempts = 3
+			calls := 0
+			_, err := withRetryNoResult(context.Background(), i, "Test", func(ctx context.Context) (*shared.APIResponse, error) {
+				status := tc.statuses[min(calls, len(tc.statuses)-1)]
+				calls++
+				if status != http.StatusOK {
+					return response(status), apiError(status, "failed")
+				}
+				return response(status), nil
+			})
+			if calls != tc.calls {
+				t.Errorf("withRetry made %d calls, want %d", calls, tc.calls)
+			}
+			if tc.class == "" && err != nil {
+				t.Errorf("withRetry: %v", err)
+			}
+			if tc.class != "" && !errors.Is(err, tc.class) {
+				t.Errorf("withRetry error %v is not of class %s", err, tc.class)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":        0,
+		"7":       7 * time.Second,
+		"invalid": 0,
+	} {
+		apiResponse := response(http.StatusTooManyRequests)
+		apiResponse.Header.Set("Retry-After", value)
+		if got := retryAfter(apiResponse); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := RetryConfig{InitialBackoff: Duration(time.Second), MaxBackoff: Duration(4 * time.Second)}
+	for attempt, limit := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		8: 4 * time.Second,
+	} {
+		for range 20 {
+			if wait := backoff(cfg, attempt); wait < limit/2 || wait > limit {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, wait, limit/2, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// secretPattern matches ${env:NAME}, ${file:/path} and ${vault:path#key}
+// placeholders, and $${ as escape for a literal ${.
+var secretPattern = regexp.MustCompile(`\$\$\{|\$\{(env|file|vault):([^}]+)\}`)
+
+// interpolateSecrets replaces the placeholders in user data with the value
+// of the environment variable, the content of the file or the field of the
+// Vault secret, so secrets such as runner tokens do not have to be part of
+// the plugin config. They are resolved whenever an instance is created, Vault
+// secrets are cached by the Vault client for its refresh interval.
+func (i *InstanceGroup) interpolateSecrets(ctx context.Context, s string) (string, error) {
+	var errs, resolved []string
+	result := secretPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		m := secretPattern.FindStringSubmatch(match)
+		source, name := m[1], m[2]
+		switch source {
+		case "env":
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("environment variable %s is not set", name))
+			}
+			resolved = append(resolved, value)
+			return value
+		case "vault":
+			value, err := i.vaultPlaceholder(ctx, name)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			resolved = append(resolved, value)
+			return value
+		default:
+			data, err := os.ReadFile(name)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			value := strings.TrimRight(string(data), "\r\n")
+			resolved = append(resolved, value)
+			return value
+		}
+	})
+	if len(errs) > 0 {
+		return "", fmt.Errorf("resolving user_data placeholders: %s", strings.Join(errs, ", "))
+	}
+	secrets.add(resolved...)
+	return result, nil
+}
+
+// vaultPlaceholder resolves the path#key of a ${vault:...} placeholder.
+func (i *InstanceGroup) vaultPlaceholder(ctx context.Context, ref string) (string, error) {
+	if i.vault == nil {
+		return "", fmt.Errorf("${vault:%s} requires vault to be configured", ref)
+	}
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("${vault:%s} must have the form path#key", ref)
+	}
+	return i.vault.secret(ctx, path, key)
+}
@@ -0,0 +1,72 @@
+package ionos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInterpolateSecrets(t *testing.T) {
+	var reads atomic.Int32
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reads.Add(1)
+		w.Write([]byte(`{"data": {"data": {"token": "vault-runner-token"}, "metadata": {}}}`))
+	}))
+	defer vault.Close()
+	client, err := newVaultClient(VaultConfig{Address: vault.URL, Token: "vault-token"}, vault.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_RUNNER_TOKEN", "env-runner-token")
+	i := newTestGroup(&mockCompute{})
+	i.vault = client
+
+	tests := []struct {
+		in, want, err string
+	}{
+		{in: "token: ${env:TEST_RUNNER_TOKEN}", want: "token: env-runner-token"},
+		{in: "token: ${vault:secret/data/runner#token}", want: "token: vault-runner-token"},
+		{in: "literal: $${env:TEST_RUNNER_TOKEN}", want: "literal: ${env:TEST_RUNNER_TOKEN}"},
+		{in: "${env:TEST_UNSET_VARIABLE}", err: "TEST_UNSET_VARIABLE is not set"},
+		{in: "${vault:secret/data/runner}", err: "must have the form path#key"},
+		{in: "${vault:secret/data/runner#missing}", err: "has no string field missing"},
+	}
+	for _, tt := range tests {
+		got, err := i.interpolateSecrets(context.Background(), tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("interpolateSecrets(%q) error %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("interpolateSecrets(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if n := reads.Load(); n != 1 {
+		t.Errorf("Vault was read %d times, want the secret to be cached after the first read", n)
+	}
+}
+
+func TestInterpolateSecretsPassesContextToVault(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Vault was called with a canceled context")
+	}))
+	defer vault.Close()
+	client, err := newVaultClient(VaultConfig{Address: vault.URL, Token: "vault-token"}, vault.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newTestGroup(&mockCompute{})
+	i.vault = client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := i.interpolateSecrets(ctx, "${vault:secret/data/runner#token}"); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("interpolateSecrets error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,145 @@
+package ionos
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+const (
+	specPolicyWeighted = "weighted"
+	specPolicyPriority = "priority"
+)
+
+// SpecVariant overrides the sizing of server_spec for a share of the
+// instances, e.g. small CUBE servers next to big ENTERPRISE ones. Unset
+// fields are taken from server_spec.
+type SpecVariant struct {
+	// Name identifies the variant in logs.
+	Name string `json:"name"`
+	// Weight is the share of new instances created with the variant when
+	// spec_policy is "weighted", defaults to 1.
+	Weight       int     `json:"weight"`
+	Type         string  `json:"type"`
+	Cores        int32   `json:"cores"`
+	Ram          int32   `json:"ram"`
+	StorageSize  float32 `json:"storage_size"`
+	CpuFamily    string  `json:"cpu_family"`
+	TemplateID   string  `json:"template_id"`
+	TemplateName string  `json:"template_name"`
+	VolumeType   string  `json:"volume_type"`
+	// UserDataFragments are merged after those of server_spec.
+	UserDataFragments []UserDataFragment `json:"user_data_fragments"`
+}
+
+func (i *InstanceGroup) validateSpecVariants() error {
+	switch i.SpecPolicy {
+	case "", specPolicyWeighted, specPolicyPriority:
+	default:
+		return fmt.Errorf("spec_policy must be %q or %q", specPolicyWeighted, specPolicyPriority)
+	}
+	for n, v := range i.ServerSpecs {
+		if v.Weight < 0 {
+			return fmt.Errorf("server_specs[%d]: weight must not be negative", n)
+		}
+		typ := i.specType(&v)
+		if !slices.Contains([]string{"ENTERPRISE", "CUBE"}, typ) {
+			return fmt.Errorf("server_specs[%d]: type must be 'ENTERPRISE' or 'CUBE'", n)
+		}
+		if typ == "CUBE" && v.TemplateID == "" && v.TemplateName == "" && i.ServerSpec.Type != "CUBE" {
+			return fmt.Errorf("server_specs[%d]: template_id or template_name is required for 'CUBE'", n)
+		}
+		if typ == "ENTERPRISE" && i.ServerSpec.Type != "ENTERPRISE" && (v.Cores == 0 || v.Ram == 0 || v.StorageSize == 0) {
+			return fmt.Errorf("server_specs[%d]: cores, ram and storage_size are required for 'ENTERPRISE'", n)
+		}
+	}
+	return nil
+}
+
+// specType returns the server type of a variant, nil being server_spec.
+func (i *InstanceGroup) specType(v *SpecVariant) string {
+	if v != nil && v.Type != "" {
+		return v.Type
+	}
+	return i.ServerSpec.Type
+}
+
+// specVariants returns the configured variants in order, or a single nil
+// variant for server_spec alone.
+func (i *InstanceGroup) specVariants() []*SpecVariant {
+	if len(i.ServerSpecs) == 0 {
+		return []*SpecVariant{nil}
+	}
+	variants := make([]*SpecVariant, len(i.ServerSpecs))
+	for n := range i.ServerSpecs {
+		variants[n] = &i.ServerSpecs[n]
+	}
+	return variants
+}
+
+// specOrder returns the variants to try for a new instance in order: the
+// next one by smooth weighted round-robin, or all of them for the "priority"
+// policy, so creation falls through to the next when one has no capacity.
+// Without server_specs it returns server_spec alone as nil.
+func (i *InstanceGroup) specOrder() []*SpecVariant {
+	if len(i.ServerSpecs) == 0 || i.SpecPolicy == specPolicyPriority {
+		return i.specVariants()
+	}
+
+	i.specMu.Lock()
+	defer i.specMu.Unlock()
+
+	if len(i.specCurrent) != len(i.ServerSpecs) {
+		i.specCurrent = make([]int, len(i.ServerSpecs))
+	}
+	best, total := 0, 0
+	for n, v := range i.ServerSpecs {
+		weight := max(v.Weight, 1)
+		total += weight
+		i.specCurrent[n] += weight
+		if i.specCurrent[n] > i.specCurrent[best] {
+			best = n
+		}
+	}
+	i.specCurrent[best] -= total
+	return []*SpecVariant{&i.ServerSpecs[best]}
+}
+
+// applySpecVariant turns the create request built from server_spec into
+// one for the variant.
+func (i *InstanceGroup) applySpecVariant(spec resolvedSpec, v *SpecVariant, serverData *compute.Server) {
+	if v == nil {
+		return
+	}
+	typ := i.specType(v)
+	props := serverData.Properties
+	props.Type = &typ
+	volume := &(*serverData.Entities.Volumes.Items)[0]
+
+	if typ == "CUBE" {
+		templateID := spec.variantTemplateID(v)
+		props.TemplateUuid = &templateID
+		props.Cores = nil
+		props.Ram = nil
+		props.CpuFamily = nil
+		volume.Properties.Size = nil
+	} else {
+		cores, ram, size := i.ServerSpec.Cores, i.ServerSpec.Ram, i.ServerSpec.StorageSize
+		if v.Cores != 0 {
+			cores = v.Cores
+		}
+		if v.Ram != 0 {
+			ram = v.Ram
+		}
+		if v.StorageSize != 0 {
+			size = v.StorageSize
+		}
+		props.TemplateUuid = nil
+		props.Cores = &cores
+		props.Ram = &ram
+		volume.Properties.Size = &size
+	}
+	volumeType := i.specVolumeType(v)
+	volume.Properties.Type = &volumeType
+}
@@ -0,0 +1,85 @@
+package ionos
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshKeyPair is the key pair managed for the group with manage_ssh_key.
+type sshKeyPair struct {
+	privateKey []byte
+	publicKey  string
+}
+
+// loadSSHKey loads the private key from ssh_key_file, or generates an
+// ed25519 key and stores it there if the file does not exist yet. Without
+// ssh_key_file the key only lives as long as the process, so instances
+// created before a restart cannot be reached anymore.
+func (i *InstanceGroup) loadSSHKey() error {
+	if !i.ManageSSHKey {
+		return nil
+	}
+
+	if i.SSHKeyFile != "" {
+		data, err := os.ReadFile(i.SSHKeyFile)
+		if err == nil {
+			signer, err := ssh.ParsePrivateKey(data)
+			if err != nil {
+				return fmt.Errorf("parsing ssh_key_file: %w", err)
+			}
+			i.sshKey = &sshKeyPair{privateKey: data, publicKey: authorizedKey(signer.PublicKey())}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("reading ssh_key_file: %w", err)
+		}
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generating SSH key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "fleeting-"+i.groupLabel())
+	if err != nil {
+		return fmt.Errorf("encoding SSH key: %w", err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return fmt.Errorf("encoding SSH key: %w", err)
+	}
+	i.sshKey = &sshKeyPair{privateKey: pem.EncodeToMemory(block), publicKey: authorizedKey(sshPublic)}
+
+	if i.SSHKeyFile == "" {
+		i.log.Warn("Generated an SSH key that is lost on restart, set ssh_key_file to keep it")
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(i.SSHKeyFile), 0o700); err != nil {
+		return fmt.Errorf("writing ssh_key_file: %w", err)
+	}
+	if err := os.WriteFile(i.SSHKeyFile, i.sshKey.privateKey, 0o600); err != nil {
+		return fmt.Errorf("writing ssh_key_file: %w", err)
+	}
+	i.log.Info("Generated SSH key", "file", i.SSHKeyFile)
+	return nil
+}
+
+func authorizedKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// sshKeys returns the public keys to install on the volume of an instance.
+func (i *InstanceGroup) sshKeys() *[]string {
+	if i.sshKey == nil || i.isWindows() || i.bootsBlankVolume() {
+		return nil
+	}
+	return &[]string{i.sshKey.publicKey}
+}
@@ -0,0 +1,219 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// staticIPPendingTTL is how long an assigned static or reserved IP is held
+// back while its server does not show up in the server list, e.g. because
+// the create request is still being processed.
+const staticIPPendingTTL = 10 * time.Minute
+
+// StaticIPConfig assigns instances a fixed IP on the private NIC with DHCP
+// disabled, for LANs without DHCP. The IPs are taken from pool or, if
+// empty, from the hosts of subnet and must not be used outside the group.
+type StaticIPConfig struct {
+	Subnet      string   `json:"subnet"`
+	Pool        []string `json:"pool,omitempty"`
+	Gateway     string   `json:"gateway,omitempty"`
+	Nameservers []string `json:"nameservers,omitempty"`
+	// Interface is the name of the private NIC in the guest. By default
+	// every ethernet interface is matched, which is only right as long as
+	// the private NIC is the only one.
+	Interface string `json:"interface,omitempty"`
+}
+
+func (c *StaticIPConfig) validate() error {
+	prefix, err := netip.ParsePrefix(c.Subnet)
+	if err != nil || !prefix.Addr().Is4() || prefix.Bits() > 30 {
+		return fmt.Errorf("static_ips: subnet must be an IPv4 CIDR of at most /30, got %q", c.Subnet)
+	}
+	for _, ip := range c.Pool {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !prefix.Contains(addr) {
+			return fmt.Errorf("static_ips: pool IP %q is not in subnet %s", ip, c.Subnet)
+		}
+	}
+	if c.Gateway != "" {
+		addr, err := netip.ParseAddr(c.Gateway)
+		if err != nil || !prefix.Contains(addr) {
+			return fmt.Errorf("static_ips: gateway %q is not in subnet %s", c.Gateway, c.Subnet)
+		}
+	}
+	for _, ns := range c.Nameservers {
+		if _, err := netip.ParseAddr(ns); err != nil {
+			return fmt.Errorf("static_ips: invalid nameserver %q", ns)
+		}
+	}
+	return nil
+}
+
+// validateStaticIPs checks static_ips against the rest of the server spec.
+// Without interface, the netplan config matches every ethernet interface,
+// so it cannot be used with the public NIC of ip_block_id, which would get
+// the static IP too and lose DHCP.
+func (i *InstanceGroup) validateStaticIPs() error {
+	c := i.ServerSpec.StaticIPs
+	if c == nil {
+		return nil
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if i.isWindows() || i.bootsBlankVolume() {
+		return fmt.Errorf("static_ips requires a Linux image that applies the netplan config from user data")
+	}
+	if i.ServerSpec.IPBlockID != "" && c.Interface == "" {
+		return fmt.Errorf("static_ips with ip_block_id requires interface, the name of the private NIC in the guest")
+	}
+	return nil
+}
+
+// size returns the number of IPs to pick from.
+func (c *StaticIPConfig) size() int {
+	if len(c.Pool) > 0 {
+		return len(c.Pool)
+	}
+	prefix := netip.MustParsePrefix(c.Subnet)
+	// Without the network and broadcast address.
+	return 1<<(32-prefix.Bits()) - 2
+}
+
+// at returns the n-th IP to pick from.
+func (c *StaticIPConfig) at(n int) string {
+	if len(c.Pool) > 0 {
+		return c.Pool[n]
+	}
+	network := netip.MustParsePrefix(c.Subnet).Masked().Addr().As4()
+	host := uint32(network[0])<<24 | uint32(network[1])<<16 | uint32(network[2])<<8 | uint32(network[3])
+	host += uint32(n) + 1
+	return netip.AddrFrom4([4]byte{byte(host >> 24), byte(host >> 16), byte(host >> 8), byte(host)}).String()
+}
+
+// cloudConfig is a cloud-config that writes a netplan config for the static
+// IP and applies it. It is merged after the other parts.
+func (c *StaticIPConfig) cloudConfig(ip string) string {
+	prefix := netip.MustParsePrefix(c.Subnet)
+	match := c.Interface
+	if match == "" {
+		match = "e*"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `#cloud-config
+write_files:
+  - path: /etc/netplan/60-fleeting-static.yaml
+    permissions: "0600"
+    content: |
+      network:
+        version: 2
+        ethernets:
+          private:
+            match:
+              name: %q
+            dhcp4: false
+            addresses: [%s/%d]
+`, match, ip, prefix.Bits())
+	if c.Gateway != "" {
+		fmt.Fprintf(&b, `            routes:
+              - to: default
+                via: %s
+`, c.Gateway)
+	}
+	if len(c.Nameservers) > 0 {
+		fmt.Fprintf(&b, `            nameservers:
+              addresses: [%s]
+`, strings.Join(c.Nameservers, ", "))
+	}
+	b.WriteString(`runcmd:
+  - netplan apply
+`)
+	return b.String()
+}
+
+// staticIPPool tracks the static or reserved IPs handed out by the running
+// plugin whose servers have not been listed yet.
+type staticIPPool struct {
+	mu      sync.Mutex
+	cursor  int
+	pending map[string]time.Time
+}
+
+func (p *staticIPPool) release(ip string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, ip)
+}
+
+// nextStaticIP picks the next static IP that is neither on the NIC of a
+// group server nor handed out for a server still being created, going
+// round-robin so released IPs are not reused right away.
+func (i *InstanceGroup) nextStaticIP(ctx context.Context) (string, error) {
+	servers, err := i.listGroupServersAt(ctx, i.Depth.listWithNICs())
+	if err != nil {
+		return "", fmt.Errorf("listing static IPs in use: %w", err)
+	}
+	used := make(map[string]bool)
+	for _, server := range servers {
+		for _, ip := range serverIPs(server) {
+			used[ip] = true
+		}
+	}
+
+	c := i.ServerSpec.StaticIPs
+	p := &i.staticIPs
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = make(map[string]time.Time)
+	}
+	for ip, assigned := range p.pending {
+		if used[ip] || time.Since(assigned) > staticIPPendingTTL {
+			delete(p.pending, ip)
+		}
+	}
+
+	size := c.size()
+	for n := range size {
+		ip := c.at((p.cursor + n) % size)
+		if used[ip] || ip == c.Gateway {
+			continue
+		}
+		if _, ok := p.pending[ip]; ok {
+			continue
+		}
+		p.cursor = (p.cursor + n + 1) % size
+		p.pending[ip] = time.Now()
+		return ip, nil
+	}
+	return "", fmt.Errorf("all %d static IPs are in use", size)
+}
+
+// serverIPs returns the IPs on the NICs of a server fetched with depth 2.
+func serverIPs(server compute.Server) []string {
+	if server.Entities == nil || server.Entities.Nics == nil || server.Entities.Nics.Items == nil {
+		return nil
+	}
+	var ips []string
+	for _, nic := range *server.Entities.Nics.Items {
+		if nic.Properties != nil && nic.Properties.Ips != nil {
+			ips = append(ips, *nic.Properties.Ips...)
+		}
+	}
+	return ips
+}
+
+// setStaticIP assigns the static IP to the private NIC of a create request
+// and disables DHCP on it.
+func setStaticIP(serverData *compute.Server, ip string) {
+	nic := &(*serverData.Entities.Nics.Items)[0]
+	nic.Properties.Ips = &[]string{ip}
+	nic.Properties.Dhcp = BoolPtr(false)
+}
@@ -0,0 +1,84 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateStaticIPs(t *testing.T) {
+	tests := []struct {
+		name      string
+		static    StaticIPConfig
+		ipBlockID string
+		err       string
+	}{
+		{name: "private NIC only", static: StaticIPConfig{Subnet: "10.7.222.0/24"}},
+		{name: "public NIC without interface", static: StaticIPConfig{Subnet: "10.7.222.0/24"}, ipBlockID: "block", err: "requires interface"},
+		{name: "public NIC with interface", static: StaticIPConfig{Subnet: "10.7.222.0/24", Interface: "ens6"}, ipBlockID: "block"},
+		{name: "pool outside the subnet", static: StaticIPConfig{Subnet: "10.7.222.0/24", Pool: []string{"10.7.223.10"}}, err: "not in subnet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestGroup(&mockCompute{})
+			i.ServerSpec.Image = "image"
+			i.ServerSpec.IPBlockID = tt.ipBlockID
+			i.ServerSpec.StaticIPs = &tt.static
+
+			err := i.validateStaticIPs()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("validateStaticIPs() = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestStaticIPCloudConfigMatch(t *testing.T) {
+	for iface, want := range map[string]string{
+		"":     `name: "e*"`,
+		"ens6": `name: "ens6"`,
+	} {
+		c := StaticIPConfig{Subnet: "10.7.222.0/24", Interface: iface}
+		if got := c.cloudConfig("10.7.222.10"); !strings.Contains(got, want) || !strings.Contains(got, "addresses: [10.7.222.10/24]") {
+			t.Errorf("cloudConfig with interface %q does not match %s:\n%s", iface, want, got)
+		}
+	}
+}
+
+func TestNextStaticIP(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config StaticIPConfig
+		used   string
+		want   []string
+	}{
+		// The network, broadcast and gateway addresses are skipped.
+		{"subnet", StaticIPConfig{Subnet: "10.0.0.0/29", Gateway: "10.0.0.1"}, "", []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}},
+		{"used by a server", StaticIPConfig{Subnet: "10.0.0.0/29", Gateway: "10.0.0.1"}, "10.0.0.3", []string{"10.0.0.2", "10.0.0.4", "10.0.0.5", "10.0.0.6"}},
+		{"pool", StaticIPConfig{Subnet: "10.0.0.0/24", Pool: []string{"10.0.0.10", "10.0.0.20"}}, "10.0.0.10", []string{"10.0.0.20"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{}
+			if tc.used != "" {
+				api.servers = append(api.servers, withNICs(testServer("server", "runner-1-aaaa", "AVAILABLE"), map[int32]string{1: tc.used}))
+			}
+			i := newTestGroup(api)
+			i.ServerSpec.StaticIPs = &tc.config
+
+			// IPs handed out for servers still being created are not
+			// handed out again until they are released.
+			var got []string
+			for ip, err := i.nextStaticIP(context.Background()); err == nil; ip, err = i.nextStaticIP(context.Background()) {
+				got = append(got, ip)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("nextStaticIP handed out %v, want %v", got, tc.want)
+			}
+			i.staticIPs.release(tc.want[0])
+			if ip, err := i.nextStaticIP(context.Background()); err != nil || ip != tc.want[0] {
+				t.Errorf("nextStaticIP = %s, %v after releasing %s", ip, err, tc.want[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package ionos
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/gitlab-org/fleeting/fleeting/provider"
+)
+
+const (
+	// statusLookback is how far back Status looks for pending and failed
+	// requests.
+	statusLookback = 24 * time.Hour
+	// statusFailedRequests is the number of failed requests Status reports.
+	statusFailedRequests = 5
+)
+
+// GroupStatus summarizes the state of the group, e.g. for the status command.
+type GroupStatus struct {
+	// Instances counts the instances by the state Update reports them in,
+	// plus "stopped" and "standby" for instances Update hides.
+	Instances map[string]int `json:"instances"`
+	MaxSize   int            `json:"max_size"`
+	// PendingRequests are the server requests that are queued or running.
+	PendingRequests []RequestInfo `json:"pending_requests"`
+	// FailedRequests are the most recent server requests that failed within
+	// the last day.
+	FailedRequests []RequestInfo `json:"failed_requests"`
+	// Quota is how much of the contract resource limits is left, nil if it
+	// could not be determined, see QuotaError.
+	Quota      *QuotaHeadroom `json:"quota,omitempty"`
+	QuotaError string         `json:"quota_error,omitempty"`
+}
+
+// Status returns a summary of the group instances, its recent server
+// requests and the quota headroom.
+func (i *InstanceGroup) Status(ctx context.Context) (GroupStatus, error) {
+	status := GroupStatus{Instances: make(map[string]int), MaxSize: i.MaxSize}
+	err := i.Update(ctx, func(_ string, state provider.State) {
+		status.Instances[string(state)]++
+	})
+	if err != nil {
+		return status, err
+	}
+	if n := len(i.registry.stopped()); n > 0 {
+		status.Instances["stopped"] = n
+	}
+	if n := len(i.registry.standby()); n > 0 {
+		status.Instances["standby"] = n
+	}
+
+	requests, err := i.ListRequests(ctx, time.Now().Add(-statusLookback), "")
+	if err != nil {
+		return status, err
+	}
+	status.PendingRequests, status.FailedRequests = []RequestInfo{}, []RequestInfo{}
+	for _, req := range requests {
+		switch req.Status {
+		case requestQueued, requestRunning:
+			status.PendingRequests = append(status.PendingRequests, req)
+		case requestFailed:
+			if len(status.FailedRequests) < statusFailedRequests {
+				status.FailedRequests = append(status.FailedRequests, req)
+			}
+		}
+	}
+
+	headroom, err := i.quotaHeadroom(ctx)
+	if err != nil {
+		status.QuotaError = err.Error()
+	} else {
+		status.Quota = &headroom
+	}
+	return status, nil
+}
@@ -0,0 +1,153 @@
+package ionos
+
+import (
+	"context"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	decreaseDelete  = "delete"
+	decreaseStop    = "stop"
+	decreaseSuspend = "suspend"
+)
+
+// keepsStopped reports whether Decrease powers instances off instead of
+// deleting them.
+func (i *InstanceGroup) keepsStopped() bool {
+	return i.DecreaseAction == decreaseStop || i.DecreaseAction == decreaseSuspend
+}
+
+// stateStopped is the state instances powered off by Decrease are counted
+// under in the instances metric.
+const stateStopped = "STOPPED"
+
+// powerOffAction returns how a server is powered off: CUBE servers are
+// suspended and keep their direct attached storage, ENTERPRISE servers are
+// stopped, which deallocates their cores and RAM but keeps the volumes.
+func powerOffAction(server compute.Server) string {
+	if server.Properties != nil && server.Properties.Type != nil && *server.Properties.Type == "CUBE" {
+		return "suspend"
+	}
+	return "stop"
+}
+
+// stopInstance powers off a single instance after checking that it belongs
+// to the group, instead of deleting it. The instance is hidden from Update
+// and its disks stay warm for a later scale-up. With decrease_action
+// "suspend" only CUBE servers are kept, others, e.g. created by
+// enterprise_fallback, are deleted.
+func (i *InstanceGroup) stopInstance(ctx context.Context, id string, groups map[string]string) error {
+	dc := i.datacenterOf(ctx, id)
+	server, err := i.checkOwnership(ctx, dc, id, groups)
+	if err != nil {
+		i.log.Error("Not stopping instance", "id", id, "err", err)
+		return err
+	}
+	action := powerOffAction(server)
+	if action != "suspend" && i.DecreaseAction == decreaseSuspend {
+		return i.deleteInstance(ctx, id, groups)
+	}
+	if i.dryRun("would "+action+" server", "id", id, "datacenter", dc) {
+		return nil
+	}
+
+	op, call := "ServersStopPost", i.api.StopServer
+	if action == "suspend" {
+		op, call = "ServersSuspendPost", i.api.SuspendServer
+	}
+	started := time.Now()
+	apiResponse, err := withRetryNoResult(ctx, i, op, func(ctx context.Context) (*shared.APIResponse, error) {
+		return call(ctx, dc, id)
+	})
+	i.audit(ctx, auditEvent{Action: action, ResourceType: "server", ResourceID: id, Datacenter: dc}, apiResponse, err)
+	if err != nil {
+		i.log.Error("Failed to stop instance", "err", err, "id", id, "action", action)
+		return err
+	}
+	i.trackRequest(action, id, apiResponse, started)
+	i.log.Info("Instance stop request successful", "id", id, "action", action)
+
+	i.registry.setStopped(id, true)
+	i.registry.setDatacenter(id, dc)
+	_, _, err = withRetry(ctx, i, "ServersLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+		return i.api.AddServerLabel(ctx, dc, id, labelStopped, "true")
+	})
+	if err != nil {
+		i.log.Warn("Failed to label stopped instance", "id", id, "err", err)
+	}
+	i.closeTunnel(id)
+	return nil
+}
+
+// isRunning reports whether the VM of a server runs.
+func isRunning(server compute.Server) bool {
+	return server.Properties == nil || server.Properties.VmState == nil || *server.Properties.VmState == "RUNNING"
+}
+
+// startStopped starts up to n instances Decrease powered off and returns
+// their IDs. Instances that are still being stopped are skipped. Started
+// instances are reported as creating by Update until they run again.
+func (i *InstanceGroup) startStopped(ctx context.Context, n int) []string {
+	var started []string
+	for _, rec := range i.registry.stopped() {
+		if len(started) == n {
+			break
+		}
+
+		dc := i.datacenterOf(ctx, rec.ID)
+		server, apiResponse, err := withRetry(ctx, i, "ServersFindById", func(ctx context.Context) (compute.Server, *shared.APIResponse, error) {
+			return i.api.GetServer(ctx, dc, rec.ID, 0)
+		})
+		if err != nil {
+			if apiResponse.HttpNotFound() {
+				i.registry.remove(rec.ID)
+			} else {
+				i.log.Warn("Failed to get stopped instance", "id", rec.ID, "err", err)
+			}
+			continue
+		}
+		if *server.Metadata.State == "BUSY" || isRunning(server) {
+			continue
+		}
+
+		action := "start"
+		op, call := "ServersStartPost", i.api.StartServer
+		if powerOffAction(server) == "suspend" {
+			action = "resume"
+			op, call = "ServersResumePost", i.api.ResumeServer
+		}
+		if i.dryRun("would "+action+" server", "id", rec.ID, "datacenter", dc) {
+			started = append(started, rec.ID)
+			continue
+		}
+		begin := time.Now()
+		apiResponse, err = withRetryNoResult(ctx, i, op, func(ctx context.Context) (*shared.APIResponse, error) {
+			return call(ctx, dc, rec.ID)
+		})
+		i.audit(ctx, auditEvent{Action: action, ResourceType: "server", ResourceID: rec.ID, Datacenter: dc}, apiResponse, err)
+		if err != nil {
+			i.log.Warn("Failed to start stopped instance", "id", rec.ID, "action", action, "err", err)
+			continue
+		}
+		i.trackRequest(action, rec.ID, apiResponse, begin)
+
+		i.registry.setStopped(rec.ID, false)
+		i.registry.setStarting(rec.ID, true)
+		i.registry.touch(rec.ID)
+		_, err = withRetryNoResult(ctx, i, "ServersLabelsDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteServerLabel(ctx, dc, rec.ID, labelStopped)
+		})
+		if err != nil {
+			i.log.Warn("Failed to remove stopped label", "id", rec.ID, "err", err)
+		}
+		i.log.Info("Starting stopped instance", "id", rec.ID, "action", action)
+		started = append(started, rec.ID)
+	}
+	if len(started) > 0 {
+		i.serverCache.invalidate()
+	}
+	return started
+}
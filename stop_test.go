@@ -0,0 +1,101 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestDecreaseAction(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		action     string
+		serverType string
+		powered    []string
+		deleted    []string
+	}{
+		{"delete", decreaseDelete, "ENTERPRISE", nil, []string{"server"}},
+		{"stop", decreaseStop, "ENTERPRISE", []string{"stop server"}, nil},
+		// CUBE servers cannot be stopped, they are suspended instead.
+		{"stop CUBE", decreaseStop, "CUBE", []string{"suspend server"}, nil},
+		{"suspend CUBE", decreaseSuspend, "CUBE", []string{"suspend server"}, nil},
+		// Only CUBE servers keep their storage while suspended, others,
+		// e.g. created by enterprise_fallback, are deleted.
+		{"suspend ENTERPRISE", decreaseSuspend, "ENTERPRISE", nil, []string{"server"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := testServer("server", "runner-1-aaaa", "AVAILABLE")
+			server.Properties.Type = &tc.serverType
+			api := &mockCompute{
+				servers: []compute.Server{server},
+				labels:  []compute.Label{testLabel("server", labelGroup, "runner")},
+			}
+			i := newTestGroup(api)
+			i.DecreaseAction = tc.action
+
+			if _, err := i.Decrease(context.Background(), []string{"server"}); err != nil {
+				t.Fatalf("Decrease: %v", err)
+			}
+			if !slices.Equal(api.powered, tc.powered) || !slices.Equal(api.deleted, tc.deleted) {
+				t.Errorf("Decrease powered %v and deleted %v, want %v and %v", api.powered, api.deleted, tc.powered, tc.deleted)
+			}
+			rec, _ := i.registry.get("server")
+			if rec.Stopped != (tc.powered != nil) {
+				t.Errorf("Decrease left the instance stopped %t", rec.Stopped)
+			}
+			stopLabel := slices.ContainsFunc(api.labels, func(label compute.Label) bool {
+				return *label.Properties.Key == labelStopped
+			})
+			if stopLabel != (tc.powered != nil) {
+				t.Errorf("Decrease left the instance labelled stopped %t", stopLabel)
+			}
+		})
+	}
+}
+
+func TestStartStopped(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		serverType string
+		state      string
+		vmState    string
+		missing    bool
+		// powered is the power action that starts the instance, if any.
+		powered string
+	}{
+		{"stopped", "ENTERPRISE", "AVAILABLE", "SHUTOFF", false, "start server"},
+		{"suspended CUBE", "CUBE", "AVAILABLE", "SUSPENDED", false, "resume server"},
+		{"still stopping", "ENTERPRISE", "BUSY", "RUNNING", false, ""},
+		{"running", "ENTERPRISE", "AVAILABLE", "RUNNING", false, ""},
+		{"deleted", "", "", "", true, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &mockCompute{labels: []compute.Label{testLabel("server", labelStopped, "true")}}
+			if !tc.missing {
+				server := testServer("server", "runner-1-aaaa", tc.state)
+				server.Properties.VmState = &tc.vmState
+				server.Properties.Type = &tc.serverType
+				api.servers = append(api.servers, server)
+			}
+			i := newTestGroup(api)
+			i.DecreaseAction = decreaseStop
+			i.registry.setStopped("server", true)
+
+			started := i.startStopped(context.Background(), 1)
+			if (len(started) == 1) != (tc.powered != "") {
+				t.Fatalf("startStopped started %v", started)
+			}
+			// Started instances are creating until they run, deleted ones
+			// are forgotten and others stay stopped.
+			rec, known := i.registry.get("server")
+			if known == tc.missing || known && (rec.Stopped == (tc.powered != "") || rec.Starting != (tc.powered != "")) {
+				t.Errorf("startStopped left the record %+v, known %t", rec, known)
+			}
+			if tc.powered != "" && (!slices.Equal(api.powered, []string{tc.powered}) || len(api.labels) != 0) {
+				t.Errorf("startStopped powered %v and kept the labels %d", api.powered, len(api.labels))
+			}
+		})
+	}
+}
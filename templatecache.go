@@ -0,0 +1,52 @@
+package ionos
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// templateCache remembers template IDs resolved by name, as listing all
+// templates on every Increase is a needless round trip.
+type templateCache struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (c *templateCache) get(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, ok := c.ids[name]
+	return id, ok
+}
+
+func (c *templateCache) set(name, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids == nil {
+		c.ids = make(map[string]string)
+	}
+	c.ids[name] = id
+}
+
+func (c *templateCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids = nil
+}
+
+// invalidatesTemplate reports whether a failed create may be caused by a
+// stale template ID, e.g. because the template was removed or replaced.
+func invalidatesTemplate(err error) bool {
+	var apiErr shared.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode() {
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
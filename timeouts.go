@@ -0,0 +1,63 @@
+package ionos
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCreateTimeout = Duration(time.Minute)
+	defaultDeleteTimeout = Duration(time.Minute)
+	defaultListTimeout   = Duration(time.Minute)
+	defaultFindTimeout   = Duration(30 * time.Second)
+)
+
+// TimeoutsConfig bounds each attempt of an API call by its kind, regardless
+// of the deadline of the caller, so a hung call cannot stall a whole Update
+// or Increase. http.request_timeout still bounds every single HTTP request.
+type TimeoutsConfig struct {
+	// Create applies to POST, PUT and PATCH calls.
+	Create Duration `json:"create"`
+	Delete Duration `json:"delete"`
+	// List applies to calls returning collections, e.g. listing servers.
+	List Duration `json:"list"`
+	// Find applies to calls returning a single resource.
+	Find Duration `json:"find"`
+}
+
+func (c TimeoutsConfig) withDefaults() TimeoutsConfig {
+	if c.Create <= 0 {
+		c.Create = defaultCreateTimeout
+	}
+	if c.Delete <= 0 {
+		c.Delete = defaultDeleteTimeout
+	}
+	if c.List <= 0 {
+		c.List = defaultListTimeout
+	}
+	if c.Find <= 0 {
+		c.Find = defaultFindTimeout
+	}
+	return c
+}
+
+// timeout returns the timeout for the API call op, which is classified by
+// the naming of the SDK operations, e.g. ServersGet or ServersFindById.
+func (c TimeoutsConfig) timeout(op string) time.Duration {
+	c = c.withDefaults()
+	switch {
+	case op == "Increase", strings.HasSuffix(op, "Post"), strings.HasSuffix(op, "Put"), strings.HasSuffix(op, "Patch"):
+		return time.Duration(c.Create)
+	case op == "Decrease", strings.HasSuffix(op, "Delete"):
+		return time.Duration(c.Delete)
+	case strings.HasSuffix(op, "Get"):
+		return time.Duration(c.List)
+	}
+	return time.Duration(c.Find)
+}
+
+// withOpTimeout returns the context for a single attempt of the API call op.
+func (i *InstanceGroup) withOpTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, i.Timeouts.timeout(op))
+}
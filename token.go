@@ -0,0 +1,136 @@
+package ionos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+const (
+	labelPermissionCheck = "fleeting-permission-check"
+	tokenExpiryWarning   = 7 * 24 * time.Hour
+
+	credentialsHint = "set ionos_token, ionos_token_file or vault in the plugin config, or IONOS_TOKEN in the environment of GitLab Runner"
+)
+
+// tokenClaims are the claims of an IONOS token the plugin checks. Tokens
+// are JWTs, whose signature is verified by the API and not here.
+type tokenClaims struct {
+	ExpiresAt int64 `json:"exp"`
+	Identity  struct {
+		Role           string `json:"role"`
+		ContractNumber int64  `json:"contractNumber"`
+	} `json:"identity"`
+}
+
+// parseTokenClaims decodes the payload of a JWT. ok is false if the token
+// is not a JWT, e.g. basic auth or a token of a test environment.
+func parseTokenClaims(token string) (claims tokenClaims, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+// validateCredentials checks during Init that the token is accepted and not
+// expired, so a bad credential fails Init instead of the first scale-up.
+// owner reports whether the token belongs to the contract owner, who may
+// modify every datacenter.
+func (i *InstanceGroup) validateCredentials(ctx context.Context) (owner bool, err error) {
+	var token string
+	if i.apiToken != nil {
+		token = i.apiToken.get()
+	}
+	if i.apiToken != nil && token == "" {
+		return false, fmt.Errorf("no IONOS credentials configured: %s", credentialsHint)
+	}
+	claims, isJWT := parseTokenClaims(token)
+	if isJWT && claims.ExpiresAt > 0 {
+		expires := time.Unix(claims.ExpiresAt, 0)
+		if time.Now().After(expires) {
+			return false, fmt.Errorf("ionos token expired at %s, create a new one and %s", expires.Format(time.RFC3339), credentialsHint)
+		}
+		if time.Until(expires) < tokenExpiryWarning {
+			i.log.Warn("IONOS token expires soon", "expires", expires.Format(time.RFC3339))
+		}
+	}
+
+	if _, err := i.resourceLimits(ctx); err != nil {
+		return false, credentialsError(err)
+	}
+	return isJWT && claims.Identity.Role == "owner", nil
+}
+
+// credentialsError explains a failed authenticated call, so the operator
+// knows what to fix instead of seeing a bare 401.
+func credentialsError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Class != ErrClassAuth {
+		return fmt.Errorf("verifying ionos token: %w", err)
+	}
+	if apiErr.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("ionos token is valid but not permitted to read the contract, use a token of a user with access to it: %w", err)
+	}
+	return fmt.Errorf("ionos token was rejected, it is invalid, expired or revoked; %s: %w", credentialsHint, err)
+}
+
+// checkWritePermission adds and removes a label on the datacenter, which
+// requires the edit privilege that creating servers in it needs as well.
+// Users other than the owner cannot read the shares of their groups, so
+// this is the only way to find out. The label key is unique per check, so
+// concurrent checks and labels left behind by a crashed check do not
+// collide, and a label that exists already proves the permission as well.
+func (i *InstanceGroup) checkWritePermission(ctx context.Context, datacenterID string) error {
+	key := labelPermissionCheck + "-" + newIdempotencyToken()
+	_, _, err := withRetry(ctx, i, "DatacentersLabelsPost", func(ctx context.Context) (compute.LabelResource, *shared.APIResponse, error) {
+		return i.api.AddDatacenterLabel(ctx, datacenterID, key, i.groupLabel())
+	})
+	if errors.Is(err, ErrClassAuth) {
+		return fmt.Errorf("ionos token cannot modify datacenter %s, grant its group edit access to the datacenter: %w", datacenterID, err)
+	}
+	if isLabelExists(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("checking write permission on datacenter %s: %w", datacenterID, err)
+	}
+	_, err = withRetryNoResult(ctx, i, "DatacentersLabelsDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+		return i.api.DeleteDatacenterLabel(ctx, datacenterID, key)
+	})
+	if err != nil {
+		i.log.Warn("Failed to remove permission check label", "datacenter", datacenterID, "key", key, "err", err)
+	}
+	return nil
+}
+
+// isLabelExists reports whether the API rejected a label because the
+// resource has a label with the same key already.
+func isLabelExists(err error) bool {
+	var apiErr shared.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode() {
+	case http.StatusConflict:
+		return true
+	case http.StatusUnprocessableEntity:
+		return strings.Contains(strings.ToLower(string(apiErr.Body())), "already exists")
+	}
+	return false
+}
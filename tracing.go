@@ -0,0 +1,111 @@
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/codecentric/fleeting-plugin-ionos"
+
+type TracingConfig struct {
+	Enabled bool `json:"enabled"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. "localhost:4318". When
+	// empty the standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint    string `json:"endpoint"`
+	Insecure    bool   `json:"insecure"`
+	ServiceName string `json:"service_name"`
+}
+
+// initTracing sets up the OTLP exporter when tracing is enabled. Without it,
+// spans go to the global tracer provider, which is a no-op by default.
+func (i *InstanceGroup) initTracing(ctx context.Context) error {
+	if !i.Tracing.Enabled {
+		i.tracer = otel.Tracer(tracerName)
+		return nil
+	}
+
+	var opts []otlptracehttp.Option
+	if i.Tracing.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(i.Tracing.Endpoint))
+	}
+	if i.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("creating OTLP trace exporter: %w", err)
+	}
+
+	serviceName := i.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = Version.Name
+	}
+	res := resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(Version.String()),
+		attribute.String("fleeting.group", i.Name),
+	)
+
+	i.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	i.tracer = i.tracerProvider.Tracer(tracerName)
+	return nil
+}
+
+func (i *InstanceGroup) shutdownTracing(ctx context.Context) error {
+	if i.tracerProvider == nil {
+		return nil
+	}
+	return i.tracerProvider.Shutdown(ctx)
+}
+
+// startSpan starts a span for name, which also becomes the operation of
+// ctx for the audit log unless an outer span has set it.
+func (i *InstanceGroup) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx = withOperation(ctx, name)
+	tracer := i.tracer
+	if tracer == nil {
+		tracer = otel.Tracer(tracerName)
+	}
+	if i.DatacenterId != "" {
+		attrs = append(attrs, attribute.String("ionos.datacenter_id", i.DatacenterId))
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on the span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// requestID extracts the IONOS request ID from the Location header that the
+// API returns for asynchronous operations.
+func requestID(apiResponse *shared.APIResponse) string {
+	if apiResponse == nil || apiResponse.Response == nil {
+		return ""
+	}
+	location := apiResponse.Header.Get("Location")
+	if location == "" {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSuffix(location, "/status"), "/")
+	return parts[len(parts)-1]
+}
@@ -0,0 +1,162 @@
+package ionos
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultConnectTimeout  = Duration(10 * time.Second)
+	defaultRequestTimeout  = Duration(60 * time.Second)
+	defaultKeepAlive       = Duration(30 * time.Second)
+	defaultIdleConnTimeout = Duration(90 * time.Second)
+	defaultMaxIdleConns    = 10
+)
+
+// HTTPConfig tunes the HTTP client used for the IONOS API, so a slow API
+// cannot block the plugin indefinitely.
+type HTTPConfig struct {
+	// ConnectTimeout bounds establishing the TCP connection.
+	ConnectTimeout Duration `json:"connect_timeout"`
+	// ResponseHeaderTimeout bounds waiting for the response headers after
+	// the request has been sent, 0 means no limit besides request_timeout.
+	ResponseHeaderTimeout Duration `json:"response_header_timeout"`
+	// RequestTimeout bounds a whole request including reading the body.
+	RequestTimeout  Duration `json:"request_timeout"`
+	KeepAlive       Duration `json:"keep_alive"`
+	IdleConnTimeout Duration `json:"idle_conn_timeout"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	// CAFile is a PEM bundle trusted in addition to the system roots, e.g.
+	// for TLS-intercepting proxies or API-compatible test environments.
+	CAFile string      `json:"ca_file"`
+	Proxy  ProxyConfig `json:"proxy"`
+}
+
+// ProxyConfig sets the proxy for API requests explicitly, since GitLab
+// Runner may exec the plugin without the proxy environment variables. Proxy
+// URLs may use the http, https and socks5 schemes.
+type ProxyConfig struct {
+	HTTP    string `json:"http"`
+	HTTPS   string `json:"https"`
+	NoProxy string `json:"no_proxy"`
+}
+
+// proxyFunc returns the proxy selection for the transport, falling back to
+// the process environment when no proxy is configured.
+func (c ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if c.HTTP == "" && c.HTTPS == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	for _, raw := range []string{c.HTTP, c.HTTPS} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("proxy URL %q: unsupported scheme %q, use http, https or socks5", raw, u.Scheme)
+		}
+	}
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  c.HTTP,
+		HTTPSProxy: c.HTTPS,
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+func (c HTTPConfig) withDefaults() HTTPConfig {
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = defaultConnectTimeout
+	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = defaultRequestTimeout
+	}
+	if c.KeepAlive == 0 {
+		c.KeepAlive = defaultKeepAlive
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = defaultMaxIdleConns
+	}
+	return c
+}
+
+// tlsConfig returns the TLS settings for the API client, or nil to use
+// the defaults.
+func (c HTTPConfig) tlsConfig() (*tls.Config, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading ca_file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_file %s contains no PEM certificates", c.CAFile)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// transport returns the HTTP transport for the configured settings. A
+// negative keep_alive disables keep-alive.
+func (c HTTPConfig) transport() (*http.Transport, error) {
+	c = c.withDefaults()
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := c.Proxy.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(c.ConnectTimeout),
+		KeepAlive: time.Duration(c.KeepAlive),
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dialer.DialContext,
+		DisableKeepAlives:     c.KeepAlive < 0,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConns,
+		IdleConnTimeout:       time.Duration(c.IdleConnTimeout),
+		TLSHandshakeTimeout:   time.Duration(c.ConnectTimeout),
+		ResponseHeaderTimeout: time.Duration(c.ResponseHeaderTimeout),
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     true,
+	}, nil
+}
+
+// client returns the HTTP client for the IONOS API.
+func (c HTTPConfig) client() (*http.Client, error) {
+	transport, err := c.transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(c.withDefaults().RequestTimeout),
+	}, nil
+}
@@ -0,0 +1,241 @@
+package ionos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"text/template"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// userDataVars are the per-instance variables available to a user_data
+// template.
+type userDataVars struct {
+	Name         string
+	Index        int
+	Group        string
+	DatacenterID string
+}
+
+// UserDataFragment is a piece of user data merged with user_data, given
+// inline as content or read from file.
+type UserDataFragment struct {
+	Content string `json:"content"`
+	File    string `json:"file"`
+}
+
+func (f UserDataFragment) load() (string, error) {
+	if f.File == "" {
+		return f.Content, nil
+	}
+	data, err := os.ReadFile(f.File)
+	if err != nil {
+		return "", fmt.Errorf("reading user_data_fragments file: %w", err)
+	}
+	return string(data), nil
+}
+
+func validateUserDataFragments(field string, fragments []UserDataFragment) error {
+	for n, f := range fragments {
+		if (f.Content == "") == (f.File == "") {
+			return fmt.Errorf("%s[%d]: one of content/file is required", field, n)
+		}
+	}
+	return nil
+}
+
+// hasUserData reports whether any user data is configured.
+func (i *InstanceGroup) hasUserData() bool {
+	return i.ServerSpec.UserData != "" || i.ServerSpec.UserDataFile != "" || len(i.ServerSpec.UserDataFragments) > 0
+}
+
+// loadUserData returns the configured user data, reading user_data_file on
+// every call so the file can be changed without restarting the runner.
+func (i *InstanceGroup) loadUserData() (string, error) {
+	if i.ServerSpec.UserDataFile == "" {
+		return i.ServerSpec.UserData, nil
+	}
+	data, err := os.ReadFile(i.ServerSpec.UserDataFile)
+	if err != nil {
+		return "", fmt.Errorf("reading user_data_file: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseUserDataTemplate(userData string) (*template.Template, error) {
+	tmpl, err := template.New("user_data").Option("missingkey=error").Parse(userData)
+	if err != nil {
+		return nil, fmt.Errorf("parsing user_data template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// validateUserData checks that the user data and fragments can be loaded
+// and, if enabled, parsed as templates.
+func (i *InstanceGroup) validateUserData(ctx context.Context) error {
+	if err := validateUserDataFragments("user_data_fragments", i.ServerSpec.UserDataFragments); err != nil {
+		return err
+	}
+	for n, v := range i.ServerSpecs {
+		if err := validateUserDataFragments(fmt.Sprintf("server_specs[%d].user_data_fragments", n), v.UserDataFragments); err != nil {
+			return err
+		}
+	}
+
+	parts, err := i.loadUserDataParts(nil)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("user_data must not be empty")
+	}
+	for _, part := range parts {
+		if i.ServerSpec.UserDataTemplate {
+			if _, err := parseUserDataTemplate(part); err != nil {
+				return err
+			}
+		}
+		if _, err := i.interpolateSecrets(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUserDataParts returns user_data followed by the fragments of the
+// group and the variant, skipping empty ones.
+func (i *InstanceGroup) loadUserDataParts(variant *SpecVariant) ([]string, error) {
+	userData, err := i.loadUserData()
+	if err != nil {
+		return nil, err
+	}
+	fragments := i.ServerSpec.UserDataFragments
+	if variant != nil {
+		fragments = append(slices.Clip(fragments), variant.UserDataFragments...)
+	}
+
+	var parts []string
+	if userData != "" {
+		parts = append(parts, userData)
+	}
+	for _, f := range fragments {
+		part, err := f.load()
+		if err != nil {
+			return nil, err
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts, nil
+}
+
+// renderUserData returns the user data for a single instance, rendering it as
+// a Go template when user_data_template is enabled and resolving ${env:...}
+// and ${file:...} placeholders. The fragments of the group and the variant
+// and, with user_data_metadata, the instance metadata and, with a static IP,
+// its network config are merged with user_data into a multi-part MIME
+// archive.
+func (i *InstanceGroup) renderUserData(ctx context.Context, datacenterID string, name string, index int, variant *SpecVariant, staticIP string) (string, error) {
+	parts, err := i.loadUserDataParts(variant)
+	if err != nil {
+		return "", err
+	}
+	vars := userDataVars{
+		Name:         name,
+		Index:        index,
+		Group:        i.Name,
+		DatacenterID: datacenterID,
+	}
+
+	for n, part := range parts {
+		if i.ServerSpec.UserDataTemplate {
+			tmpl, err := parseUserDataTemplate(part)
+			if err != nil {
+				return "", err
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, vars); err != nil {
+				return "", fmt.Errorf("rendering user_data template: %w", err)
+			}
+			part = buf.String()
+		}
+		// Secrets are resolved after templating, so their values are
+		// never parsed as template.
+		if parts[n], err = i.interpolateSecrets(ctx, part); err != nil {
+			return "", err
+		}
+	}
+
+	if i.ServerSpec.UserDataMetadata {
+		parts = append([]string{metadataCloudConfig(vars)}, parts...)
+	}
+	if staticIP != "" {
+		parts = append(parts, i.ServerSpec.StaticIPs.cloudConfig(staticIP))
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return multipartUserData(parts)
+}
+
+// setInstanceUserData replaces the user data of a create request with one
+// including the fragments of the variant and the network config of the
+// static IP, if any.
+func (i *InstanceGroup) setInstanceUserData(ctx context.Context, serverData *compute.Server, datacenterID, name string, index int, variant *SpecVariant, staticIP string) error {
+	if (variant == nil || len(variant.UserDataFragments) == 0) && staticIP == "" {
+		return nil
+	}
+	rendered, err := i.renderUserData(ctx, datacenterID, name, index, variant, staticIP)
+	if err != nil {
+		return err
+	}
+	encoded, err := i.encodeUserData(rendered)
+	if err != nil {
+		return err
+	}
+	(*serverData.Entities.Volumes.Items)[0].Properties.UserData = &encoded
+	return nil
+}
+
+// metadataCloudConfig is a cloud-config that sets the hostname to the
+// instance name and writes the instance metadata to /etc/fleeting/instance.env
+// as shell variables, e.g. for the runner description.
+func metadataCloudConfig(vars userDataVars) string {
+	return fmt.Sprintf(`#cloud-config
+preserve_hostname: false
+hostname: %[1]s
+write_files:
+  - path: /etc/fleeting/instance.env
+    permissions: "0644"
+    content: |
+      FLEETING_INSTANCE_NAME=%[1]s
+      FLEETING_INSTANCE_INDEX=%[2]d
+      FLEETING_GROUP=%[3]q
+      FLEETING_DATACENTER_ID=%[4]s
+`, vars.Name, vars.Index, vars.Group, vars.DatacenterID)
+}
+
+// encodeUserData base64 encodes the user data as expected by the API,
+// compressing it first when user_data_gzip is enabled. cloud-init detects
+// and decompresses gzip content on its own.
+func (i *InstanceGroup) encodeUserData(userData string) (string, error) {
+	if !i.ServerSpec.UserDataGzip {
+		return base64.StdEncoding.EncodeToString([]byte(userData)), nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(userData)); err != nil {
+		return "", fmt.Errorf("compressing user_data: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("compressing user_data: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,170 @@
+package ionos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderUserData(t *testing.T) {
+	t.Setenv("TEST_RUNNER_TOKEN", "glrt-{{.Name}}")
+	for _, tc := range []struct {
+		name     string
+		userData string
+		template bool
+		want     string
+		err      string
+	}{
+		{"template", "hostname: {{.Name}}-{{.Index}}\ngroup: {{.Group}}\n", true, "hostname: runner-3-abcd-3\ngroup: group\n", ""},
+		{"template disabled", "hostname: {{.Name}}\n", false, "hostname: {{.Name}}\n", ""},
+		// Secrets are not rendered as template.
+		{"secret", "token: ${env:TEST_RUNNER_TOKEN}\n", true, "token: glrt-{{.Name}}\n", ""},
+		{"escaped placeholder", "literal: $${env:X}\n", true, "literal: ${env:X}\n", ""},
+		{"unknown variable", "hostname: {{.Hostname}}\n", true, "", "rendering user_data template"},
+		{"missing env", "token: ${env:TEST_UNSET_VARIABLE}\n", false, "", "TEST_UNSET_VARIABLE"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.Name = "group"
+			i.ServerSpec.UserData = tc.userData
+			i.ServerSpec.UserDataTemplate = tc.template
+
+			got, err := i.renderUserData(context.Background(), "dc1", "runner-3-abcd", 3, nil, "")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("renderUserData error %v, want one containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("renderUserData = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderUserDataParts(t *testing.T) {
+	variant := &SpecVariant{UserDataFragments: []UserDataFragment{{Content: "#!/bin/sh\necho variant\n"}}}
+	for _, tc := range []struct {
+		name     string
+		variant  *SpecVariant
+		metadata bool
+		// want lists the parts in the order they must appear.
+		want []string
+	}{
+		{"group fragments", nil, false, []string{"packages: [git]", "echo group"}},
+		{"variant fragments", variant, false, []string{"packages: [git]", "echo group", "echo variant"}},
+		{"metadata", variant, true, []string{"FLEETING_INSTANCE_NAME=runner-1-abcd", "packages: [git]", "echo group", "echo variant"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.Name = "group"
+			i.ServerSpec.UserData = "#cloud-config\npackages: [git]\n"
+			i.ServerSpec.UserDataFragments = []UserDataFragment{{Content: "#!/bin/sh\necho group\n"}}
+			i.ServerSpec.UserDataMetadata = tc.metadata
+
+			got, err := i.renderUserData(context.Background(), "dc1", "runner-1-abcd", 1, tc.variant, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got, "Content-Type: multipart/mixed") {
+				t.Fatalf("renderUserData did not return a multi-part archive: %q", got)
+			}
+			last := -1
+			for _, s := range tc.want {
+				n := strings.Index(got, s)
+				if n < 0 || n < last {
+					t.Fatalf("renderUserData does not contain %q after the previous parts: %q", s, got)
+				}
+				last = n
+			}
+			if tc.variant == nil && strings.Contains(got, "echo variant") {
+				t.Errorf("renderUserData contains the fragments of a variant: %q", got)
+			}
+			// The variant's fragments must not leak into the group's.
+			if len(i.ServerSpec.UserDataFragments) != 1 {
+				t.Errorf("renderUserData changed user_data_fragments to %v", i.ServerSpec.UserDataFragments)
+			}
+		})
+	}
+}
+
+func TestEncodeUserData(t *testing.T) {
+	userData := "#cloud-config\npackages: [git]\n"
+	for _, tc := range []struct {
+		name string
+		gzip bool
+	}{
+		{"plain", false},
+		{"gzip", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := newTestGroup(nil)
+			i.ServerSpec.UserDataGzip = tc.gzip
+
+			encoded, err := i.encodeUserData(userData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var r io.Reader = bytes.NewReader(data)
+			if tc.gzip {
+				if r, err = gzip.NewReader(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if decoded, _ := io.ReadAll(r); string(decoded) != userData {
+				t.Errorf("encodeUserData decodes to %q, want %q", decoded, userData)
+			}
+		})
+	}
+}
+
+func TestLoadUserData(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "user-data")
+	for _, tc := range []struct {
+		name     string
+		userData string
+		file     string
+		content  string
+		want     string
+		err      bool
+	}{
+		{"inline", "#cloud-config\n", "", "", "#cloud-config\n", false},
+		{"file", "", file, "#!/bin/sh\n", "#!/bin/sh\n", false},
+		// The file is read on every call, so changes apply to new instances.
+		{"changed file", "", file, "#!/bin/sh\necho changed\n", "#!/bin/sh\necho changed\n", false},
+		{"missing file", "", filepath.Join(t.TempDir(), "missing"), "", "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.content != "" {
+				if err := os.WriteFile(tc.file, []byte(tc.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			i := newTestGroup(nil)
+			i.ServerSpec.UserData = tc.userData
+			i.ServerSpec.UserDataFile = tc.file
+
+			got, err := i.loadUserData()
+			if (err != nil) != tc.err {
+				t.Fatalf("loadUserData error %v, want error %t", err, tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("loadUserData = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
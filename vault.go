@@ -0,0 +1,338 @@
+package ionos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultVaultKey             = "token"
+	defaultVaultAuthPath        = "approle"
+	defaultVaultRefreshInterval = Duration(5 * time.Minute)
+)
+
+// VaultConfig fetches the IONOS token from HashiCorp Vault instead of the
+// plugin config. The Vault token is renewed and the secret re-read while the
+// plugin runs, so a token rotated in Vault is picked up without a restart.
+type VaultConfig struct {
+	// Address of the Vault server, defaults to VAULT_ADDR.
+	Address string `json:"address"`
+	// Token authenticates to Vault, defaults to VAULT_TOKEN. TokenFile
+	// reads it from a file instead, e.g. one written by Vault Agent.
+	Token     string `json:"token"`
+	TokenFile string `json:"token_file"`
+	// RoleID and SecretIDFile log in with AppRole instead of a token.
+	RoleID       string `json:"role_id"`
+	SecretIDFile string `json:"secret_id_file"`
+	// AuthPath is the mount of the AppRole auth method, defaults to approle.
+	AuthPath  string `json:"auth_path"`
+	Namespace string `json:"namespace"`
+	// Path is the secret with the IONOS token, e.g.
+	// "secret/data/fleeting/ionos" for a KV version 2 engine.
+	Path string `json:"path"`
+	// Key is the field of the secret holding the token, defaults to token.
+	Key string `json:"key"`
+	// RefreshInterval is how often the secret is re-read, defaults to 5m.
+	RefreshInterval Duration `json:"refresh_interval"`
+}
+
+func (c VaultConfig) enabled() bool {
+	return c.Path != ""
+}
+
+func (c VaultConfig) withDefaults() VaultConfig {
+	if c.Address == "" {
+		c.Address = os.Getenv("VAULT_ADDR")
+	}
+	if c.Token == "" && c.TokenFile == "" && c.RoleID == "" {
+		c.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if c.Namespace == "" {
+		c.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if c.AuthPath == "" {
+		c.AuthPath = defaultVaultAuthPath
+	}
+	if c.Key == "" {
+		c.Key = defaultVaultKey
+	}
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultVaultRefreshInterval
+	}
+	return c
+}
+
+// vaultClient is a minimal client for the Vault HTTP API covering token and
+// AppRole auth and reading KV secrets.
+type vaultClient struct {
+	cfg  VaultConfig
+	http *http.Client
+
+	mu        sync.Mutex
+	token     string
+	renewable bool
+	expiresAt time.Time
+	secrets   map[string]vaultSecret
+}
+
+type vaultSecret struct {
+	data   map[string]any
+	readAt time.Time
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+func newVaultClient(cfg VaultConfig, httpClient *http.Client) (*vaultClient, error) {
+	cfg = cfg.withDefaults()
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault requires address or VAULT_ADDR")
+	}
+	if cfg.RoleID != "" && cfg.SecretIDFile == "" {
+		return nil, fmt.Errorf("vault role_id requires secret_id_file")
+	}
+	if cfg.Token == "" && cfg.TokenFile == "" && cfg.RoleID == "" {
+		return nil, fmt.Errorf("vault requires token, token_file, role_id or VAULT_TOKEN")
+	}
+	return &vaultClient{
+		cfg:     cfg,
+		http:    httpClient,
+		secrets: map[string]vaultSecret{},
+	}, nil
+}
+
+// login obtains the Vault token, with AppRole or from the config, and looks
+// up its TTL for renewal.
+func (v *vaultClient) login(ctx context.Context) error {
+	if v.cfg.RoleID != "" {
+		secretID, err := os.ReadFile(v.cfg.SecretIDFile)
+		if err != nil {
+			return fmt.Errorf("reading vault secret_id_file: %w", err)
+		}
+		var resp struct {
+			Auth vaultAuth `json:"auth"`
+		}
+		body := map[string]string{
+			"role_id":   v.cfg.RoleID,
+			"secret_id": strings.TrimSpace(string(secretID)),
+		}
+		if err := v.do(ctx, http.MethodPost, "auth/"+v.cfg.AuthPath+"/login", "", body, &resp); err != nil {
+			return fmt.Errorf("vault approle login: %w", err)
+		}
+		v.setAuth(resp.Auth)
+		return nil
+	}
+
+	token := v.cfg.Token
+	if v.cfg.TokenFile != "" {
+		data, err := os.ReadFile(v.cfg.TokenFile)
+		if err != nil {
+			return fmt.Errorf("reading vault token_file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	var resp struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, "auth/token/lookup-self", token, nil, &resp); err != nil {
+		return fmt.Errorf("vault token lookup: %w", err)
+	}
+	v.setAuth(vaultAuth{ClientToken: token, LeaseDuration: resp.Data.TTL, Renewable: resp.Data.Renewable})
+	return nil
+}
+
+func (v *vaultClient) setAuth(auth vaultAuth) {
+	secrets.add(auth.ClientToken)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = auth.ClientToken
+	v.renewable = auth.Renewable
+	v.expiresAt = time.Time{}
+	if auth.LeaseDuration > 0 {
+		v.expiresAt = time.Now().Add(time.Duration(auth.LeaseDuration) * time.Second)
+	}
+}
+
+// renew extends the Vault token when it would expire within two refresh
+// intervals, or logs in again if it cannot be renewed. Tokens without TTL are left alone, except
+// that a token_file is re-read since Vault Agent rotates it.
+func (v *vaultClient) renew(ctx context.Context) error {
+	v.mu.Lock()
+	token, renewable, expiresAt := v.token, v.renewable, v.expiresAt
+	v.mu.Unlock()
+
+	if expiresAt.IsZero() {
+		if v.cfg.TokenFile != "" {
+			return v.login(ctx)
+		}
+		return nil
+	}
+	if time.Until(expiresAt) > time.Duration(v.cfg.RefreshInterval)*2 {
+		return nil
+	}
+	if renewable {
+		var resp struct {
+			Auth vaultAuth `json:"auth"`
+		}
+		err := v.do(ctx, http.MethodPost, "auth/token/renew-self", token, struct{}{}, &resp)
+		if err == nil {
+			resp.Auth.ClientToken = token
+			v.setAuth(resp.Auth)
+			return nil
+		}
+		if v.cfg.RoleID == "" && v.cfg.TokenFile == "" {
+			return fmt.Errorf("renewing vault token: %w", err)
+		}
+	}
+	return v.login(ctx)
+}
+
+// read returns the data of the secret at path, unwrapping the KV version 2
+// envelope. Secrets are cached for the refresh interval.
+func (v *vaultClient) read(ctx context.Context, path string) (map[string]any, error) {
+	path = strings.Trim(path, "/")
+	v.mu.Lock()
+	cached, ok := v.secrets[path]
+	token := v.token
+	v.mu.Unlock()
+	if ok && time.Since(cached.readAt) < time.Duration(v.cfg.RefreshInterval) {
+		return cached.data, nil
+	}
+
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, path, token, nil, &resp); err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", path, err)
+	}
+	data := resp.Data
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+
+	v.mu.Lock()
+	v.secrets[path] = vaultSecret{data: data, readAt: time.Now()}
+	v.mu.Unlock()
+	return data, nil
+}
+
+// secret returns the string field key of the secret at path.
+func (v *vaultClient) secret(ctx context.Context, path, key string) (string, error) {
+	data, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string field %s", path, key)
+	}
+	return value, nil
+}
+
+// apiToken returns the IONOS token from the configured secret.
+func (v *vaultClient) apiToken(ctx context.Context) (string, error) {
+	return v.secret(ctx, v.cfg.Path, v.cfg.Key)
+}
+
+// invalidate drops the cached secrets, so the next read fetches them again.
+func (v *vaultClient) invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.secrets = map[string]vaultSecret{}
+}
+
+func (v *vaultClient) do(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	url := strings.TrimSuffix(v.cfg.Address, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(vaultErr.Errors, ", "))
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// initVault logs in to Vault. The IONOS token is read from it by
+// loadAPIToken.
+func (i *InstanceGroup) initVault(ctx context.Context, httpClient *http.Client) error {
+	client, err := newVaultClient(i.Vault, httpClient)
+	if err != nil {
+		return err
+	}
+	if err := client.login(ctx); err != nil {
+		return err
+	}
+	i.vault = client
+	return nil
+}
+
+// refreshVault renews the Vault token and re-reads the IONOS token, so a
+// token rotated in Vault is used for the next requests.
+func (i *InstanceGroup) refreshVault(ctx context.Context) error {
+	if err := i.vault.renew(ctx); err != nil {
+		return err
+	}
+	_, err := i.reloadCredentials(ctx)
+	return err
+}
+
+// startVaultRefresh refreshes the Vault credentials in the background.
+func (i *InstanceGroup) startVaultRefresh() {
+	if i.vault == nil {
+		return
+	}
+	i.runPeriodic("vault-refresh", time.Duration(i.vault.cfg.RefreshInterval), i.refreshVault)
+}
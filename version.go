@@ -5,7 +5,7 @@ import (
 )
 
 var (
-	NAME      = "fleeting-plugin-aws"
+	NAME      = "fleeting-plugin-ionos"
 	VERSION   = "dev"
 	REVISION  = "HEAD"
 	REFERENCE = "HEAD"
@@ -0,0 +1,111 @@
+package ionos
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	volumeTypeHDD = "HDD"
+	volumeTypeSSD = "SSD"
+	volumeTypeDAS = "DAS"
+
+	defaultVolumeTier = "standard"
+)
+
+// volumeType returns the API volume type for a configured volume_type. "SSD"
+// is combined with volume_tier into e.g. "SSD Premium", so new tiers only
+// need a config change.
+func (s ServerSpec) volumeType(volumeType string) string {
+	if !strings.EqualFold(volumeType, volumeTypeSSD) {
+		return volumeType
+	}
+	tier := s.VolumeTier
+	if tier == "" {
+		tier = defaultVolumeTier
+	}
+	return volumeTypeSSD + " " + strings.ToUpper(tier[:1]) + strings.ToLower(tier[1:])
+}
+
+// validateVolumeType checks a volume_type against the types the API knows:
+// HDD, DAS and the SSD tiers.
+func validateVolumeType(field, volumeType string) error {
+	switch {
+	case volumeType == "",
+		strings.EqualFold(volumeType, volumeTypeHDD),
+		strings.EqualFold(volumeType, volumeTypeDAS),
+		strings.EqualFold(volumeType, volumeTypeSSD),
+		strings.HasPrefix(strings.ToUpper(volumeType), volumeTypeSSD+" "):
+		return nil
+	}
+	return fmt.Errorf("%s %q is not a volume type, use 'HDD', 'SSD' with volume_tier, 'SSD Standard', 'SSD Premium' or 'DAS'", field, volumeType)
+}
+
+// validateServerVolume checks that 'CUBE' servers use DAS volumes, sized by
+// their template, and that only they do.
+func validateServerVolume(field, serverType, volumeType string, storageSize float32) error {
+	isDAS := strings.EqualFold(volumeType, volumeTypeDAS)
+	switch {
+	case serverType == "CUBE" && volumeType != "" && !isDAS:
+		return fmt.Errorf("%svolume_type %q is not available for 'CUBE' servers, they use 'DAS' volumes (the default)", field, volumeType)
+	case serverType == "CUBE" && storageSize != 0:
+		return fmt.Errorf("%sstorage_size cannot be set for 'CUBE' servers, the DAS volume size is part of the template, pick a bigger template instead", field)
+	case serverType == "ENTERPRISE" && isDAS:
+		return fmt.Errorf("%svolume_type 'DAS' is only available for 'CUBE' servers, use 'HDD' or 'SSD' for 'ENTERPRISE'", field)
+	}
+	return nil
+}
+
+// specVolumeType returns the configured volume type of a variant, nil being
+// server_spec, defaulting to DAS for 'CUBE' servers and to the fallback
+// volume type for 'ENTERPRISE' variants of a 'CUBE' spec.
+func (i *InstanceGroup) specVolumeType(v *SpecVariant) string {
+	typ := i.specType(v)
+	volumeType := i.ServerSpec.VolumeType
+	if v != nil && v.VolumeType != "" {
+		volumeType = v.VolumeType
+	} else if v != nil && typ != i.ServerSpec.Type {
+		volumeType = ""
+	}
+	switch {
+	case typ == "CUBE":
+		return volumeTypeDAS
+	case volumeType == "":
+		return defaultFallbackVolumeType
+	}
+	return i.ServerSpec.volumeType(volumeType)
+}
+
+func (i *InstanceGroup) validateVolumeTypes() error {
+	if err := validateServerVolume("", i.ServerSpec.Type, i.ServerSpec.VolumeType, i.ServerSpec.StorageSize); err != nil {
+		return err
+	}
+	for n, v := range i.ServerSpecs {
+		if err := validateServerVolume(fmt.Sprintf("server_specs[%d].", n), i.specType(&v), v.VolumeType, v.StorageSize); err != nil {
+			return err
+		}
+	}
+	if fallback := i.ServerSpec.EnterpriseFallback; fallback != nil {
+		if err := validateServerVolume("enterprise_fallback.", "ENTERPRISE", fallback.VolumeType, fallback.StorageSize); err != nil {
+			return err
+		}
+	}
+
+	if i.ServerSpec.VolumeTier != "" && !strings.EqualFold(i.ServerSpec.VolumeType, volumeTypeSSD) {
+		return fmt.Errorf("volume_tier requires volume_type 'SSD'")
+	}
+	if err := validateVolumeType("volume_type", i.ServerSpec.VolumeType); err != nil {
+		return err
+	}
+	if fallback := i.ServerSpec.EnterpriseFallback; fallback != nil {
+		if err := validateVolumeType("enterprise_fallback.volume_type", fallback.VolumeType); err != nil {
+			return err
+		}
+	}
+	for n, v := range i.ServerSpecs {
+		if err := validateVolumeType(fmt.Sprintf("server_specs[%d].volume_type", n), v.VolumeType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
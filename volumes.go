@@ -0,0 +1,76 @@
+package ionos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+	"github.com/ionos-cloud/sdk-go-bundle/shared"
+)
+
+// SweepVolumes deletes volumes of the group that are no longer attached to a
+// server, e.g. left behind by servers deleted without their volumes. Volumes
+// are matched by their group label, or by name for volumes without one. It
+// returns the IDs of the deleted volumes.
+func (i *InstanceGroup) SweepVolumes(ctx context.Context) ([]string, error) {
+	ctx = withOperation(ctx, "SweepVolumes")
+	groups, err := i.resourceLabel(ctx, "volume", labelGroup)
+	if err != nil {
+		return nil, fmt.Errorf("listing volume labels: %w", err)
+	}
+
+	var deleted []string
+	for _, dc := range i.datacenters() {
+		ids, err2 := i.sweepDatacenterVolumes(ctx, dc.ID, groups)
+		deleted = append(deleted, ids...)
+		err = errors.Join(err, err2)
+	}
+	return deleted, err
+}
+
+func (i *InstanceGroup) sweepDatacenterVolumes(ctx context.Context, datacenterID string, groups map[string]string) ([]string, error) {
+	volumes, _, err := withRetry(ctx, i, "VolumesGet", func(ctx context.Context) (compute.Volumes, *shared.APIResponse, error) {
+		return i.api.ListVolumes(ctx, datacenterID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing volumes: %w", err)
+	}
+	if volumes.Items == nil {
+		return nil, nil
+	}
+
+	var deleted []string
+	for _, volume := range *volumes.Items {
+		if !i.isGroupVolume(volume, groups) {
+			continue
+		}
+		if volume.Properties == nil || volume.Properties.BootServer != nil {
+			continue
+		}
+		if volume.Metadata == nil || volume.Metadata.State == nil || *volume.Metadata.State != "AVAILABLE" {
+			continue
+		}
+
+		id := *volume.Id
+		var name string
+		if volume.Properties.Name != nil {
+			name = *volume.Properties.Name
+		}
+		if i.dryRun("would delete orphaned volume", "id", id, "name", name) {
+			continue
+		}
+		apiResponse, err2 := withRetryNoResult(ctx, i, "VolumesDelete", func(ctx context.Context) (*shared.APIResponse, error) {
+			return i.api.DeleteVolume(ctx, datacenterID, id)
+		})
+		i.audit(ctx, auditEvent{Action: "delete", ResourceType: "volume", ResourceID: id, Name: name, Datacenter: datacenterID}, apiResponse, err2)
+		if err2 != nil {
+			i.log.Error("Failed to delete orphaned volume", "err", err2, "id", id)
+			err = errors.Join(err, err2)
+			continue
+		}
+		i.log.Info("Deleted orphaned volume", "id", id, "name", name)
+		deleted = append(deleted, id)
+	}
+	return deleted, err
+}
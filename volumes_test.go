@@ -0,0 +1,59 @@
+package ionos
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+func TestSweepVolumes(t *testing.T) {
+	volumeLabel := func(id, group string) compute.Label {
+		label := testLabel(id, labelGroup, group)
+		label.Properties.ResourceType = StrPtr("volume")
+		return label
+	}
+
+	tests := []struct {
+		name    string
+		volume  compute.Volume
+		group   string
+		deleted bool
+	}{
+		{name: "labeled", volume: testVolume("labeled", "runner-1-aaaa", "AVAILABLE", false), group: "runner", deleted: true},
+		{name: "labeled with a name outside the prefix", volume: testVolume("renamed", "data", "AVAILABLE", false), group: "runner", deleted: true},
+		{name: "labeled for a group sharing the prefix", volume: testVolume("other-group", "runner-large-1-aaaa", "AVAILABLE", false), group: "runner-large"},
+		{name: "unlabeled with the prefix", volume: testVolume("unlabeled", "runner-2-bbbb", "AVAILABLE", false), deleted: true},
+		{name: "unlabeled without the prefix", volume: testVolume("unrelated", "database", "AVAILABLE", false)},
+		{name: "attached", volume: testVolume("attached", "runner-3-cccc", "AVAILABLE", true), group: "runner"},
+		{name: "busy", volume: testVolume("busy", "runner-4-dddd", "BUSY", false), group: "runner"},
+		{name: "without metadata", volume: compute.Volume{Id: StrPtr("no-metadata"), Properties: &compute.VolumeProperties{Name: StrPtr("runner-5-eeee")}}},
+		{name: "without properties", volume: compute.Volume{Id: StrPtr("no-properties")}},
+		{name: "without ID", volume: compute.Volume{}},
+	}
+
+	api := &mockCompute{}
+	for _, tt := range tests {
+		api.volumes = append(api.volumes, tt.volume)
+		if tt.group != "" {
+			api.labels = append(api.labels, volumeLabel(*tt.volume.Id, tt.group))
+		}
+	}
+	deleted, err := newTestGroup(api).SweepVolumes(context.Background())
+	if err != nil {
+		t.Fatalf("SweepVolumes: %v", err)
+	}
+	for _, tt := range tests {
+		if tt.volume.Id == nil {
+			continue
+		}
+		if got := slices.Contains(deleted, *tt.volume.Id); got != tt.deleted {
+			t.Errorf("%s: deleted %v, want %v", tt.name, got, tt.deleted)
+		}
+	}
+
+	if deleted, err := newTestGroup(&mockCompute{}).SweepVolumes(context.Background()); err != nil || len(deleted) != 0 {
+		t.Errorf("SweepVolumes without volumes = %v, %v, want nothing deleted", deleted, err)
+	}
+}
@@ -0,0 +1,50 @@
+package ionos
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ionos-cloud/sdk-go-bundle/products/compute"
+)
+
+// validateAvailabilityZones checks availability_zones, which cannot be
+// combined with a fixed availability_zone.
+func (i *InstanceGroup) validateAvailabilityZones() error {
+	if len(i.ServerSpec.AvailabilityZones) == 0 {
+		return nil
+	}
+	if i.ServerSpec.AvailabilityZone != "" {
+		return fmt.Errorf("only one of availability_zone/availability_zones can be specified")
+	}
+	for _, zone := range i.ServerSpec.AvailabilityZones {
+		if !slices.Contains([]string{"ZONE_1", "ZONE_2"}, zone) {
+			return fmt.Errorf("availability_zones can contain 'ZONE_1' and 'ZONE_2'")
+		}
+	}
+	return nil
+}
+
+// nextZone picks the availability zone for a new instance, going
+// round-robin over availability_zones. It returns "" if none are configured.
+func (i *InstanceGroup) nextZone() string {
+	zones := i.ServerSpec.AvailabilityZones
+	if len(zones) == 0 {
+		return ""
+	}
+	n := int(i.zoneCursor.Add(1)-1) % len(zones)
+	return zones[n]
+}
+
+// setZone places the server of a create request in the given availability
+// zone.
+func setZone(serverData *compute.Server, zone string) {
+	serverData.Properties.AvailabilityZone = &zone
+}
+
+// serverZone returns the availability zone of a server, if known.
+func serverZone(server compute.Server) string {
+	if server.Properties == nil || server.Properties.AvailabilityZone == nil {
+		return ""
+	}
+	return *server.Properties.AvailabilityZone
+}